@@ -1,6 +1,7 @@
 package jwtauth
 
 import (
+	"context"
 	"errors"
 	"time"
 )
@@ -31,3 +32,15 @@ type JWTAuth interface {
 	// ValidateToken validates a sign and returns token info (decryption)
 	ValidateSign(sign string) (token *Token, err error)
 }
+
+// JWTAuthContext is an interface for auth aware of a context
+// - e.g. the context of a request, propagated to the sessions of the users
+type JWTAuthContext interface {
+	JWTAuth
+
+	// GenerateSignContext generates a new sign from token info (encryption)
+	GenerateSignContext(ctx context.Context, token *Token) (sign string, err error)
+
+	// ValidateSignContext validates a sign and returns token info (decryption)
+	ValidateSignContext(ctx context.Context, sign string) (token *Token, err error)
+}
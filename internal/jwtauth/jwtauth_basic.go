@@ -1,6 +1,7 @@
 package jwtauth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -39,6 +40,18 @@ type JWTAuthBasic struct {
 // - | encryption
 // - sign: token is signed ([]byte or string) with signing method and secret-key
 func (j *JWTAuthBasic) GenerateSign(token *Token) (sign string, err error) {
+	sign, err = j.GenerateSignContext(context.Background(), token)
+	return
+}
+
+// GenerateSignContext generates a new sign from token info (encryption)
+// - signing is not started once the context is done
+func (j *JWTAuthBasic) GenerateSignContext(ctx context.Context, token *Token) (sign string, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrJWTAuthInternal, err)
+		return
+	}
+
 	// -> claims: id, expire date, claims (token-info deserialization)
 	claims := &CustomClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -65,6 +78,18 @@ func (j *JWTAuthBasic) GenerateSign(token *Token) (sign string, err error) {
 // - token/claims: token is parsed with claims from sign (use secret-key and sign method)
 // 				   token-info (id, expire date, claims) serialization
 func (j *JWTAuthBasic) ValidateSign(sign string) (token *Token, err error) {
+	token, err = j.ValidateSignContext(context.Background(), sign)
+	return
+}
+
+// ValidateSignContext validates a sign and returns token info (decryption)
+// - parsing is not started once the context is done
+func (j *JWTAuthBasic) ValidateSignContext(ctx context.Context, sign string) (token *Token, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrJWTAuthInternal, err)
+		return
+	}
+
 	// parse token with claims from sign (using secret-key and sign method) (decryption)
 	var jwttoken *jwt.Token
 	jwttoken, err = jwt.ParseWithClaims(sign, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {return j.config.Secret, nil})
@@ -0,0 +1,42 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithContext returns a JWTAuthContext of a JWTAuth
+// - auths that are already aware of a context are returned as they are
+// - otherwise, the context is only checked before each operation, once done the error wraps ErrJWTAuthInternal and the error of the context
+func WithContext(jw JWTAuth) (j JWTAuthContext) {
+	j, ok := jw.(JWTAuthContext)
+	if !ok {
+		j = &jwtAuthContext{jw}
+	}
+	return
+}
+
+// jwtAuthContext is the adapter of a JWTAuth to the JWTAuthContext interface
+type jwtAuthContext struct {
+	JWTAuth
+}
+
+// GenerateSignContext generates a new sign from token info (encryption)
+func (j *jwtAuthContext) GenerateSignContext(ctx context.Context, token *Token) (sign string, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrJWTAuthInternal, err)
+		return
+	}
+	sign, err = j.GenerateSign(token)
+	return
+}
+
+// ValidateSignContext validates a sign and returns token info (decryption)
+func (j *jwtAuthContext) ValidateSignContext(ctx context.Context, sign string) (token *Token, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrJWTAuthInternal, err)
+		return
+	}
+	token, err = j.ValidateSign(sign)
+	return
+}
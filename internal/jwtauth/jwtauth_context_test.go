@@ -0,0 +1,46 @@
+package jwtauth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// jwtAuthOnly hides the context methods of a JWTAuth, so WithContext adapts it
+type jwtAuthOnly struct {
+	JWTAuth
+}
+
+// Tests for WithContext
+func TestWithContext(t *testing.T) {
+	t.Run("success - context aware auths are returned as they are", func(t *testing.T) {
+		// arrange
+		jw := NewJWTAuthMock()
+
+		// act
+		j := WithContext(jw)
+
+		// assert
+		require.Same(t, jw, j)
+	})
+
+	t.Run("failure - done context is wrapped as an internal error", func(t *testing.T) {
+		// arrange
+		jw := NewJWTAuthMock()
+		j := WithContext(jwtAuthOnly{jw})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// act
+		_, errGenerate := j.GenerateSignContext(ctx, &Token{})
+		_, errValidate := j.ValidateSignContext(ctx, "sign")
+
+		// assert
+		require.ErrorIs(t, errGenerate, ErrJWTAuthInternal)
+		require.ErrorIs(t, errGenerate, context.Canceled)
+		require.ErrorIs(t, errValidate, ErrJWTAuthInternal)
+		require.ErrorIs(t, errValidate, context.Canceled)
+		jw.AssertExpectations(t)
+	})
+}
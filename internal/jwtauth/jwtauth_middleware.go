@@ -0,0 +1,53 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/LNMMusic/msauth/pkg/web/response"
+)
+
+// contextKey is the type of the context keys of the package
+type contextKey string
+
+// contextKeyToken is the context key of the validated token
+const contextKeyToken contextKey = "jwtauth.token"
+
+// TokenFromContext returns the validated token set by the Authenticate middleware
+func TokenFromContext(ctx context.Context) (token *Token, ok bool) {
+	token, ok = ctx.Value(contextKeyToken).(*Token)
+	return
+}
+
+// Authenticate returns a middleware that validates the bearer token of the Authorization header
+// - the validated token is set in the request context (see TokenFromContext)
+// - the sign is validated within the request context
+func Authenticate(jw JWTAuth) func(http.Handler) http.Handler {
+	jc := WithContext(jw)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// sign
+			sign, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || sign == "" {
+				response.JSON(w, http.StatusUnauthorized, "missing bearer token")
+				return
+			}
+
+			// validate
+			token, err := jc.ValidateSignContext(r.Context(), sign)
+			if err != nil {
+				switch {
+				case errors.Is(err, ErrJWTAuthUnauthorized), errors.Is(err, ErrJWTExpired):
+					response.JSON(w, http.StatusUnauthorized, "unauthorized")
+				default:
+					response.JSON(w, http.StatusInternalServerError, "internal server error")
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKeyToken, token)))
+		})
+	}
+}
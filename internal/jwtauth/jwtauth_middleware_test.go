@@ -0,0 +1,98 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests for Authenticate
+func TestAuthenticate(t *testing.T) {
+	type input struct { authorization string }
+	type output struct { code int; body string; token *Token }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUpJWTAuth func(mk *JWTAuthMock)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case",
+			input: input{authorization: "Bearer sign"},
+			output: output{code: http.StatusOK, body: "", token: &Token{ID: "token_id", Claims: map[string]any{"user_id": "1"}}},
+			setUpJWTAuth: func(mk *JWTAuthMock) {
+				mk.On("ValidateSign", "sign").Return(&Token{ID: "token_id", Claims: map[string]any{"user_id": "1"}}, nil)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "missing bearer token",
+			input: input{authorization: ""},
+			output: output{code: http.StatusUnauthorized, body: `"missing bearer token"`},
+			setUpJWTAuth: func(mk *JWTAuthMock) {},
+		},
+		{
+			title: "unauthorized token",
+			input: input{authorization: "Bearer sign"},
+			output: output{code: http.StatusUnauthorized, body: `"unauthorized"`},
+			setUpJWTAuth: func(mk *JWTAuthMock) {
+				mk.On("ValidateSign", "sign").Return((*Token)(nil), ErrJWTAuthUnauthorized)
+			},
+		},
+		{
+			title: "expired token",
+			input: input{authorization: "Bearer sign"},
+			output: output{code: http.StatusUnauthorized, body: `"unauthorized"`},
+			setUpJWTAuth: func(mk *JWTAuthMock) {
+				mk.On("ValidateSign", "sign").Return((*Token)(nil), ErrJWTExpired)
+			},
+		},
+		{
+			title: "internal error",
+			input: input{authorization: "Bearer sign"},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUpJWTAuth: func(mk *JWTAuthMock) {
+				mk.On("ValidateSign", "sign").Return((*Token)(nil), ErrJWTAuthInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			jw := NewJWTAuthMock()
+			c.setUpJWTAuth(jw)
+			var token *Token
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token, _ = TokenFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+			hd := Authenticate(jw)(next)
+
+			// act
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.input.authorization != "" {
+				req.Header.Set("Authorization", c.input.authorization)
+			}
+			res := httptest.NewRecorder()
+			hd.ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			if c.output.body != "" {
+				assert.JSONEq(t, c.output.body, res.Body.String())
+			}
+			assert.Equal(t, c.output.token, token)
+			jw.AssertExpectations(t)
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package jwtauth
 
-import "github.com/stretchr/testify/mock"
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
 
 // constructor
 func NewJWTAuthMock() *JWTAuthMock {
@@ -25,4 +29,22 @@ func (j *JWTAuthMock) ValidateSign(sign string) (token *Token, err error) {
 	token = args.Get(0).(*Token)
 	err = args.Error(1)
 	return
-}
\ No newline at end of file
+}
+
+// GenerateSignContext returns the error of a done context, otherwise the GenerateSign mock is called
+func (j *JWTAuthMock) GenerateSignContext(ctx context.Context, token *Token) (sign string, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	sign, err = j.GenerateSign(token)
+	return
+}
+
+// ValidateSignContext returns the error of a done context, otherwise the ValidateSign mock is called
+func (j *JWTAuthMock) ValidateSignContext(ctx context.Context, sign string) (token *Token, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	token, err = j.ValidateSign(sign)
+	return
+}
@@ -1,6 +1,7 @@
 package jwtauth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -11,8 +12,8 @@ import (
 // constructor
 func NewJWTAuthSessions(jw JWTAuth, ss sessionauth.SessionAuthManager) *JWTAuthSessions {
 	return &JWTAuthSessions{
-		jw: jw,
-		ss: ss,
+		jw: WithContext(jw),
+		ss: sessionauth.WithContext(ss),
 	}
 }
 
@@ -21,15 +22,21 @@ func NewJWTAuthSessions(jw JWTAuth, ss sessionauth.SessionAuthManager) *JWTAuthS
 // - It use decorator pattern to add session functionality to JWTAuth interface
 type JWTAuthSessions struct {
 	// JWTAuth interface to generate and validate signs
-	jw JWTAuth
+	jw JWTAuthContext
 	// SessionAuthManager interface to handle sessions
-	ss sessionauth.SessionAuthManager
+	ss sessionauth.SessionAuthManagerContext
 }
 
 // GenerateSign generates a new sign from token info (encryption) and tracks the session by user id
 func (j *JWTAuthSessions) GenerateSign(token *Token) (sign string, err error) {
+	sign, err = j.GenerateSignContext(context.Background(), token)
+	return
+}
+
+// GenerateSignContext generates a new sign from token info (encryption) and tracks the session by user id
+func (j *JWTAuthSessions) GenerateSignContext(ctx context.Context, token *Token) (sign string, err error) {
 	// generate sign
-	sign, err = j.jw.GenerateSign(token)
+	sign, err = j.jw.GenerateSignContext(ctx, token)
 	if err != nil {
 		return
 	}
@@ -46,7 +53,7 @@ func (j *JWTAuthSessions) GenerateSign(token *Token) (sign string, err error) {
 		ExpireDate: token.ExpireDate,
 	}
 
-	err = j.ss.GenerateSession(userID, session)
+	err = j.ss.GenerateSessionContext(ctx, userID, session)
 	if err != nil {
 		sign = ""
 		switch {
@@ -63,8 +70,14 @@ func (j *JWTAuthSessions) GenerateSign(token *Token) (sign string, err error) {
 
 // ValidateSign validates a sign and returns token info (decryption) and validates the session
 func (j *JWTAuthSessions) ValidateSign(sign string) (token *Token, err error) {
+	token, err = j.ValidateSignContext(context.Background(), sign)
+	return
+}
+
+// ValidateSignContext validates a sign and returns token info (decryption) and validates the session
+func (j *JWTAuthSessions) ValidateSignContext(ctx context.Context, sign string) (token *Token, err error) {
 	// validate sign
-	token, err = j.jw.ValidateSign(sign)
+	token, err = j.jw.ValidateSignContext(ctx, sign)
 	if err != nil {
 		return
 	}
@@ -81,7 +94,7 @@ func (j *JWTAuthSessions) ValidateSign(sign string) (token *Token, err error) {
 		ExpireDate: token.ExpireDate,
 	}
 
-	err = j.ss.ValidateSession(userID, session.TokenID)
+	err = j.ss.ValidateSessionContext(ctx, userID, session.TokenID)
 	if err != nil {
 		token = nil
 		switch {
@@ -0,0 +1,29 @@
+package jwtauth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// NewToken returns a new token for a user with a random id that expires after ttl
+// - the user id is set in the "user_id" claim, as expected by JWTAuthSessions
+func NewToken(userID string, ttl time.Duration) (token *Token, err error) {
+	// random id
+	b := make([]byte, 16)
+	_, err = rand.Read(b)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrJWTAuthInternal, err)
+		return
+	}
+
+	token = &Token{
+		ID: hex.EncodeToString(b),
+		ExpireDate: time.Now().Add(ttl),
+		Claims: map[string]any{
+			"user_id": userID,
+		},
+	}
+	return
+}
@@ -1,6 +1,7 @@
 package sessionauth
 
 import (
+	"context"
 	"errors"
 
 	"github.com/LNMMusic/msauth/internal/session"
@@ -20,4 +21,28 @@ type SessionAuthManager interface {
 
 	// ValidateSession validates a session for a user
 	ValidateSession(userId string, tokenId string) (err error)
-}
\ No newline at end of file
+
+	// RevokeSessions revokes all sessions for a user
+	RevokeSessions(userId string) (err error)
+
+	// RevokeOtherSessions revokes all sessions for a user except the one of a token
+	RevokeOtherSessions(userId string, tokenId string) (err error)
+}
+
+// SessionAuthManagerContext is an interface for session auth managers aware of a context
+// - the context is propagated to the storage of the sessions
+type SessionAuthManagerContext interface {
+	SessionAuthManager
+
+	// GenerateSessionContext generates a new session for a user
+	GenerateSessionContext(ctx context.Context, userId string, s *session.Session) (err error)
+
+	// ValidateSessionContext validates a session for a user
+	ValidateSessionContext(ctx context.Context, userId string, tokenId string) (err error)
+
+	// RevokeSessionsContext revokes all sessions for a user
+	RevokeSessionsContext(ctx context.Context, userId string) (err error)
+
+	// RevokeOtherSessionsContext revokes all sessions for a user except the one of a token
+	RevokeOtherSessionsContext(ctx context.Context, userId string, tokenId string) (err error)
+}
@@ -0,0 +1,64 @@
+package sessionauth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LNMMusic/msauth/internal/session"
+)
+
+// WithContext returns a SessionAuthManagerContext of a SessionAuthManager
+// - managers that are already aware of a context are returned as they are
+// - otherwise, the context is only checked before each operation, once done the error wraps ErrSessionAuthManagerInternal and the error of the context
+func WithContext(sa SessionAuthManager) (s SessionAuthManagerContext) {
+	s, ok := sa.(SessionAuthManagerContext)
+	if !ok {
+		s = &sessionAuthManagerContext{sa}
+	}
+	return
+}
+
+// sessionAuthManagerContext is the adapter of a SessionAuthManager to the SessionAuthManagerContext interface
+type sessionAuthManagerContext struct {
+	SessionAuthManager
+}
+
+// GenerateSessionContext generates a new session for a user
+func (s *sessionAuthManagerContext) GenerateSessionContext(ctx context.Context, userId string, se *session.Session) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrSessionAuthManagerInternal, err)
+		return
+	}
+	err = s.GenerateSession(userId, se)
+	return
+}
+
+// ValidateSessionContext validates a session for a user
+func (s *sessionAuthManagerContext) ValidateSessionContext(ctx context.Context, userId string, tokenId string) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrSessionAuthManagerInternal, err)
+		return
+	}
+	err = s.ValidateSession(userId, tokenId)
+	return
+}
+
+// RevokeSessionsContext revokes all sessions for a user
+func (s *sessionAuthManagerContext) RevokeSessionsContext(ctx context.Context, userId string) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrSessionAuthManagerInternal, err)
+		return
+	}
+	err = s.RevokeSessions(userId)
+	return
+}
+
+// RevokeOtherSessionsContext revokes all sessions for a user except the one of a token
+func (s *sessionAuthManagerContext) RevokeOtherSessionsContext(ctx context.Context, userId string, tokenId string) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrSessionAuthManagerInternal, err)
+		return
+	}
+	err = s.RevokeOtherSessions(userId, tokenId)
+	return
+}
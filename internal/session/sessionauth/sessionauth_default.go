@@ -1,7 +1,10 @@
 package sessionauth
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/LNMMusic/msauth/internal/session"
@@ -17,8 +20,10 @@ func NewSessionAuthManagerDefault(st storage.Storage, config *Config) *SessionAu
 	}
 
 	return &SessionAuthManagerDefault{
-		st: st,
+		st: storage.WithContext(st),
 		config:  config,
+		mu: &sync.Mutex{},
+		users: make(map[string]*userLock),
 	}
 }
 
@@ -30,14 +35,66 @@ type Config struct {
 
 type SessionAuthManagerDefault struct {
 	// st is the storage for sessions (get and set operations)
-	st storage.Storage
+	st storage.StorageContext
 	config  *Config
+	// mu is the mutex of users
+	mu *sync.Mutex
+	// users are the locks of the users with session changes in progress
+	// - changes read and then set all the sessions of a user, so they are serialized per user
+	// to not lose a concurrent change (e.g. a session generated while the sessions are revoked)
+	users map[string]*userLock
+}
+
+// userLock is the lock of a user
+type userLock struct {
+	// mu serializes the session changes of the user
+	mu sync.Mutex
+	// refs is the number of changes holding or waiting for the lock
+	refs int
+}
+
+// lock locks the session changes of a user and returns the function to unlock them
+func (sa *SessionAuthManagerDefault) lock(userId string) (unlock func()) {
+	sa.mu.Lock()
+	l, ok := sa.users[userId]
+	if !ok {
+		l = &userLock{}
+		sa.users[userId] = l
+	}
+	l.refs++
+	sa.mu.Unlock()
+
+	l.mu.Lock()
+	unlock = func() {
+		l.mu.Unlock()
+
+		sa.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(sa.users, userId)
+		}
+		sa.mu.Unlock()
+	}
+	return
 }
 
 // GenerateSession generates a new session for a user
 func (sa *SessionAuthManagerDefault) GenerateSession(userId string, s *session.Session) (err error) {
+	err = sa.GenerateSessionContext(context.Background(), userId, s)
+	return
+}
+
+// GenerateSessionContext generates a new session for a user
+func (sa *SessionAuthManagerDefault) GenerateSessionContext(ctx context.Context, userId string, s *session.Session) (err error) {
+	unlock := sa.lock(userId)
+	defer unlock()
+
 	// get all sessions for a user
-	sessions, err := sa.st.Get(userId)
+	// - a user without stored sessions has no sessions yet (e.g. its first sign in)
+	sessions, err := sa.st.GetContext(ctx, userId)
+	if errors.Is(err, storage.ErrStorageUserNotFound) {
+		sessions, err = nil, nil
+	}
 	if err != nil {
 		err = fmt.Errorf("%w. %s", ErrSessionAuthManagerInternal, err.Error())
 		return
@@ -63,7 +120,7 @@ func (sa *SessionAuthManagerDefault) GenerateSession(userId string, s *session.S
 	syncedSessions = append(syncedSessions, s)
 
 	// set sessions
-	err = sa.st.Set(userId, syncedSessions)
+	err = sa.st.SetContext(ctx, userId, syncedSessions)
 	if err != nil {
 		err = fmt.Errorf("%w. %s", ErrSessionAuthManagerInternal, err.Error())
 		return
@@ -74,8 +131,18 @@ func (sa *SessionAuthManagerDefault) GenerateSession(userId string, s *session.S
 
 // ValidateSession validates a session for a user
 func (sa *SessionAuthManagerDefault) ValidateSession(userId string, tokenId string) (err error) {
+	err = sa.ValidateSessionContext(context.Background(), userId, tokenId)
+	return
+}
+
+// ValidateSessionContext validates a session for a user
+func (sa *SessionAuthManagerDefault) ValidateSessionContext(ctx context.Context, userId string, tokenId string) (err error) {
 	// get all sessions for a user
-	sessions, err := sa.st.Get(userId)
+	// - a user without stored sessions has no sessions yet (e.g. its first sign in)
+	sessions, err := sa.st.GetContext(ctx, userId)
+	if errors.Is(err, storage.ErrStorageUserNotFound) {
+		sessions, err = nil, nil
+	}
 	if err != nil {
 		err = fmt.Errorf("%w. %s", ErrSessionAuthManagerInternal, err.Error())
 		return
@@ -105,4 +172,65 @@ func (sa *SessionAuthManagerDefault) ValidateSession(userId string, tokenId stri
 	}
 
 	return
-}
\ No newline at end of file
+}
+
+// RevokeSessions revokes all sessions for a user
+func (sa *SessionAuthManagerDefault) RevokeSessions(userId string) (err error) {
+	err = sa.RevokeSessionsContext(context.Background(), userId)
+	return
+}
+
+// RevokeSessionsContext revokes all sessions for a user
+func (sa *SessionAuthManagerDefault) RevokeSessionsContext(ctx context.Context, userId string) (err error) {
+	unlock := sa.lock(userId)
+	defer unlock()
+
+	// set empty sessions
+	err = sa.st.SetContext(ctx, userId, []*session.Session{})
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrSessionAuthManagerInternal, err.Error())
+		return
+	}
+
+	return
+}
+
+// RevokeOtherSessions revokes all sessions for a user except the one of a token
+func (sa *SessionAuthManagerDefault) RevokeOtherSessions(userId string, tokenId string) (err error) {
+	err = sa.RevokeOtherSessionsContext(context.Background(), userId, tokenId)
+	return
+}
+
+// RevokeOtherSessionsContext revokes all sessions for a user except the one of a token
+func (sa *SessionAuthManagerDefault) RevokeOtherSessionsContext(ctx context.Context, userId string, tokenId string) (err error) {
+	unlock := sa.lock(userId)
+	defer unlock()
+
+	// get all sessions for a user
+	// - a user without stored sessions has no sessions yet (e.g. its first sign in)
+	sessions, err := sa.st.GetContext(ctx, userId)
+	if errors.Is(err, storage.ErrStorageUserNotFound) {
+		sessions, err = nil, nil
+	}
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrSessionAuthManagerInternal, err.Error())
+		return
+	}
+
+	// keep the session of the token
+	keptSessions := []*session.Session{}
+	for _, session := range sessions {
+		if session.TokenID == tokenId && session.ExpireDate.After(time.Now()) {
+			keptSessions = append(keptSessions, session)
+		}
+	}
+
+	// set sessions
+	err = sa.st.SetContext(ctx, userId, keptSessions)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrSessionAuthManagerInternal, err.Error())
+		return
+	}
+
+	return
+}
@@ -1,15 +1,57 @@
 package sessionauth
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/LNMMusic/msauth/internal/session"
 	"github.com/LNMMusic/msauth/internal/session/storage"
+	"github.com/LNMMusic/optional"
 	"github.com/stretchr/testify/assert"
 )
 
+// storageSlow is a StorageLocal that takes its time to return the sessions, so concurrent changes interleave
+type storageSlow struct {
+	*storage.StorageLocal
+}
+
+// GetContext returns all sessions for a user, a while after reading them
+func (s *storageSlow) GetContext(ctx context.Context, userId string) (sessions []*session.Session, err error) {
+	sessions, err = s.StorageLocal.GetContext(ctx, userId)
+	time.Sleep(time.Millisecond)
+	return
+}
+
 // Tests for SessionAuthManagerDefault
+func TestSessionAuthManagerDefault_Concurrent(t *testing.T) {
+	t.Run("concurrent changes of a user are not lost", func(t *testing.T) {
+		// arrange
+		st := storage.NewStorageLocal(map[string][]*session.Session{"user-id": {}})
+		ss := NewSessionAuthManagerDefault(&storageSlow{st}, &Config{MaxSessionsPerUser: optional.Some(100)})
+
+		// act
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				err := ss.GenerateSession("user-id", &session.Session{TokenID: fmt.Sprintf("token-%d", i), ExpireDate: time.Now().Add(time.Hour)})
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+
+		// assert
+		sessions, err := st.Get("user-id")
+		assert.NoError(t, err)
+		assert.Len(t, sessions, 50)
+		assert.Empty(t, ss.users, "the locks of the users are released")
+	})
+}
+
 func TestSessionAuthManagerDefault_GenerateSession(t *testing.T) {
 	type input struct {userId string; s *session.Session}
 	type output struct {err error; errMsg string}
@@ -67,6 +109,24 @@ func TestSessionAuthManagerDefault_GenerateSession(t *testing.T) {
 			},
 			setUpConfig: func(cfg *Config) {},
 		},
+		{
+			title: "success - user without stored sessions",
+			input: input{userId: "user-id", s: &session.Session{
+				TokenID: "token-id",
+				ExpireDate: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			}},
+			output: output{err: nil, errMsg: ""},
+			setUpStorage: func(mk *storage.StorageMock) {
+				mk.On("Get", "user-id").Return([]*session.Session{}, storage.ErrStorageUserNotFound)
+				mk.On("Set", "user-id", []*session.Session{
+					{
+						TokenID: "token-id",
+						ExpireDate: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+					},
+				}).Return(nil)
+			},
+			setUpConfig: func(cfg *Config) {},
+		},
 
 		// invalid cases
 		// -> storage
@@ -204,6 +264,14 @@ func TestSessionAuthManagerDefault_ValidateSession(t *testing.T) {
 				mk.On("Get", "user-id").Return([]*session.Session{}, nil)
 			},
 			setUpConfig: func(cfg *Config) {},
+		},		{
+			title: "validation error - user without stored sessions",
+			input: input{userId: "user-id", tokenId: "token-id"},
+			output: output{err: ErrSessionAuthManagerUnauthorized, errMsg: "unauthorized session. token-id"},
+			setUpStorage: func(mk *storage.StorageMock) {
+				mk.On("Get", "user-id").Return([]*session.Session{}, storage.ErrStorageUserNotFound)
+			},
+			setUpConfig: func(cfg *Config) {},
 		},
 	}
 
@@ -229,4 +297,141 @@ func TestSessionAuthManagerDefault_ValidateSession(t *testing.T) {
 			st.AssertExpectations(t)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestSessionAuthManagerDefault_RevokeSessions(t *testing.T) {
+	type input struct {userId string}
+	type output struct {err error; errMsg string}
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUpStorage func(mk *storage.StorageMock)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "success",
+			input: input{userId: "user-id"},
+			output: output{err: nil, errMsg: ""},
+			setUpStorage: func(mk *storage.StorageMock) {
+				mk.On("Set", "user-id", []*session.Session{}).Return(nil)
+			},
+		},
+
+		// invalid cases
+		// -> storage
+		{
+			title: "storage error - set",
+			input: input{userId: "user-id"},
+			output: output{err: ErrSessionAuthManagerInternal, errMsg: "internal session auth manager error. internal storage error"},
+			setUpStorage: func(mk *storage.StorageMock) {
+				mk.On("Set", "user-id", []*session.Session{}).Return(storage.ErrStorageInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			st := storage.NewStorageMock()
+			c.setUpStorage(st)
+			ss := NewSessionAuthManagerDefault(st, &Config{})
+
+			// act
+			err := ss.RevokeSessions(c.input.userId)
+
+			// assert
+			assert.ErrorIs(t, err, c.output.err)
+			if c.output.err != nil {
+				assert.Equal(t, c.output.errMsg, err.Error())
+			}
+			st.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSessionAuthManagerDefault_RevokeOtherSessions(t *testing.T) {
+	type input struct {userId string; tokenId string}
+	type output struct {err error; errMsg string}
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUpStorage func(mk *storage.StorageMock)
+	}
+
+	expireDate := time.Now().Add(1 * time.Hour)
+	cases := []testCase{
+		// valid cases
+		{
+			title: "success - keeps the session of the token",
+			input: input{userId: "user-id", tokenId: "token-id"},
+			output: output{err: nil, errMsg: ""},
+			setUpStorage: func(mk *storage.StorageMock) {
+				mk.On("Get", "user-id").Return([]*session.Session{
+					{TokenID: "other-token-id", ExpireDate: expireDate},
+					{TokenID: "token-id", ExpireDate: expireDate},
+				}, nil)
+				mk.On("Set", "user-id", []*session.Session{
+					{TokenID: "token-id", ExpireDate: expireDate},
+				}).Return(nil)
+			},
+		},
+		{
+			title: "success - user without stored sessions",
+			input: input{userId: "user-id", tokenId: "token-id"},
+			output: output{err: nil, errMsg: ""},
+			setUpStorage: func(mk *storage.StorageMock) {
+				mk.On("Get", "user-id").Return([]*session.Session{}, storage.ErrStorageUserNotFound)
+				mk.On("Set", "user-id", []*session.Session{}).Return(nil)
+			},
+		},
+
+		// invalid cases
+		// -> storage
+		{
+			title: "storage error - get",
+			input: input{userId: "user-id", tokenId: "token-id"},
+			output: output{err: ErrSessionAuthManagerInternal, errMsg: "internal session auth manager error. internal storage error"},
+			setUpStorage: func(mk *storage.StorageMock) {
+				mk.On("Get", "user-id").Return([]*session.Session{}, storage.ErrStorageInternal)
+			},
+		},
+		{
+			title: "storage error - set",
+			input: input{userId: "user-id", tokenId: "token-id"},
+			output: output{err: ErrSessionAuthManagerInternal, errMsg: "internal session auth manager error. internal storage error"},
+			setUpStorage: func(mk *storage.StorageMock) {
+				mk.On("Get", "user-id").Return([]*session.Session{}, nil)
+				mk.On("Set", "user-id", []*session.Session{}).Return(storage.ErrStorageInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			st := storage.NewStorageMock()
+			c.setUpStorage(st)
+			ss := NewSessionAuthManagerDefault(st, &Config{})
+
+			// act
+			err := ss.RevokeOtherSessions(c.input.userId, c.input.tokenId)
+
+			// assert
+			assert.ErrorIs(t, err, c.output.err)
+			if c.output.err != nil {
+				assert.Equal(t, c.output.errMsg, err.Error())
+			}
+			st.AssertExpectations(t)
+		})
+	}
+}
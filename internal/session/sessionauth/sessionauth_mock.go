@@ -1,6 +1,8 @@
 package sessionauth
 
 import (
+	"context"
+
 	"github.com/LNMMusic/msauth/internal/session"
 	"github.com/stretchr/testify/mock"
 )
@@ -25,4 +27,52 @@ func (m *SessionAuthMock) ValidateSession(userID string, sessionID string) (err
 	args := m.Called(userID, sessionID)
 	err = args.Error(0)
 	return
-}
\ No newline at end of file
+}
+
+func (m *SessionAuthMock) RevokeSessions(userID string) (err error) {
+	args := m.Called(userID)
+	err = args.Error(0)
+	return
+}
+
+func (m *SessionAuthMock) RevokeOtherSessions(userID string, tokenID string) (err error) {
+	args := m.Called(userID, tokenID)
+	err = args.Error(0)
+	return
+}
+
+// GenerateSessionContext returns the error of a done context, otherwise the GenerateSession mock is called
+func (m *SessionAuthMock) GenerateSessionContext(ctx context.Context, userID string, session *session.Session) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.GenerateSession(userID, session)
+	return
+}
+
+// ValidateSessionContext returns the error of a done context, otherwise the ValidateSession mock is called
+func (m *SessionAuthMock) ValidateSessionContext(ctx context.Context, userID string, sessionID string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.ValidateSession(userID, sessionID)
+	return
+}
+
+// RevokeSessionsContext returns the error of a done context, otherwise the RevokeSessions mock is called
+func (m *SessionAuthMock) RevokeSessionsContext(ctx context.Context, userID string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.RevokeSessions(userID)
+	return
+}
+
+// RevokeOtherSessionsContext returns the error of a done context, otherwise the RevokeOtherSessions mock is called
+func (m *SessionAuthMock) RevokeOtherSessionsContext(ctx context.Context, userID string, tokenID string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.RevokeOtherSessions(userID, tokenID)
+	return
+}
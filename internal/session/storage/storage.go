@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"errors"
 
 	"github.com/LNMMusic/msauth/internal/session"
@@ -21,3 +22,15 @@ type Storage interface {
 	// Set sets sessions for a user
 	Set(userId string, session []*session.Session) (err error)
 }
+
+// StorageContext is an interface for storages aware of a context
+// - operations are not started, or are abandoned, once the context is done
+type StorageContext interface {
+	Storage
+
+	// GetContext returns all sessions for a user
+	GetContext(ctx context.Context, userId string) (s []*session.Session, err error)
+
+	// SetContext sets sessions for a user
+	SetContext(ctx context.Context, userId string, session []*session.Session) (err error)
+}
@@ -0,0 +1,15 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/session/storage"
+	"github.com/LNMMusic/msauth/internal/session/storage/storagetest"
+)
+
+// Conformance tests for StorageLocal
+func TestStorageLocal_Conformance(t *testing.T) {
+	storagetest.RunStorage(t, func(t *testing.T) storage.Storage {
+		return storage.NewStorageLocal(nil)
+	})
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LNMMusic/msauth/internal/session"
+)
+
+// WithContext returns a StorageContext of a Storage
+// - storages that are already aware of a context are returned as they are
+// - otherwise, the context is only checked before each operation, once done the error wraps ErrStorageInternal and the error of the context
+func WithContext(st Storage) (s StorageContext) {
+	s, ok := st.(StorageContext)
+	if !ok {
+		s = &storageContext{st}
+	}
+	return
+}
+
+// storageContext is the adapter of a Storage to the StorageContext interface
+type storageContext struct {
+	Storage
+}
+
+// GetContext returns all sessions for a user
+func (s *storageContext) GetContext(ctx context.Context, userId string) (sessions []*session.Session, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	sessions, err = s.Get(userId)
+	return
+}
+
+// SetContext sets sessions for a user
+func (s *storageContext) SetContext(ctx context.Context, userId string, sessions []*session.Session) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = s.Set(userId, sessions)
+	return
+}
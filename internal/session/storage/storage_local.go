@@ -1,35 +1,71 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
 	"github.com/LNMMusic/msauth/internal/session"
 )
 
 // constructor
 func NewStorageLocal(db map[string][]*session.Session) *StorageLocal {
-	return &StorageLocal{db: db}
+	// default config
+	if db == nil {
+		db = make(map[string][]*session.Session)
+	}
+
+	return &StorageLocal{db: db, mu: &sync.RWMutex{}}
 }
 
 // StorageLocal is a local implementation of Storage interface
 type StorageLocal struct {
 	db map[string][]*session.Session
+	// mu guards the db
+	mu *sync.RWMutex
 }
 
 // Get returns all sessions for a user
+// - the slice is a copy, so it can be modified by the caller
 func (s *StorageLocal) Get(userId string) (sessions []*session.Session, err error) {
-	var ok bool
-	sessions, ok = s.db[userId]
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.db[userId]
 	if !ok {
 		err = fmt.Errorf("%w: %s", ErrStorageUserNotFound, userId)
 		return
 	}
+	sessions = append([]*session.Session{}, stored...)
 
 	return
 }
 
 // Set sets sessions for a user
 func (s *StorageLocal) Set(userId string, sessions []*session.Session) (err error) {
-	s.db[userId] = sessions
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.db[userId] = append([]*session.Session{}, sessions...)
+	return
+}
+
+// GetContext returns all sessions for a user
+func (s *StorageLocal) GetContext(ctx context.Context, userId string) (sessions []*session.Session, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	sessions, err = s.Get(userId)
+	return
+}
+
+// SetContext sets sessions for a user
+func (s *StorageLocal) SetContext(ctx context.Context, userId string, sessions []*session.Session) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = s.Set(userId, sessions)
 	return
 }
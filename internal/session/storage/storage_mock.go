@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"context"
+
 	"github.com/LNMMusic/msauth/internal/session"
 	"github.com/stretchr/testify/mock"
 )
@@ -26,4 +28,22 @@ func (st *StorageMock) Set(userId string, session []*session.Session) (err error
 	args := st.Called(userId, session)
 	err = args.Error(0)
 	return
-}
\ No newline at end of file
+}
+
+// GetContext returns the error of a done context, otherwise the Get mock is called
+func (st *StorageMock) GetContext(ctx context.Context, userId string) (s []*session.Session, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	s, err = st.Get(userId)
+	return
+}
+
+// SetContext returns the error of a done context, otherwise the Set mock is called
+func (st *StorageMock) SetContext(ctx context.Context, userId string, session []*session.Session) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = st.Set(userId, session)
+	return
+}
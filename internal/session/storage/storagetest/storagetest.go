@@ -0,0 +1,153 @@
+// Package storagetest implements a conformance suite for the session storages
+// - every implementation of storage.Storage should pass it, e.g. a new Redis backend
+//
+//	func TestStorageRedis(t *testing.T) {
+//		storagetest.RunStorage(t, func(t *testing.T) storage.Storage { ... })
+//	}
+package storagetest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/session"
+	"github.com/LNMMusic/msauth/internal/session/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewStorage returns a new empty storage
+// - it is called once per case, each case must get an isolated storage
+type NewStorage func(t *testing.T) storage.Storage
+
+// RunStorage runs the conformance suite of storage.Storage
+func RunStorage(t *testing.T, newStorage NewStorage) {
+	expireDate := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("set sessions are returned by get", func(t *testing.T) {
+		// arrange
+		st := newStorage(t)
+		sessions := []*session.Session{
+			{TokenID: "token1", ExpireDate: expireDate},
+			{TokenID: "token2", ExpireDate: expireDate.Add(time.Hour)},
+		}
+
+		// act
+		err := st.Set("user1", sessions)
+
+		// assert
+		require.NoError(t, err)
+		got, err := st.Get("user1")
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		for i := range sessions {
+			require.Equal(t, sessions[i].TokenID, got[i].TokenID)
+			require.True(t, sessions[i].ExpireDate.Equal(got[i].ExpireDate))
+		}
+	})
+
+	t.Run("set replaces the sessions of the user", func(t *testing.T) {
+		// arrange
+		st := newStorage(t)
+		require.NoError(t, st.Set("user1", []*session.Session{{TokenID: "token1", ExpireDate: expireDate}}))
+
+		// act
+		err := st.Set("user1", []*session.Session{{TokenID: "token2", ExpireDate: expireDate}})
+
+		// assert
+		require.NoError(t, err)
+		got, err := st.Get("user1")
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		require.Equal(t, "token2", got[0].TokenID)
+	})
+
+	t.Run("users with no sessions are found", func(t *testing.T) {
+		// arrange
+		st := newStorage(t)
+
+		// act
+		err := st.Set("user1", []*session.Session{})
+
+		// assert
+		require.NoError(t, err)
+		got, err := st.Get("user1")
+		require.NoError(t, err)
+		require.Empty(t, got)
+	})
+
+	t.Run("expired sessions are kept, the storage does not sync them", func(t *testing.T) {
+		// arrange
+		st := newStorage(t)
+		expired := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		// act
+		err := st.Set("user1", []*session.Session{{TokenID: "token1", ExpireDate: expired}})
+
+		// assert
+		require.NoError(t, err)
+		got, err := st.Get("user1")
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		require.True(t, expired.Equal(got[0].ExpireDate))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		// arrange
+		st := newStorage(t)
+		require.NoError(t, st.Set("user1", []*session.Session{{TokenID: "token1", ExpireDate: expireDate}}))
+
+		// act
+		_, err := st.Get("user2")
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageUserNotFound)
+	})
+
+	t.Run("users are isolated", func(t *testing.T) {
+		// arrange
+		st := newStorage(t)
+		require.NoError(t, st.Set("user1", []*session.Session{{TokenID: "token1", ExpireDate: expireDate}}))
+
+		// act
+		err := st.Set("user2", []*session.Session{{TokenID: "token2", ExpireDate: expireDate}})
+
+		// assert
+		require.NoError(t, err)
+		got, err := st.Get("user1")
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		require.Equal(t, "token1", got[0].TokenID)
+	})
+
+	t.Run("concurrent sets and gets", func(t *testing.T) {
+		// arrange
+		st := newStorage(t)
+		n := 50
+
+		// act
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				userId := fmt.Sprintf("user%d", i)
+				assert.NoError(t, st.Set(userId, []*session.Session{{TokenID: userId, ExpireDate: expireDate}}))
+				got, err := st.Get(userId)
+				if assert.NoError(t, err) && assert.Len(t, got, 1) {
+					assert.Equal(t, userId, got[0].TokenID)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		// assert
+		for i := 0; i < n; i++ {
+			got, err := st.Get(fmt.Sprintf("user%d", i))
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+		}
+	})
+}
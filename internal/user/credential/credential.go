@@ -1,6 +1,9 @@
 package credential
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 var (
 	// ErrCredentialInternal is returned when an internal error occurs
@@ -11,8 +14,28 @@ var (
 	ErrCredentialEmailNotFound = errors.New("credential email not found")
 	// ErrCredentialPasswordInvalid is returned when the password is invalid
 	ErrCredentialPasswordInvalid = errors.New("credential password invalid")
+	// ErrCredentialUserInactive is returned when the credential is valid but the user is not active (email not verified)
+	ErrCredentialUserInactive = errors.New("credential user inactive")
+	// ErrCredentialInvalid is the generic error exposed to callers when the credential is invalid
+	// - it hides whether the username/email was not found or the password was invalid
+	ErrCredentialInvalid = errors.New("credential invalid")
+	// ErrCredentialLocked is returned when the credential is locked after repeated failed attempts
+	ErrCredentialLocked = errors.New("credential locked")
 )
 
+// Generic maps the errors of an invalid credential to ErrCredentialInvalid
+// - errors that do not reveal the existence of the user are returned as they are
+func Generic(err error) (e error) {
+	e = err
+	switch {
+	case errors.Is(err, ErrCredentialUsernameNotFound),
+		errors.Is(err, ErrCredentialEmailNotFound),
+		errors.Is(err, ErrCredentialPasswordInvalid):
+		e = ErrCredentialInvalid
+	}
+	return
+}
+
 // Credential interface for verifying credentials
 type Credential interface {
 	// VerifyByUsername verifies a credential by username
@@ -21,3 +44,15 @@ type Credential interface {
 	// VerifyByEmail verifies a credential by email
 	VerifyByEmail(email string, password string) (err error)
 }
+
+// CredentialContext interface for verifying credentials aware of a context
+// - a verification abandoned because of the context is not an invalid credential
+type CredentialContext interface {
+	Credential
+
+	// VerifyByUsernameContext verifies a credential by username
+	VerifyByUsernameContext(ctx context.Context, username string, password string) (err error)
+
+	// VerifyByEmailContext verifies a credential by email
+	VerifyByEmailContext(ctx context.Context, email string, password string) (err error)
+}
@@ -0,0 +1,42 @@
+package credential
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithContext returns a CredentialContext of a Credential
+// - credentials that are already aware of a context are returned as they are
+// - otherwise, the context is only checked before each verification
+func WithContext(cr Credential) (c CredentialContext) {
+	c, ok := cr.(CredentialContext)
+	if !ok {
+		c = &credentialContext{cr}
+	}
+	return
+}
+
+// credentialContext is the adapter of a Credential to the CredentialContext interface
+type credentialContext struct {
+	Credential
+}
+
+// VerifyByUsernameContext verifies a credential by username
+func (c *credentialContext) VerifyByUsernameContext(ctx context.Context, username string, password string) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrCredentialInternal, err)
+		return
+	}
+	err = c.VerifyByUsername(username, password)
+	return
+}
+
+// VerifyByEmailContext verifies a credential by email
+func (c *credentialContext) VerifyByEmailContext(ctx context.Context, email string, password string) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrCredentialInternal, err)
+		return
+	}
+	err = c.VerifyByEmail(email, password)
+	return
+}
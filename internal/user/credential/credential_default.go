@@ -1,34 +1,58 @@
 package credential
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
 	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/validator"
 	"github.com/LNMMusic/msauth/pkg/crypter"
 )
 
 // NewCredentialDefault returns a new default credential
-func NewCredentialDefault(st storage.StorageRead, cr crypter.Crypter) *CredentialDefault {
-	return &CredentialDefault{
-		st: st,
-		cr: cr,
+// - the dummy hash is generated with the crypter, in case of failure the error wraps ErrCredentialInternal
+func NewCredentialDefault(st storage.StorageRead, cr crypter.Crypter) (c *CredentialDefault, err error) {
+	dummy, err := cr.Encrypt("credential-dummy-password")
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrCredentialInternal, err.Error())
+		return
+	}
+
+	c = &CredentialDefault{
+		st: storage.StorageReadWithContext(st),
+		cr: crypter.WithContext(cr),
+		dummy: dummy,
 	}
+	return
 }
 
 // CredentialDefault is the implementation of the Credential interface
 type CredentialDefault struct {
 	// st is the StorageRead interface
-	st storage.StorageRead
+	st storage.StorageReadContext
 	// cr is the Crypter interface
-	cr crypter.Crypter
+	cr crypter.CrypterContext
+
+	// dummy is a hashed password compared against when the user is not found or has no password
+	// - it makes the response time of unknown users comparable to the one of existing users
+	dummy string
 }
 
 // VerifyByUsername verifies a credential by username
 func (c *CredentialDefault) VerifyByUsername(username string, password string) (err error) {
+	err = c.VerifyByUsernameContext(context.Background(), username, password)
+	return
+}
+
+// VerifyByUsernameContext verifies a credential by username
+// - in case the context is done, the error wraps ErrCredentialInternal and the error of the context
+func (c *CredentialDefault) VerifyByUsernameContext(ctx context.Context, username string, password string) (err error) {
 	// get user from storage
-	u, err := c.st.GetByUsername(username)
+	u, err := c.st.GetByUsernameContext(ctx, username)
 	if err != nil {
 		if errors.Is(err, storage.ErrStorageNotFound) {
+			c.compareDummy(ctx, password)
 			err = ErrCredentialUsernameNotFound
 		}
 
@@ -37,27 +61,50 @@ func (c *CredentialDefault) VerifyByUsername(username string, password string) (
 
 	// check if password is set
 	if !u.Password.IsSome() {
+		c.compareDummy(ctx, password)
 		err = ErrCredentialInternal
 		return
 	}
 	passwordUser, _ := u.Password.Unwrap()
 
 	// check if password matches
-	err = c.cr.Compare(passwordUser, password)
+	// - passwords are stored normalized by the validator
+	err = c.cr.CompareContext(ctx, passwordUser, validator.NormalizePassword(password))
 	if err != nil {
+		// - an abandoned comparison is not an invalid password
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("%w. %w", ErrCredentialInternal, ctxErr)
+			return
+		}
 		err = ErrCredentialPasswordInvalid
 		return
 	}
 
+	// check if user is active
+	// - checked after the password, so it is only revealed to the owner of the credential
+	isActive, _ := u.IsActive.Unwrap()
+	if !isActive {
+		err = ErrCredentialUserInactive
+		return
+	}
+
 	return
 }
 
 // VerifyByEmail verifies a credential by email
 func (c *CredentialDefault) VerifyByEmail(email string, password string) (err error) {
+	err = c.VerifyByEmailContext(context.Background(), email, password)
+	return
+}
+
+// VerifyByEmailContext verifies a credential by email
+// - in case the context is done, the error wraps ErrCredentialInternal and the error of the context
+func (c *CredentialDefault) VerifyByEmailContext(ctx context.Context, email string, password string) (err error) {
 	// get user from storage
-	u, err := c.st.GetByEmail(email)
+	u, err := c.st.GetByEmailContext(ctx, email)
 	if err != nil {
 		if errors.Is(err, storage.ErrStorageNotFound) {
+			c.compareDummy(ctx, password)
 			err = ErrCredentialEmailNotFound
 		}
 
@@ -66,17 +113,38 @@ func (c *CredentialDefault) VerifyByEmail(email string, password string) (err er
 
 	// check if password is set
 	if !u.Password.IsSome() {
+		c.compareDummy(ctx, password)
 		err = ErrCredentialInternal
 		return
 	}
 	passwordUser, _ := u.Password.Unwrap()
 
 	// check if password matches
-	err = c.cr.Compare(passwordUser, password)
+	// - passwords are stored normalized by the validator
+	err = c.cr.CompareContext(ctx, passwordUser, validator.NormalizePassword(password))
 	if err != nil {
+		// - an abandoned comparison is not an invalid password
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("%w. %w", ErrCredentialInternal, ctxErr)
+			return
+		}
 		err = ErrCredentialPasswordInvalid
 		return
 	}
 
+	// check if user is active
+	// - checked after the password, so it is only revealed to the owner of the credential
+	isActive, _ := u.IsActive.Unwrap()
+	if !isActive {
+		err = ErrCredentialUserInactive
+		return
+	}
+
 	return
-}
\ No newline at end of file
+}
+
+// compareDummy compares a password against the dummy hash, discarding the result
+// - the password is normalized as in a real comparison, so both take the same work
+func (c *CredentialDefault) compareDummy(ctx context.Context, password string) {
+	_ = c.cr.CompareContext(ctx, c.dummy, validator.NormalizePassword(password))
+}
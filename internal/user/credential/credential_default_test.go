@@ -0,0 +1,277 @@
+package credential_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/credential"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/validator"
+	"github.com/LNMMusic/msauth/pkg/crypter"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for NewCredentialDefault
+func TestNewCredentialDefault(t *testing.T) {
+	t.Run("success - dummy hash generated once", func(t *testing.T) {
+		// arrange
+		cr := crypter.NewCrypterMock()
+		cr.On("Encrypt", "credential-dummy-password").Return("dummy_hash", nil).Once()
+		cr.On("Compare", "dummy_hash", "password").Return(crypter.ErrCrypterComparison).Twice()
+		db := storage.NewStoreMap()
+
+		// act
+		cd, err := credential.NewCredentialDefault(storage.NewStorageReadMap(db), cr)
+		require.NoError(t, err)
+		errUsername := cd.VerifyByUsername("johndoe", "password")
+		errEmail := cd.VerifyByEmail("johndoe@gmail.com", "password")
+
+		// assert
+		require.ErrorIs(t, errUsername, credential.ErrCredentialUsernameNotFound)
+		require.ErrorIs(t, errEmail, credential.ErrCredentialEmailNotFound)
+		cr.AssertExpectations(t)
+	})
+
+	t.Run("success - dummy compared with the normalized password", func(t *testing.T) {
+		// arrange
+		cr := crypter.NewCrypterMock()
+		cr.On("Encrypt", "credential-dummy-password").Return("dummy_hash", nil).Once()
+		cr.On("Compare", "dummy_hash", "password").Return(crypter.ErrCrypterComparison).Twice()
+		db := storage.NewStoreMap()
+
+		// act
+		cd, err := credential.NewCredentialDefault(storage.NewStorageReadMap(db), cr)
+		require.NoError(t, err)
+		errUsername := cd.VerifyByUsername("johndoe", "ｐａｓｓｗｏｒｄ")
+		errEmail := cd.VerifyByEmail("johndoe@gmail.com", "ｐａｓｓｗｏｒｄ")
+
+		// assert
+		require.ErrorIs(t, errUsername, credential.ErrCredentialUsernameNotFound)
+		require.ErrorIs(t, errEmail, credential.ErrCredentialEmailNotFound)
+		cr.AssertExpectations(t)
+	})
+
+	t.Run("success - dummy compared when the user has no password", func(t *testing.T) {
+		// arrange
+		cr := crypter.NewCrypterMock()
+		cr.On("Encrypt", "credential-dummy-password").Return("dummy_hash", nil).Once()
+		cr.On("Compare", "dummy_hash", "password").Return(crypter.ErrCrypterComparison).Twice()
+		db := storage.NewStoreMap()
+		db.Put(user.User{Id: 1, Username: optional.Some("johndoe"), Email: optional.Some("johndoe@gmail.com"), IsActive: optional.Some(true)})
+
+		// act
+		cd, err := credential.NewCredentialDefault(storage.NewStorageReadMap(db), cr)
+		require.NoError(t, err)
+		errUsername := cd.VerifyByUsername("johndoe", "password")
+		errEmail := cd.VerifyByEmail("johndoe@gmail.com", "password")
+
+		// assert
+		require.ErrorIs(t, errUsername, credential.ErrCredentialInternal)
+		require.ErrorIs(t, errEmail, credential.ErrCredentialInternal)
+		cr.AssertExpectations(t)
+	})
+
+	t.Run("failure - dummy hash can not be generated", func(t *testing.T) {
+		// arrange
+		cr := crypter.NewCrypterMock()
+		cr.On("Encrypt", "credential-dummy-password").Return("", crypter.ErrCrypterEncryption)
+
+		// act
+		cd, err := credential.NewCredentialDefault(storage.NewStorageReadMap(storage.NewStoreMap()), cr)
+
+		// assert
+		require.ErrorIs(t, err, credential.ErrCredentialInternal)
+		require.EqualError(t, err, "credential internal error. "+crypter.ErrCrypterEncryption.Error())
+		require.Nil(t, cd)
+		cr.AssertExpectations(t)
+	})
+}
+
+// Tests for CredentialDefault
+func TestCredentialDefault_VerifyByUsername(t *testing.T) {
+	// arrange
+	cr := crypter.NewCrypterDefault(8)
+	hashed, err := cr.Encrypt("password")
+	require.NoError(t, err)
+	db := storage.NewStoreMap()
+	db.Put(user.User{
+		Id: 1,
+		Username: optional.Some("johndoe"),
+		Password: optional.Some(hashed),
+		Email: optional.Some("johndoe@gmail.com"),
+		IsActive: optional.Some(true),
+	})
+	db.Put(user.User{
+		Id: 2,
+		Username: optional.Some("janedoe"),
+		Password: optional.Some(hashed),
+		Email: optional.Some("janedoe@gmail.com"),
+		IsActive: optional.Some(false),
+	})
+	cd, err := credential.NewCredentialDefault(storage.NewStorageReadMap(db), cr)
+	require.NoError(t, err)
+
+	t.Run("success - valid credential", func(t *testing.T) {
+		// act
+		err := cd.VerifyByUsername("johndoe", "password")
+
+		// assert
+		require.NoError(t, err)
+	})
+
+	t.Run("failure - valid credential of an inactive user", func(t *testing.T) {
+		// act
+		err := cd.VerifyByUsername("janedoe", "password")
+
+		// assert
+		require.ErrorIs(t, err, credential.ErrCredentialUserInactive)
+		require.ErrorIs(t, credential.Generic(err), credential.ErrCredentialUserInactive)
+	})
+
+	t.Run("failure - invalid credential of an inactive user", func(t *testing.T) {
+		// act
+		err := cd.VerifyByUsername("janedoe", "wrong")
+
+		// assert
+		require.ErrorIs(t, err, credential.ErrCredentialPasswordInvalid)
+	})
+
+	t.Run("failure - invalid password", func(t *testing.T) {
+		// act
+		err := cd.VerifyByUsername("johndoe", "wrong")
+
+		// assert
+		require.ErrorIs(t, err, credential.ErrCredentialPasswordInvalid)
+		require.ErrorIs(t, credential.Generic(err), credential.ErrCredentialInvalid)
+	})
+
+	t.Run("failure - username not found", func(t *testing.T) {
+		// act
+		err := cd.VerifyByUsername("unknown", "password")
+
+		// assert
+		require.ErrorIs(t, err, credential.ErrCredentialUsernameNotFound)
+		require.ErrorIs(t, credential.Generic(err), credential.ErrCredentialInvalid)
+	})
+
+	t.Run("failure - context canceled is not an invalid credential", func(t *testing.T) {
+		// arrange
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// act
+		err := cd.VerifyByUsernameContext(ctx, "johndoe", "wrong")
+
+		// assert
+		require.ErrorIs(t, err, context.Canceled)
+		require.NotErrorIs(t, err, credential.ErrCredentialPasswordInvalid)
+		require.NotErrorIs(t, credential.Generic(err), credential.ErrCredentialInvalid)
+	})
+
+	t.Run("success - unknown and existing users take comparable time", func(t *testing.T) {
+		// arrange
+		measure := func(username string) time.Duration {
+			start := time.Now()
+			for i := 0; i < 10; i++ {
+				_ = cd.VerifyByUsername(username, "wrong")
+			}
+			return time.Since(start)
+		}
+		// - warm up, so the first comparison is not measured
+		_ = cd.VerifyByUsername("unknown", "wrong")
+
+		// act
+		existing := measure("johndoe")
+		unknown := measure("unknown")
+
+		// assert
+		ratio := float64(unknown) / float64(existing)
+		require.InDelta(t, 1, ratio, 0.5, "existing: %s, unknown: %s", existing, unknown)
+	})
+}
+
+// Tests for CredentialDefault
+func TestCredentialDefault_VerifyByEmail(t *testing.T) {
+	// arrange
+	cr := crypter.NewCrypterDefault(8)
+	hashed, err := cr.Encrypt("password")
+	require.NoError(t, err)
+	db := storage.NewStoreMap()
+	db.Put(user.User{
+		Id: 1,
+		Username: optional.Some("johndoe"),
+		Password: optional.Some(hashed),
+		Email: optional.Some("johndoe@gmail.com"),
+		IsActive: optional.Some(true),
+	})
+	cd, err := credential.NewCredentialDefault(storage.NewStorageReadMap(db), cr)
+	require.NoError(t, err)
+
+	t.Run("success - valid credential", func(t *testing.T) {
+		// act
+		err := cd.VerifyByEmail("johndoe@gmail.com", "password")
+
+		// assert
+		require.NoError(t, err)
+	})
+
+	t.Run("failure - email not found", func(t *testing.T) {
+		// act
+		err := cd.VerifyByEmail("unknown@gmail.com", "password")
+
+		// assert
+		require.ErrorIs(t, err, credential.ErrCredentialEmailNotFound)
+		require.ErrorIs(t, credential.Generic(err), credential.ErrCredentialInvalid)
+	})
+
+	t.Run("success - unknown and existing users take comparable time", func(t *testing.T) {
+		// arrange
+		measure := func(email string) time.Duration {
+			start := time.Now()
+			for i := 0; i < 10; i++ {
+				_ = cd.VerifyByEmail(email, "wrong")
+			}
+			return time.Since(start)
+		}
+		// - warm up, so the first comparison is not measured
+		_ = cd.VerifyByEmail("unknown@gmail.com", "wrong")
+
+		// act
+		existing := measure("johndoe@gmail.com")
+		unknown := measure("unknown@gmail.com")
+
+		// assert
+		ratio := float64(unknown) / float64(existing)
+		require.InDelta(t, 1, ratio, 0.5, "existing: %s, unknown: %s", existing, unknown)
+	})
+}
+
+// Tests for CredentialDefault with unicode passwords
+func TestCredentialDefault_NormalizedPassword(t *testing.T) {
+	// arrange
+	// - the password is stored as prepared by the validator
+	cr := crypter.NewCrypterDefault(4)
+	hashed, err := validator.NewValidatorDefault("", cr).PreparePassword("ｐａｓｓｗｏｒｄ")
+	require.NoError(t, err)
+	db := storage.NewStoreMap()
+	db.Put(user.User{
+		Id: 1,
+		Username: optional.Some("johndoe"),
+		Password: optional.Some(hashed),
+		Email: optional.Some("johndoe@gmail.com"),
+		IsActive: optional.Some(true),
+	})
+	cd, err := credential.NewCredentialDefault(storage.NewStorageReadMap(db), cr)
+	require.NoError(t, err)
+
+	// act
+	errFullWidth := cd.VerifyByUsername("johndoe", "ｐａｓｓｗｏｒｄ")
+	errNormalized := cd.VerifyByUsername("johndoe", "password")
+
+	// assert
+	require.NoError(t, errFullWidth)
+	require.NoError(t, errNormalized)
+}
@@ -0,0 +1,320 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/validator"
+	"github.com/LNMMusic/optional"
+)
+
+// NewCredentialLockout returns a new CredentialLockout
+// - st resolves the users of the identifiers, so the username and the email of a user share the attempts
+// - in case of a nil storage, the attempts are tracked per identifier
+func NewCredentialLockout(cr Credential, st storage.StorageRead, config *ConfigLockout) *CredentialLockout {
+	// default values
+	if !config.Threshold.IsSome() {
+		config.Threshold = optional.Some(5)
+	}
+	if !config.Window.IsSome() {
+		config.Window = optional.Some(15 * time.Minute)
+	}
+	if !config.LockoutDuration.IsSome() {
+		config.LockoutDuration = optional.Some(time.Minute)
+	}
+	if !config.MaxLockoutDuration.IsSome() {
+		config.MaxLockoutDuration = optional.Some(24 * time.Hour)
+	}
+	if config.Normalizer == nil {
+		config.Normalizer = validator.NewNormalizerDefault(nil)
+	}
+
+	var stc storage.StorageReadContext
+	if st != nil {
+		stc = storage.StorageReadWithContext(st)
+	}
+
+	return &CredentialLockout{
+		cr: WithContext(cr),
+		st: stc,
+		config: config,
+		attempts: make(map[string]*attempts),
+		mu: &sync.Mutex{},
+		pruneAt: 1024,
+		now: time.Now,
+	}
+}
+
+// ConfigLockout is the configuration of CredentialLockout
+type ConfigLockout struct {
+	// Threshold is the number of consecutive failed attempts before an identifier is locked
+	Threshold 			optional.Option[int]
+	// Window is the period of time in which failed attempts are counted
+	Window 				optional.Option[time.Duration]
+	// LockoutDuration is the duration of the first lockout
+	// - it doubles on each subsequent lockout of the same identifier (exponential backoff)
+	LockoutDuration 	optional.Option[time.Duration]
+	// MaxLockoutDuration is the maximum duration of a lockout
+	MaxLockoutDuration 	optional.Option[time.Duration]
+	// Normalizer normalizes the identifiers of the attempts, it must be the same used by the storage lookups
+	// - otherwise, each spelling of an identifier (e.g. "JohnDoe", "johndoe") would get its own attempts
+	Normalizer 			validator.Normalizer
+}
+
+// attempts is the failed attempts record of an identifier
+type attempts struct {
+	// failures is the number of failed attempts in the current window
+	failures 	int
+	// firstFailure is the date of the first failed attempt of the current window
+	firstFailure time.Time
+	// lockouts is the number of times the identifier has been locked
+	lockouts 	int
+	// lockedUntil is the date until the identifier is locked
+	lockedUntil time.Time
+	// inflight is the number of attempts being verified
+	// - they are counted against the threshold, so concurrent attempts can not exceed it
+	inflight 	int
+}
+
+// CredentialLockout is a decorator of the Credential interface that tracks failed attempts
+// - users are locked after reaching the threshold of failed attempts within the window
+// - unknown identifiers are tracked as well, so lockouts do not leak which accounts exist
+type CredentialLockout struct {
+	// cr is the Credential interface to be wrapped
+	cr CredentialContext
+	// st is the StorageRead interface to resolve the users of the identifiers, optional
+	st storage.StorageReadContext
+	// config is the lockout configuration
+	config *ConfigLockout
+	// attempts is the failed attempts record
+	// - key: user id, or identifier (username or email) for unknown users
+	// - value: attempts
+	attempts map[string]*attempts
+	// mu is the mutex of the attempts record
+	mu *sync.Mutex
+	// pruneAt is the size of the attempts record that triggers the next prune
+	pruneAt int
+	// now returns the current date
+	now func() time.Time
+}
+
+// VerifyByUsername verifies a credential by username
+func (c *CredentialLockout) VerifyByUsername(username string, password string) (err error) {
+	err = c.VerifyByUsernameContext(context.Background(), username, password)
+	return
+}
+
+// VerifyByUsernameContext verifies a credential by username
+func (c *CredentialLockout) VerifyByUsernameContext(ctx context.Context, username string, password string) (err error) {
+	err = c.verify(c.keyUsername(ctx, username), func() error {
+		return c.cr.VerifyByUsernameContext(ctx, username, password)
+	})
+	return
+}
+
+// VerifyByEmail verifies a credential by email
+func (c *CredentialLockout) VerifyByEmail(email string, password string) (err error) {
+	err = c.VerifyByEmailContext(context.Background(), email, password)
+	return
+}
+
+// VerifyByEmailContext verifies a credential by email
+func (c *CredentialLockout) VerifyByEmailContext(ctx context.Context, email string, password string) (err error) {
+	err = c.verify(c.keyEmail(ctx, email), func() error {
+		return c.cr.VerifyByEmailContext(ctx, email, password)
+	})
+	return
+}
+
+// UnlockByUsername removes the lockout and failed attempts of a username
+func (c *CredentialLockout) UnlockByUsername(username string) {
+	c.unlock(c.keyUsername(context.Background(), username))
+}
+
+// UnlockByEmail removes the lockout and failed attempts of an email
+func (c *CredentialLockout) UnlockByEmail(email string) {
+	c.unlock(c.keyEmail(context.Background(), email))
+}
+
+// keyUsername returns the key of the attempts of a username
+// - the key of its user if it is found, otherwise the normalized username
+func (c *CredentialLockout) keyUsername(ctx context.Context, username string) (key string) {
+	username = c.config.Normalizer.Username(username)
+	if c.st != nil {
+		if u, err := c.st.GetByUsernameContext(ctx, username); err == nil {
+			key = "user:" + strconv.Itoa(u.Id)
+			return
+		}
+	}
+
+	key = "username:" + username
+	return
+}
+
+// keyEmail returns the key of the attempts of an email
+// - the key of its user if it is found, otherwise the normalized email
+func (c *CredentialLockout) keyEmail(ctx context.Context, email string) (key string) {
+	email = c.config.Normalizer.Email(email)
+	if c.st != nil {
+		if u, err := c.st.GetByEmailContext(ctx, email); err == nil {
+			key = "user:" + strconv.Itoa(u.Id)
+			return
+		}
+	}
+
+	key = "email:" + email
+	return
+}
+
+// verify reserves an attempt of a key before verifying its credential and records the result
+// - the reservation and the record are atomic with the lockout check, so concurrent attempts can not exceed the threshold
+func (c *CredentialLockout) verify(key string, fn func() error) (err error) {
+	// reserve attempt
+	err = c.reserve(key)
+	if err != nil {
+		return
+	}
+
+	// verify credential
+	err = fn()
+
+	// record attempt
+	c.record(key, err)
+	return
+}
+
+// reserve checks the lockout of a key and counts an attempt in-flight
+// - in-flight attempts are counted as failures, until they are recorded
+func (c *CredentialLockout) reserve(key string) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	threshold, _ := c.config.Threshold.Unwrap()
+	window, _ := c.config.Window.Unwrap()
+	maxLockoutDuration, _ := c.config.MaxLockoutDuration.Unwrap()
+
+	// prune stale records (amortized, only when the record doubles its size)
+	if len(c.attempts) >= c.pruneAt {
+		c.prune(now, window, maxLockoutDuration)
+		c.pruneAt = max(1024, 2 * len(c.attempts))
+	}
+
+	// get attempts
+	a, ok := c.attempts[key]
+	if !ok {
+		a = &attempts{}
+		c.attempts[key] = a
+	}
+
+	// check lockout
+	if now.Before(a.lockedUntil) {
+		err = fmt.Errorf("%w. until %s", ErrCredentialLocked, a.lockedUntil.Format(time.RFC3339))
+		return
+	}
+
+	// reset failures out of the window
+	if a.failures > 0 && now.Sub(a.firstFailure) > window {
+		a.failures = 0
+	}
+
+	// check threshold, with the attempts in-flight
+	if a.failures + a.inflight >= threshold {
+		err = fmt.Errorf("%w. too many attempts in progress", ErrCredentialLocked)
+		return
+	}
+	a.inflight++
+
+	return
+}
+
+// record releases an in-flight attempt of a key with the result of its verification
+// - a success removes the failed attempts, a failure is counted and locks the key in case of reaching the threshold
+// - other errors (e.g. internal) are not counted
+func (c *CredentialLockout) record(key string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// get attempts
+	// - the record may be gone if the key was unlocked meanwhile
+	a, ok := c.attempts[key]
+	if !ok {
+		a = &attempts{}
+		c.attempts[key] = a
+	}
+	if a.inflight > 0 {
+		a.inflight--
+	}
+
+	switch {
+	case err == nil:
+		a.failures = 0
+		a.lockouts = 0
+		a.lockedUntil = time.Time{}
+	case errors.Is(err, ErrCredentialPasswordInvalid),
+		errors.Is(err, ErrCredentialUsernameNotFound),
+		errors.Is(err, ErrCredentialEmailNotFound):
+		c.fail(a)
+	}
+
+	// remove empty records
+	if a.failures == 0 && a.lockouts == 0 && a.inflight == 0 {
+		delete(c.attempts, key)
+	}
+}
+
+// fail counts a failed attempt and locks it in case of reaching the threshold
+// - it must be called with the mutex locked
+func (c *CredentialLockout) fail(a *attempts) {
+	now := c.now()
+	threshold, _ := c.config.Threshold.Unwrap()
+	window, _ := c.config.Window.Unwrap()
+	lockoutDuration, _ := c.config.LockoutDuration.Unwrap()
+	maxLockoutDuration, _ := c.config.MaxLockoutDuration.Unwrap()
+
+	// reset failures out of the window
+	if a.failures == 0 || now.Sub(a.firstFailure) > window {
+		a.failures = 0
+		a.firstFailure = now
+	}
+	a.failures++
+
+	// check threshold
+	if a.failures < threshold {
+		return
+	}
+
+	// lock with exponential backoff
+	duration := lockoutDuration
+	for i := 0; i < a.lockouts && duration < maxLockoutDuration; i++ {
+		duration *= 2
+	}
+	if duration > maxLockoutDuration {
+		duration = maxLockoutDuration
+	}
+	a.lockouts++
+	a.lockedUntil = now.Add(duration)
+	a.failures = 0
+}
+
+// prune removes the records with no failures in the window, no attempts in-flight and whose lockout backoff has already cooled down
+func (c *CredentialLockout) prune(now time.Time, window time.Duration, maxLockoutDuration time.Duration) {
+	for key, a := range c.attempts {
+		if a.inflight == 0 && now.Sub(a.firstFailure) > window && now.Sub(a.lockedUntil) > maxLockoutDuration {
+			delete(c.attempts, key)
+		}
+	}
+}
+
+// unlock removes the failed attempts record of a key
+func (c *CredentialLockout) unlock(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.attempts, key)
+}
@@ -0,0 +1,292 @@
+package credential
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/validator"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for CredentialLockout
+func TestCredentialLockout_VerifyByUsername(t *testing.T) {
+	// clock
+	type clock struct {now time.Time}
+	newLockout := func(mk *CredentialMock, c *clock) *CredentialLockout {
+		cr := NewCredentialLockout(mk, nil, &ConfigLockout{
+			Threshold: optional.Some(3),
+			Window: optional.Some(time.Minute),
+			LockoutDuration: optional.Some(time.Minute),
+			MaxLockoutDuration: optional.Some(3 * time.Minute),
+		})
+		cr.now = func() time.Time { return c.now }
+		return cr
+	}
+
+	t.Run("success - valid credential", func(t *testing.T) {
+		// arrange
+		c := &clock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+		mk := NewCredentialMock()
+		mk.On("VerifyByUsername", "johndoe", "password").Return(nil)
+		cr := newLockout(mk, c)
+
+		// act
+		err := cr.VerifyByUsername("johndoe", "password")
+
+		// assert
+		require.NoError(t, err)
+		mk.AssertExpectations(t)
+	})
+
+	t.Run("failure - locked after reaching the threshold", func(t *testing.T) {
+		// arrange
+		c := &clock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+		mk := NewCredentialMock()
+		mk.On("VerifyByUsername", "johndoe", "wrong").Return(ErrCredentialPasswordInvalid).Times(3)
+		cr := newLockout(mk, c)
+
+		// act
+		for i := 0; i < 3; i++ {
+			err := cr.VerifyByUsername("johndoe", "wrong")
+			require.ErrorIs(t, err, ErrCredentialPasswordInvalid)
+		}
+		err := cr.VerifyByUsername("johndoe", "password")
+
+		// assert
+		require.ErrorIs(t, err, ErrCredentialLocked)
+		require.EqualError(t, err, "credential locked. until 2023-01-01T00:01:00Z")
+		mk.AssertExpectations(t)
+	})
+
+	t.Run("failure - unknown usernames are locked as well", func(t *testing.T) {
+		// arrange
+		c := &clock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+		mk := NewCredentialMock()
+		mk.On("VerifyByUsername", "unknown", "wrong").Return(ErrCredentialUsernameNotFound).Times(3)
+		cr := newLockout(mk, c)
+
+		// act
+		for i := 0; i < 3; i++ {
+			_ = cr.VerifyByUsername("unknown", "wrong")
+		}
+		err := cr.VerifyByUsername("unknown", "wrong")
+
+		// assert
+		require.ErrorIs(t, err, ErrCredentialLocked)
+		mk.AssertExpectations(t)
+	})
+
+	t.Run("failure - spellings of a username share the attempts", func(t *testing.T) {
+		// arrange
+		c := &clock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+		mk := NewCredentialMock()
+		mk.On("VerifyByUsername", "JohnDoe", "wrong").Return(ErrCredentialPasswordInvalid).Once()
+		mk.On("VerifyByUsername", "JOHNDOE", "wrong").Return(ErrCredentialPasswordInvalid).Once()
+		mk.On("VerifyByUsername", " johndoe", "wrong").Return(ErrCredentialPasswordInvalid).Once()
+		cr := newLockout(mk, c)
+
+		// act
+		_ = cr.VerifyByUsername("JohnDoe", "wrong")
+		_ = cr.VerifyByUsername("JOHNDOE", "wrong")
+		_ = cr.VerifyByUsername(" johndoe", "wrong")
+		err := cr.VerifyByUsername("johndoe", "password")
+
+		// assert
+		require.ErrorIs(t, err, ErrCredentialLocked)
+		mk.AssertExpectations(t)
+	})
+
+	t.Run("success - failures out of the window are not counted", func(t *testing.T) {
+		// arrange
+		c := &clock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+		mk := NewCredentialMock()
+		mk.On("VerifyByUsername", "johndoe", "wrong").Return(ErrCredentialPasswordInvalid).Times(3)
+		mk.On("VerifyByUsername", "johndoe", "password").Return(nil).Once()
+		cr := newLockout(mk, c)
+
+		// act
+		_ = cr.VerifyByUsername("johndoe", "wrong")
+		_ = cr.VerifyByUsername("johndoe", "wrong")
+		c.now = c.now.Add(2 * time.Minute)
+		_ = cr.VerifyByUsername("johndoe", "wrong")
+		err := cr.VerifyByUsername("johndoe", "password")
+
+		// assert
+		require.NoError(t, err)
+		mk.AssertExpectations(t)
+	})
+
+	t.Run("failure - lockout duration backs off exponentially up to the max", func(t *testing.T) {
+		// arrange
+		c := &clock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+		mk := NewCredentialMock()
+		mk.On("VerifyByUsername", "johndoe", "wrong").Return(ErrCredentialPasswordInvalid)
+		cr := newLockout(mk, c)
+		lockedUntil := func() time.Duration {
+			return cr.attempts["username:johndoe"].lockedUntil.Sub(c.now)
+		}
+
+		// act & assert
+		for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+			for i := 0; i < 3; i++ {
+				_ = cr.VerifyByUsername("johndoe", "wrong")
+			}
+			require.Equal(t, expected, lockedUntil())
+			c.now = c.now.Add(expected)
+		}
+	})
+
+	t.Run("success - unlocked by an admin", func(t *testing.T) {
+		// arrange
+		c := &clock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+		mk := NewCredentialMock()
+		mk.On("VerifyByUsername", "johndoe", "wrong").Return(ErrCredentialPasswordInvalid).Times(3)
+		mk.On("VerifyByUsername", "johndoe", "password").Return(nil).Once()
+		cr := newLockout(mk, c)
+		for i := 0; i < 3; i++ {
+			_ = cr.VerifyByUsername("johndoe", "wrong")
+		}
+
+		// act
+		cr.UnlockByUsername("johndoe")
+		err := cr.VerifyByUsername("johndoe", "password")
+
+		// assert
+		require.NoError(t, err)
+		mk.AssertExpectations(t)
+	})
+
+	t.Run("success - internal errors are not counted", func(t *testing.T) {
+		// arrange
+		c := &clock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+		mk := NewCredentialMock()
+		mk.On("VerifyByUsername", "johndoe", "password").Return(ErrCredentialInternal).Times(3)
+		cr := newLockout(mk, c)
+
+		// act
+		for i := 0; i < 3; i++ {
+			_ = cr.VerifyByUsername("johndoe", "password")
+		}
+
+		// assert
+		require.Empty(t, cr.attempts)
+		mk.AssertExpectations(t)
+	})
+}
+
+// Tests for CredentialLockout with concurrent attempts
+func TestCredentialLockout_Concurrent(t *testing.T) {
+	t.Run("failure - concurrent attempts do not exceed the threshold", func(t *testing.T) {
+		// arrange
+		mk := NewCredentialMock()
+		mk.On("VerifyByUsername", "johndoe", "wrong").Return(ErrCredentialPasswordInvalid).After(20 * time.Millisecond)
+		cr := NewCredentialLockout(mk, nil, &ConfigLockout{Threshold: optional.Some(3)})
+
+		// act
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = cr.VerifyByUsername("johndoe", "wrong")
+			}()
+		}
+		wg.Wait()
+		err := cr.VerifyByUsername("johndoe", "wrong")
+
+		// assert
+		require.ErrorIs(t, err, ErrCredentialLocked)
+		mk.AssertNumberOfCalls(t, "VerifyByUsername", 3)
+	})
+}
+
+// Tests for CredentialLockout with a storage to resolve the users
+func TestCredentialLockout_Users(t *testing.T) {
+	t.Run("failure - the username and the email of a user share the attempts", func(t *testing.T) {
+		// arrange
+		db := storage.NewStoreMap(user.User{
+			Id: 1,
+			Username: optional.Some("johndoe"),
+			Email: optional.Some("johndoe@gmail.com"),
+		})
+		mk := NewCredentialMock()
+		mk.On("VerifyByUsername", "johndoe", "wrong").Return(ErrCredentialPasswordInvalid).Once()
+		mk.On("VerifyByEmail", "johndoe@gmail.com", "wrong").Return(ErrCredentialPasswordInvalid).Once()
+		cr := NewCredentialLockout(mk, storage.NewStorageReadMap(db), &ConfigLockout{Threshold: optional.Some(2)})
+
+		// act
+		_ = cr.VerifyByUsername("johndoe", "wrong")
+		_ = cr.VerifyByEmail("johndoe@gmail.com", "wrong")
+		errUsername := cr.VerifyByUsername("johndoe", "password")
+		errEmail := cr.VerifyByEmail("johndoe@gmail.com", "password")
+
+		// assert
+		require.ErrorIs(t, errUsername, ErrCredentialLocked)
+		require.ErrorIs(t, errEmail, ErrCredentialLocked)
+		require.Contains(t, cr.attempts, "user:1")
+		mk.AssertExpectations(t)
+	})
+}
+
+// Tests for CredentialLockout
+func TestCredentialLockout_VerifyByEmail(t *testing.T) {
+	t.Run("failure - locked after reaching the threshold", func(t *testing.T) {
+		// arrange
+		mk := NewCredentialMock()
+		mk.On("VerifyByEmail", "johndoe@gmail.com", "wrong").Return(ErrCredentialPasswordInvalid).Times(2)
+		cr := NewCredentialLockout(mk, nil, &ConfigLockout{Threshold: optional.Some(2)})
+
+		// act
+		_ = cr.VerifyByEmail("johndoe@gmail.com", "wrong")
+		_ = cr.VerifyByEmail("johndoe@gmail.com", "wrong")
+		err := cr.VerifyByEmail("johndoe@gmail.com", "password")
+
+		// assert
+		require.ErrorIs(t, err, ErrCredentialLocked)
+		mk.AssertExpectations(t)
+	})
+
+	t.Run("success - unlocked by an admin", func(t *testing.T) {
+		// arrange
+		mk := NewCredentialMock()
+		mk.On("VerifyByEmail", "johndoe@gmail.com", "wrong").Return(ErrCredentialPasswordInvalid).Times(2)
+		mk.On("VerifyByEmail", "johndoe@gmail.com", "password").Return(nil).Once()
+		cr := NewCredentialLockout(mk, nil, &ConfigLockout{Threshold: optional.Some(2)})
+		_ = cr.VerifyByEmail("johndoe@gmail.com", "wrong")
+		_ = cr.VerifyByEmail("johndoe@gmail.com", "wrong")
+
+		// act
+		cr.UnlockByEmail("johndoe@gmail.com")
+		err := cr.VerifyByEmail("johndoe@gmail.com", "password")
+
+		// assert
+		require.NoError(t, err)
+		mk.AssertExpectations(t)
+	})
+
+	t.Run("failure - spellings of an email share the attempts", func(t *testing.T) {
+		// arrange
+		mk := NewCredentialMock()
+		mk.On("VerifyByEmail", "John.Doe@gmail.com", "wrong").Return(ErrCredentialPasswordInvalid).Once()
+		mk.On("VerifyByEmail", "johndoe+1@gmail.com", "wrong").Return(ErrCredentialPasswordInvalid).Once()
+		cr := NewCredentialLockout(mk, nil, &ConfigLockout{
+			Threshold: optional.Some(2),
+			Normalizer: validator.NewNormalizerDefault(&validator.ConfigNormalizer{
+				Providers: []validator.EmailProvider{validator.EmailProviderGmail},
+			}),
+		})
+
+		// act
+		_ = cr.VerifyByEmail("John.Doe@gmail.com", "wrong")
+		_ = cr.VerifyByEmail("johndoe+1@gmail.com", "wrong")
+		err := cr.VerifyByEmail("johndoe@gmail.com", "password")
+
+		// assert
+		require.ErrorIs(t, err, ErrCredentialLocked)
+		mk.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,50 @@
+package credential
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// NewCredentialMock returns a new mock credential
+func NewCredentialMock() *CredentialMock {
+	return &CredentialMock{}
+}
+
+// CredentialMock is a mock implementation of the Credential interface
+type CredentialMock struct {
+	// mock.Mock is a struct that implements the Mock struct for testing purposes
+	mock.Mock
+}
+
+// VerifyByUsername verifies a credential by username
+func (m *CredentialMock) VerifyByUsername(username string, password string) (err error) {
+	args := m.Called(username, password)
+	err = args.Error(0)
+	return
+}
+
+// VerifyByEmail verifies a credential by email
+func (m *CredentialMock) VerifyByEmail(email string, password string) (err error) {
+	args := m.Called(email, password)
+	err = args.Error(0)
+	return
+}
+
+// VerifyByUsernameContext returns the error of a done context, otherwise the VerifyByUsername mock is called
+func (m *CredentialMock) VerifyByUsernameContext(ctx context.Context, username string, password string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.VerifyByUsername(username, password)
+	return
+}
+
+// VerifyByEmailContext returns the error of a done context, otherwise the VerifyByEmail mock is called
+func (m *CredentialMock) VerifyByEmailContext(ctx context.Context, email string, password string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.VerifyByEmail(email, password)
+	return
+}
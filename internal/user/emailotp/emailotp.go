@@ -0,0 +1,28 @@
+package emailotp
+
+import (
+	"errors"
+
+	"github.com/LNMMusic/msauth/internal/user"
+)
+
+var (
+	// ErrEmailOTPInternal is returned when an internal error occurs
+	ErrEmailOTPInternal = errors.New("emailotp: internal error")
+	// ErrEmailOTPInvalid is returned when a code is wrong, expired, out of attempts or of another request
+	ErrEmailOTPInvalid = errors.New("emailotp: invalid code")
+	// ErrEmailOTPUserInactive is returned when the user of a code is not active
+	ErrEmailOTPUserInactive = errors.New("emailotp: user is not active")
+)
+
+// EmailOTP interface to handle the login with one-time passcodes sent by email
+// - codes are bound to the request that issued them, only the requesting client can verify them
+type EmailOTP interface {
+	// Request sends a code to an email and returns the id of the request
+	// - it does not fail when the email is not registered, so emails can not be enumerated
+	// - once issued, the request id is returned also on failure, so failures can be responded as unregistered emails
+	Request(email string) (requestId string, err error)
+
+	// Verify verifies the code of a request and returns its user
+	Verify(requestId string, code string) (u user.User, err error)
+}
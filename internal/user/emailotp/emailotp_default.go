@@ -0,0 +1,266 @@
+package emailotp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/pkg/mailer"
+	"github.com/LNMMusic/optional"
+)
+
+// TemplateDefault is the default template of the code mails
+// - data: Code is the code, TTL is its time to live
+var TemplateDefault = mailer.MustTemplate(
+	"Your sign in code",
+	"Your sign in code is {{.Code}}. It expires in {{.TTL}}.\nIf you did not request it, ignore this email.",
+	`<p>Your sign in code is <strong>{{.Code}}</strong>. It expires in {{.TTL}}.</p><p>If you did not request it, ignore this email.</p>`,
+)
+
+// NewEmailOTPDefault returns a new EmailOTPDefault
+// - ml should send in the background (e.g. mailer.MailerAsync), so registered emails do not take longer to respond
+func NewEmailOTPDefault(st Storage, stRead storage.StorageRead, ml mailer.Mailer, config *Config) *EmailOTPDefault {
+	// default values
+	if !config.TTL.IsSome() {
+		config.TTL = optional.Some(10 * time.Minute)
+	}
+	if !config.MaxAttempts.IsSome() {
+		config.MaxAttempts = optional.Some(5)
+	}
+	if config.Template == nil {
+		config.Template = TemplateDefault
+	}
+
+	return &EmailOTPDefault{
+		st: st,
+		stRead: stRead,
+		ml: ml,
+		config: config,
+		mu: &sync.Mutex{},
+		now: time.Now,
+	}
+}
+
+// Config is the configuration of EmailOTPDefault
+type Config struct {
+	// TTL is the time to live of the codes (default 10 minutes)
+	TTL 		optional.Option[time.Duration]
+	// MaxAttempts is the number of failed verifications of a user, across its requests, that invalidates its codes (default 5)
+	// - the attempts are reset a TTL after the first request, or on a successful verification
+	MaxAttempts optional.Option[int]
+	// Template is the template of the code mails (default TemplateDefault)
+	Template 	*mailer.Template
+}
+
+// EmailOTPDefault is the default implementation of the EmailOTP interface
+// - codes are 6 random digits, a new request invalidates the previous code of the user
+// - failed attempts are counted per user, so new requests do not renew them
+type EmailOTPDefault struct {
+	// st is the storage of the challenges
+	st Storage
+	// stRead is the read interface for the user store
+	stRead storage.StorageRead
+	// ml is the Mailer interface to send the codes
+	ml mailer.Mailer
+	// config is the configuration of the codes
+	config *Config
+	// mu serializes the requests and verifications, so attempts are counted and codes used once
+	mu *sync.Mutex
+	// now returns the current date
+	now func() time.Time
+}
+
+// Request sends a code to an email and returns the id of the request
+// - once issued, the request id is returned also on failure, see EmailOTP
+func (e *EmailOTPDefault) Request(email string) (requestId string, err error) {
+	// request id
+	// - issued even if the email is not registered
+	b := make([]byte, 32)
+	_, err = rand.Read(b)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrEmailOTPInternal, err.Error())
+		return
+	}
+	id := base64.RawURLEncoding.EncodeToString(b)
+	requestId = id
+
+	// get user
+	u, err := e.stRead.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, storage.ErrStorageNotFound) {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("%w. %s", ErrEmailOTPInternal, err.Error())
+		return
+	}
+	if isActive, _ := u.IsActive.Unwrap(); !isActive {
+		return
+	}
+
+	// code
+	_, err = rand.Read(b[:8])
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrEmailOTPInternal, err.Error())
+		return
+	}
+	code := fmt.Sprintf("%06d", binary.BigEndian.Uint64(b[:8]) % 1000000)
+	ttl, _ := e.config.TTL.Unwrap()
+	exhausted, err := e.issue(Challenge{
+		Id: hash(id),
+		UserId: u.Id,
+		CodeHash: codeHash(id, code),
+		AttemptsResetDate: e.now().Add(ttl),
+		ExpireDate: e.now().Add(ttl),
+	})
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrEmailOTPInternal, err.Error())
+		return
+	}
+	if exhausted {
+		// no code is sent, it could not be verified anyway
+		return
+	}
+
+	// send mail
+	// - to the stored email, the typed one may be another spelling of it (e.g. case or provider rules)
+	to, _ := u.Email.Unwrap()
+	m, err := e.config.Template.Render([]string{to}, map[string]any{"Code": code, "TTL": ttl.String()})
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrEmailOTPInternal, err.Error())
+		return
+	}
+	err = e.ml.Send(m)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrEmailOTPInternal, err.Error())
+		return
+	}
+
+	return
+}
+
+// Verify verifies the code of a request and returns its user
+func (e *EmailOTPDefault) Verify(requestId string, code string) (u user.User, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// get challenge
+	id := hash(requestId)
+	c, err := e.st.Get(id)
+	if err != nil {
+		if errors.Is(err, ErrStorageChallengeNotFound) {
+			err = fmt.Errorf("%w. %s", ErrEmailOTPInvalid, err.Error())
+			return
+		}
+		err = fmt.Errorf("%w. %s", ErrEmailOTPInternal, err.Error())
+		return
+	}
+	if !e.now().Before(c.ExpireDate) {
+		_ = e.st.Delete(id)
+		err = fmt.Errorf("%w. code expired", ErrEmailOTPInvalid)
+		return
+	}
+
+	// compare code
+	// - an exhausted challenge is kept until it expires, so the next request of the user carries its attempts
+	maxAttempts, _ := e.config.MaxAttempts.Unwrap()
+	if c.Attempts >= maxAttempts {
+		err = fmt.Errorf("%w. out of attempts", ErrEmailOTPInvalid)
+		return
+	}
+	if !hmac.Equal([]byte(c.CodeHash), []byte(codeHash(requestId, code))) {
+		c.Attempts++
+		err = e.st.Set(c)
+		if err != nil {
+			err = fmt.Errorf("%w. %s", ErrEmailOTPInternal, err.Error())
+			return
+		}
+		err = fmt.Errorf("%w. code mismatch", ErrEmailOTPInvalid)
+		return
+	}
+
+	// consume challenge
+	err = e.st.Delete(id)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrEmailOTPInternal, err.Error())
+		return
+	}
+
+	// get user
+	u, err = e.stRead.Get(c.UserId)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrStorageNotFound):
+			err = fmt.Errorf("%w. %s", ErrEmailOTPInvalid, err.Error())
+		default:
+			err = fmt.Errorf("%w. %s", ErrEmailOTPInternal, err.Error())
+		}
+		return
+	}
+	if isActive, _ := u.IsActive.Unwrap(); !isActive {
+		u = user.User{}
+		err = ErrEmailOTPUserInactive
+		return
+	}
+
+	return
+}
+
+// issue sets the new challenge of a user, invalidating the previous one
+// - the failed attempts of the previous challenge are carried over until they are reset,
+// so new requests do not renew the attempts of the user
+// - exhausted is true when the user has no attempts left
+func (e *EmailOTPDefault) issue(c Challenge) (exhausted bool, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	prev, err := e.st.GetByUser(c.UserId)
+	switch {
+	case err == nil:
+		if e.now().Before(prev.AttemptsResetDate) {
+			c.Attempts = prev.Attempts
+			c.AttemptsResetDate = prev.AttemptsResetDate
+		}
+		err = e.st.Delete(prev.Id)
+		if err != nil {
+			return
+		}
+	case errors.Is(err, ErrStorageChallengeNotFound):
+		err = nil
+	default:
+		return
+	}
+
+	err = e.st.Set(c)
+	if err != nil {
+		return
+	}
+	maxAttempts, _ := e.config.MaxAttempts.Unwrap()
+	exhausted = c.Attempts >= maxAttempts
+	return
+}
+
+// hash returns the sha256 hash of a request id
+func hash(requestId string) (h string) {
+	sum := sha256.Sum256([]byte(requestId))
+	h = hex.EncodeToString(sum[:])
+	return
+}
+
+// codeHash returns the HMAC-SHA256 of a code keyed with its request id
+// - without the request id, the hash can not be brute forced offline
+func codeHash(requestId string, code string) (h string) {
+	mac := hmac.New(sha256.New, []byte(requestId))
+	mac.Write([]byte(code))
+	h = hex.EncodeToString(mac.Sum(nil))
+	return
+}
@@ -0,0 +1,306 @@
+package emailotp
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/pkg/mailer"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newEmailOTP returns an EmailOTPDefault with a registered user and a controlled clock
+func newEmailOTP(t *testing.T, now *time.Time, isActive bool) (e *EmailOTPDefault, st *StorageLocal, ml *mailer.MailerMemory) {
+	rd := storage.NewStorageReadMock()
+	u := user.User{Id: 1, Email: optional.Some("johndoe@gmail.com"), IsActive: optional.Some(isActive)}
+	rd.On("GetByEmail", "johndoe@gmail.com").Return(u, nil)
+	rd.On("GetByEmail", "John.Doe@Gmail.com").Return(u, nil)
+	rd.On("GetByEmail", "unknown@gmail.com").Return(user.User{}, storage.ErrStorageNotFound)
+	rd.On("Get", 1).Return(u, nil)
+	st = NewStorageLocal()
+	ml = mailer.NewMailerMemory()
+	e = NewEmailOTPDefault(st, rd, ml, &Config{MaxAttempts: optional.Some(3)})
+	e.now = func() time.Time { return *now }
+	return
+}
+
+// codeFromMail extracts the code of the last mail
+func codeFromMail(t *testing.T, ml *mailer.MailerMemory) string {
+	mails := ml.Mails()
+	require.NotEmpty(t, mails)
+	code := regexp.MustCompile(`\d{6}`).FindString(mails[len(mails)-1].Text)
+	require.NotEmpty(t, code)
+	return code
+}
+
+// wrong returns a code different from the given one
+func wrong(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+// Tests for EmailOTPDefault
+func TestEmailOTPDefault(t *testing.T) {
+	t.Run("success - code sent and verified", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, st, ml := newEmailOTP(t, &now, true)
+
+		// act
+		requestId, err := e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+		code := codeFromMail(t, ml)
+		u, err := e.Verify(requestId, code)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 1, u.Id)
+		require.Equal(t, "Your sign in code", ml.Mails()[0].Subject)
+		require.Contains(t, ml.Mails()[0].Text, "It expires in 10m0s.")
+		_, err = st.Get(hash(requestId))
+		require.ErrorIs(t, err, ErrStorageChallengeNotFound, "the code must be single-use")
+	})
+
+	t.Run("success - code stored hashed", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, st, ml := newEmailOTP(t, &now, true)
+
+		// act
+		requestId, err := e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+
+		// assert
+		c, err := st.Get(hash(requestId))
+		require.NoError(t, err)
+		require.NotEqual(t, requestId, c.Id)
+		require.NotContains(t, c.CodeHash, codeFromMail(t, ml))
+	})
+
+	t.Run("success - code sent to the stored email", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, _, ml := newEmailOTP(t, &now, true)
+
+		// act
+		requestId, err := e.Request("John.Doe@Gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		require.NotEmpty(t, requestId)
+		require.Len(t, ml.Mails(), 1)
+		require.Equal(t, []string{"johndoe@gmail.com"}, ml.Mails()[0].To)
+	})
+
+	t.Run("success - unknown email gets a request id but no mail", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, _, ml := newEmailOTP(t, &now, true)
+
+		// act
+		requestId, err := e.Request("unknown@gmail.com")
+		_, errVerify := e.Verify(requestId, "123456")
+
+		// assert
+		require.NoError(t, err)
+		require.NotEmpty(t, requestId)
+		require.Empty(t, ml.Mails())
+		require.ErrorIs(t, errVerify, ErrEmailOTPInvalid)
+	})
+
+	t.Run("success - inactive user gets no mail", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, _, ml := newEmailOTP(t, &now, false)
+
+		// act
+		requestId, err := e.Request("johndoe@gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		require.NotEmpty(t, requestId)
+		require.Empty(t, ml.Mails())
+	})
+
+	t.Run("failure - mail not sent returns the request id", func(t *testing.T) {
+		// arrange
+		rd := storage.NewStorageReadMock()
+		rd.On("GetByEmail", "johndoe@gmail.com").Return(user.User{Id: 1, Email: optional.Some("johndoe@gmail.com"), IsActive: optional.Some(true)}, nil)
+		ml := mailer.NewMailerMock()
+		ml.On("Send", mock.Anything).Return(mailer.ErrMailerSend)
+		e := NewEmailOTPDefault(NewStorageLocal(), rd, ml, &Config{})
+
+		// act
+		requestId, err := e.Request("johndoe@gmail.com")
+
+		// assert
+		require.ErrorIs(t, err, ErrEmailOTPInternal)
+		require.NotEmpty(t, requestId)
+		rd.AssertExpectations(t)
+		ml.AssertExpectations(t)
+	})
+
+	t.Run("failure - code bound to its request", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, _, ml := newEmailOTP(t, &now, true)
+		_, err := e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+		code := codeFromMail(t, ml)
+		other, err := e.Request("unknown@gmail.com")
+		require.NoError(t, err)
+
+		// act
+		_, err = e.Verify(other, code)
+
+		// assert
+		require.ErrorIs(t, err, ErrEmailOTPInvalid)
+	})
+
+	t.Run("failure - new request invalidates the previous code", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, _, ml := newEmailOTP(t, &now, true)
+		first, err := e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+		code := codeFromMail(t, ml)
+		_, err = e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+
+		// act
+		_, err = e.Verify(first, code)
+
+		// assert
+		require.ErrorIs(t, err, ErrEmailOTPInvalid)
+	})
+
+	t.Run("failure - code expired", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, _, ml := newEmailOTP(t, &now, true)
+		requestId, err := e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+		now = now.Add(10 * time.Minute)
+
+		// act
+		_, err = e.Verify(requestId, codeFromMail(t, ml))
+
+		// assert
+		require.ErrorIs(t, err, ErrEmailOTPInvalid)
+		require.EqualError(t, err, "emailotp: invalid code. code expired")
+	})
+
+	t.Run("failure - out of attempts", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, _, ml := newEmailOTP(t, &now, true)
+		requestId, err := e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+		code := codeFromMail(t, ml)
+		for i := 0; i < 3; i++ {
+			_, err = e.Verify(requestId, wrong(code))
+			require.ErrorIs(t, err, ErrEmailOTPInvalid)
+		}
+
+		// act
+		_, err = e.Verify(requestId, code)
+
+		// assert
+		require.ErrorIs(t, err, ErrEmailOTPInvalid)
+	})
+
+	t.Run("failure - new requests carry the attempts of the user", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, _, ml := newEmailOTP(t, &now, true)
+		first, err := e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+		code := codeFromMail(t, ml)
+		for i := 0; i < 2; i++ {
+			_, err = e.Verify(first, wrong(code))
+			require.ErrorIs(t, err, ErrEmailOTPInvalid)
+		}
+		second, err := e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+		code = codeFromMail(t, ml)
+		_, err = e.Verify(second, wrong(code))
+		require.ErrorIs(t, err, ErrEmailOTPInvalid)
+
+		// act
+		_, err = e.Verify(second, code)
+
+		// assert
+		require.ErrorIs(t, err, ErrEmailOTPInvalid)
+		require.EqualError(t, err, "emailotp: invalid code. out of attempts")
+	})
+
+	t.Run("failure - user out of attempts gets no mail", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, _, ml := newEmailOTP(t, &now, true)
+		requestId, err := e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+		code := codeFromMail(t, ml)
+		for i := 0; i < 3; i++ {
+			_, err = e.Verify(requestId, wrong(code))
+			require.ErrorIs(t, err, ErrEmailOTPInvalid)
+		}
+
+		// act
+		other, err := e.Request("johndoe@gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		require.NotEmpty(t, other)
+		require.Len(t, ml.Mails(), 1)
+	})
+
+	t.Run("success - attempts of the user reset after the ttl", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, _, ml := newEmailOTP(t, &now, true)
+		requestId, err := e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+		code := codeFromMail(t, ml)
+		for i := 0; i < 3; i++ {
+			_, err = e.Verify(requestId, wrong(code))
+			require.ErrorIs(t, err, ErrEmailOTPInvalid)
+		}
+		now = now.Add(10 * time.Minute)
+		requestId, err = e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+
+		// act
+		u, err := e.Verify(requestId, codeFromMail(t, ml))
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 1, u.Id)
+	})
+
+	t.Run("success - attempts below the limit", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		e, _, ml := newEmailOTP(t, &now, true)
+		requestId, err := e.Request("johndoe@gmail.com")
+		require.NoError(t, err)
+		code := codeFromMail(t, ml)
+		for i := 0; i < 2; i++ {
+			_, err = e.Verify(requestId, wrong(code))
+			require.ErrorIs(t, err, ErrEmailOTPInvalid)
+		}
+
+		// act
+		u, err := e.Verify(requestId, code)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 1, u.Id)
+	})
+}
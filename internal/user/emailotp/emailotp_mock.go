@@ -0,0 +1,33 @@
+package emailotp
+
+import (
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/stretchr/testify/mock"
+)
+
+// NewEmailOTPMock returns a new mock email otp
+func NewEmailOTPMock() *EmailOTPMock {
+	return &EmailOTPMock{}
+}
+
+// EmailOTPMock is a mock implementation of the EmailOTP interface
+type EmailOTPMock struct {
+	// mock.Mock is a struct that implements the Mock struct for testing purposes
+	mock.Mock
+}
+
+// Request sends a code to an email and returns the id of the request
+func (m *EmailOTPMock) Request(email string) (requestId string, err error) {
+	args := m.Called(email)
+	requestId = args.String(0)
+	err = args.Error(1)
+	return
+}
+
+// Verify verifies the code of a request and returns its user
+func (m *EmailOTPMock) Verify(requestId string, code string) (u user.User, err error) {
+	args := m.Called(requestId, code)
+	u = args.Get(0).(user.User)
+	err = args.Error(1)
+	return
+}
@@ -0,0 +1,45 @@
+package emailotp
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrStorageChallengeNotFound is returned when a challenge is not found
+	ErrStorageChallengeNotFound = errors.New("emailotp: storage challenge not found")
+)
+
+// Challenge is a pending code of a user
+// - neither the request id nor the code are stored in plain
+type Challenge struct {
+	// Id is the sha256 hash of the request id
+	Id 			string
+	// UserId is the id of the user
+	UserId 		int
+	// CodeHash is the HMAC-SHA256 of the code keyed with the request id
+	CodeHash 	string
+	// Attempts is the number of failed verifications of the user
+	// - carried over to the next challenge of the user until AttemptsResetDate
+	Attempts 	int
+	// AttemptsResetDate is the date the failed verifications of the user are reset
+	AttemptsResetDate time.Time
+	// ExpireDate is the expire date of the code
+	ExpireDate 	time.Time
+}
+
+// Storage interface to handle the challenges in the database
+// - there is at most one challenge per user
+type Storage interface {
+	// Get returns a challenge by id
+	Get(id string) (c Challenge, err error)
+
+	// GetByUser returns the challenge of a user
+	GetByUser(userId int) (c Challenge, err error)
+
+	// Set sets a challenge, replacing the previous one of its user
+	Set(c Challenge) (err error)
+
+	// Delete deletes a challenge by id
+	Delete(id string) (err error)
+}
@@ -0,0 +1,82 @@
+package emailotp
+
+import (
+	"fmt"
+	"sync"
+)
+
+// NewStorageLocal returns a new StorageLocal
+func NewStorageLocal() *StorageLocal {
+	return &StorageLocal{
+		db: make(map[string]Challenge),
+		users: make(map[int]string),
+		mu: &sync.RWMutex{},
+	}
+}
+
+// StorageLocal is an in-memory implementation of the Storage interface
+type StorageLocal struct {
+	// db is the map of challenges
+	// - key: challenge id
+	// - value: challenge
+	db map[string]Challenge
+	// users is the index of challenges by user
+	// - key: user id
+	// - value: challenge id
+	users map[int]string
+	// mu is the mutex of the db
+	mu *sync.RWMutex
+}
+
+// Get returns a challenge by id
+func (s *StorageLocal) Get(id string) (c Challenge, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.db[id]
+	if !ok {
+		err = fmt.Errorf("%w - %s", ErrStorageChallengeNotFound, id)
+		return
+	}
+
+	return
+}
+
+// GetByUser returns the challenge of a user
+func (s *StorageLocal) GetByUser(userId int) (c Challenge, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.db[s.users[userId]]
+	if !ok {
+		err = fmt.Errorf("%w - user %d", ErrStorageChallengeNotFound, userId)
+		return
+	}
+
+	return
+}
+
+// Set sets a challenge, replacing the previous one of its user
+func (s *StorageLocal) Set(c Challenge) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, ok := s.users[c.UserId]; ok && id != c.Id {
+		delete(s.db, id)
+	}
+	s.db[c.Id] = c
+	s.users[c.UserId] = c.Id
+	return
+}
+
+// Delete deletes a challenge by id
+func (s *StorageLocal) Delete(id string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.db[id]; ok {
+		delete(s.db, id)
+		delete(s.users, c.UserId)
+	}
+	return
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/jwtauth"
+	"github.com/LNMMusic/msauth/internal/user/emailotp"
+	"github.com/LNMMusic/msauth/internal/user/linktoken"
+	"github.com/LNMMusic/msauth/internal/user/twofactor"
+	"github.com/LNMMusic/msauth/pkg/web/request"
+	"github.com/LNMMusic/msauth/pkg/web/response"
+	"github.com/LNMMusic/optional"
+)
+
+// NewHandlersEmailOTP returns a new HandlersEmailOTP struct
+// - tf, ch and config are the ones of the login handlers, so users with two factor enabled still need a code
+func NewHandlersEmailOTP(ot emailotp.EmailOTP, jw jwtauth.JWTAuth, tf twofactor.TwoFactor, ch linktoken.LinkToken, config *ConfigLogin) *HandlersEmailOTP {
+	// default values
+	if !config.TokenTTL.IsSome() {
+		config.TokenTTL = optional.Some(24 * time.Hour)
+	}
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	return &HandlersEmailOTP{
+		ot: ot,
+		jw: jwtauth.WithContext(jw),
+		tf: tf,
+		ch: ch,
+		config: config,
+	}
+}
+
+// HandlersEmailOTP is the struct that contains the dependencies for the email code handlers
+type HandlersEmailOTP struct {
+	// ot is the email otp interface to send and verify the codes
+	ot emailotp.EmailOTP
+	// jw is the jwt auth interface to sign the tokens
+	jw jwtauth.JWTAuthContext
+	// tf is the two factor interface to check if a code is required
+	tf twofactor.TwoFactor
+	// ch is the link token interface to issue the two factor challenges
+	ch linktoken.LinkToken
+	// config is the configuration of the tokens
+	config *ConfigLogin
+}
+
+// EmailOTPRequest is the request body for the request email code route
+type EmailOTPRequest struct {
+	// Email is the email of the user
+	Email 	optional.Option[string] `json:"email"`
+}
+
+// Request is the handler for the request email code route
+// - it responds a request id whether the email is registered or not, failures are logged
+func (h *HandlersEmailOTP) Request() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body EmailOTPRequest
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if !body.Email.IsSome() {
+			response.JSON(w, http.StatusBadRequest, "missing required fields")
+			return
+		}
+		email, _ := body.Email.Unwrap()
+
+		// process
+		// - the request id is returned also on failure, only its absence can not be responded the same
+		requestId, err := h.ot.Request(email)
+		if err != nil {
+			h.config.Logger.Printf("handler: email code request failed: %s", err.Error())
+			if requestId == "" {
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+		}
+
+		// response
+		response.JSON(w, http.StatusAccepted, map[string]any{
+			"message": "if the email is registered, a sign in code has been sent",
+			"data": map[string]any{
+				"request_id": requestId,
+			},
+		})
+	}
+}
+
+// EmailOTPVerify is the request body for the verify email code route
+type EmailOTPVerify struct {
+	// RequestId is the request id responded by the request route
+	RequestId 	optional.Option[string] `json:"request_id"`
+	// Code is the code sent by email
+	Code 		optional.Option[string] `json:"code"`
+}
+
+// Verify is the handler for the verify email code route
+func (h *HandlersEmailOTP) Verify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body EmailOTPVerify
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if !body.RequestId.IsSome() || !body.Code.IsSome() {
+			response.JSON(w, http.StatusBadRequest, "missing required fields")
+			return
+		}
+		requestId, _ := body.RequestId.Unwrap()
+		code, _ := body.Code.Unwrap()
+
+		// process
+		u, err := h.ot.Verify(requestId, code)
+		if err != nil {
+			switch {
+			case errors.Is(err, emailotp.ErrEmailOTPInvalid):
+				response.JSON(w, http.StatusUnauthorized, "invalid or expired code")
+			case errors.Is(err, emailotp.ErrEmailOTPUserInactive):
+				response.JSON(w, http.StatusForbidden, "user not active, verify your email first")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		completeSignIn(r.Context(), w, h.jw, h.tf, h.ch, h.config, u)
+	}
+}
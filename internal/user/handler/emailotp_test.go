@@ -0,0 +1,236 @@
+package handler_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/emailotp"
+	"github.com/LNMMusic/msauth/internal/user/handler"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Tests for HandlersEmailOTP.Request
+func TestHandlersEmailOTP_Request(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string; log string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUpEmailOTP func(mk *emailotp.EmailOTPMock)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - code requested",
+			input: input{body: `{"email": "johndoe@gmail.com"}`},
+			output: output{
+				code: http.StatusAccepted,
+				body: `{"message": "if the email is registered, a sign in code has been sent", "data": {"request_id": "request"}}`,
+			},
+			setUpEmailOTP: func(mk *emailotp.EmailOTPMock) {
+				mk.On("Request", "johndoe@gmail.com").Return("request", nil)
+			},
+		},
+		{
+			title: "valid case - failures with a request id are logged and responded the same",
+			input: input{body: `{"email": "johndoe@gmail.com"}`},
+			output: output{
+				code: http.StatusAccepted,
+				body: `{"message": "if the email is registered, a sign in code has been sent", "data": {"request_id": "request"}}`,
+				log: "handler: email code request failed: emailotp: internal error\n",
+			},
+			setUpEmailOTP: func(mk *emailotp.EmailOTPMock) {
+				mk.On("Request", "johndoe@gmail.com").Return("request", emailotp.ErrEmailOTPInternal)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "invalid json",
+			input: input{body: `{"email": }`},
+			output: output{code: http.StatusBadRequest, body: `"invalid json"`},
+			setUpEmailOTP: func(mk *emailotp.EmailOTPMock) {},
+		},
+		{
+			title: "missing email",
+			input: input{body: `{}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUpEmailOTP: func(mk *emailotp.EmailOTPMock) {},
+		},
+		{
+			title: "request id not issued",
+			input: input{body: `{"email": "johndoe@gmail.com"}`},
+			output: output{
+				code: http.StatusInternalServerError,
+				body: `"internal server error"`,
+				log: "handler: email code request failed: emailotp: internal error\n",
+			},
+			setUpEmailOTP: func(mk *emailotp.EmailOTPMock) {
+				mk.On("Request", "johndoe@gmail.com").Return("", emailotp.ErrEmailOTPInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			ot := emailotp.NewEmailOTPMock()
+			c.setUpEmailOTP(ot)
+			var buf bytes.Buffer
+			hd := handler.NewHandlersEmailOTP(ot, nil, nil, nil, &handler.ConfigLogin{Logger: log.New(&buf, "", 0)})
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/email-code", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+			hd.Request().ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			assert.Equal(t, c.output.log, buf.String())
+			ot.AssertExpectations(t)
+		})
+	}
+}
+
+// Tests for HandlersEmailOTP.Verify
+func TestHandlersEmailOTP_Verify(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUp func(ot *emailotp.EmailOTPMock, m mocksLogin)
+	}
+
+	johndoe := user.User{Id: 1, Username: optional.Some("johndoe"), IsActive: optional.Some(true)}
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - user signed in",
+			input: input{body: `{"request_id": "request", "code": "123456"}`},
+			output: output{code: http.StatusOK, body: `{"message": "user signed in", "data": {"token": "sign"}}`},
+			setUp: func(ot *emailotp.EmailOTPMock, m mocksLogin) {
+				ot.On("Verify", "request", "123456").Return(johndoe, nil)
+				m.tf.On("Enabled", 1).Return(false, nil)
+				m.jw.On("GenerateSign", mock.Anything).Return("sign", nil)
+			},
+		},
+		{
+			title: "valid case - two factor enabled responds a challenge",
+			input: input{body: `{"request_id": "request", "code": "123456"}`},
+			output: output{code: http.StatusOK, body: `{"message": "two factor code required", "data": {"challenge": "challenge"}}`},
+			setUp: func(ot *emailotp.EmailOTPMock, m mocksLogin) {
+				ot.On("Verify", "request", "123456").Return(johndoe, nil)
+				m.tf.On("Enabled", 1).Return(true, nil)
+				m.ch.On("Issue", 1).Return("challenge", nil)
+			},
+		},
+
+		// invalid cases
+		// -> request
+		{
+			title: "invalid json",
+			input: input{body: `{"request_id": }`},
+			output: output{code: http.StatusBadRequest, body: `"invalid json"`},
+			setUp: func(ot *emailotp.EmailOTPMock, m mocksLogin) {},
+		},
+		{
+			title: "missing code",
+			input: input{body: `{"request_id": "request"}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUp: func(ot *emailotp.EmailOTPMock, m mocksLogin) {},
+		},
+		{
+			title: "missing request id",
+			input: input{body: `{"code": "123456"}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUp: func(ot *emailotp.EmailOTPMock, m mocksLogin) {},
+		},
+		// -> email otp
+		{
+			title: "invalid or expired code",
+			input: input{body: `{"request_id": "request", "code": "123456"}`},
+			output: output{code: http.StatusUnauthorized, body: `"invalid or expired code"`},
+			setUp: func(ot *emailotp.EmailOTPMock, m mocksLogin) {
+				ot.On("Verify", "request", "123456").Return(user.User{}, emailotp.ErrEmailOTPInvalid)
+			},
+		},
+		{
+			title: "user inactive",
+			input: input{body: `{"request_id": "request", "code": "123456"}`},
+			output: output{code: http.StatusForbidden, body: `"user not active, verify your email first"`},
+			setUp: func(ot *emailotp.EmailOTPMock, m mocksLogin) {
+				ot.On("Verify", "request", "123456").Return(user.User{}, emailotp.ErrEmailOTPUserInactive)
+			},
+		},
+		{
+			title: "email otp internal error",
+			input: input{body: `{"request_id": "request", "code": "123456"}`},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(ot *emailotp.EmailOTPMock, m mocksLogin) {
+				ot.On("Verify", "request", "123456").Return(user.User{}, emailotp.ErrEmailOTPInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			ot := emailotp.NewEmailOTPMock()
+			m := newMocksLogin()
+			c.setUp(ot, m)
+			hd := handler.NewHandlersEmailOTP(ot, m.jw, m.tf, m.ch, &handler.ConfigLogin{})
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/email-code/verify", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+			hd.Verify().ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			ot.AssertExpectations(t)
+			m.assertExpectations(t)
+		})
+	}
+
+	t.Run("success - first sign in with a fresh session store", func(t *testing.T) {
+		// arrange
+		ot := emailotp.NewEmailOTPMock()
+		ot.On("Verify", "request", "123456").Return(johndoe, nil)
+		m := newMocksLogin()
+		m.tf.On("Enabled", 1).Return(false, nil)
+		jw, st := newJWTAuthSessions()
+		hd := handler.NewHandlersEmailOTP(ot, jw, m.tf, m.ch, &handler.ConfigLogin{})
+
+		// act
+		req := httptest.NewRequest(http.MethodPost, "/email-code/verify", strings.NewReader(`{"request_id": "request", "code": "123456"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		hd.Verify().ServeHTTP(res, req)
+
+		// assert
+		requireSignedIn(t, res, st, "1")
+		ot.AssertExpectations(t)
+		m.assertExpectations(t)
+	})
+}
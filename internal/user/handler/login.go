@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/jwtauth"
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/credential"
+	"github.com/LNMMusic/msauth/internal/user/linktoken"
+	"github.com/LNMMusic/msauth/internal/user/recovery"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/twofactor"
+	"github.com/LNMMusic/msauth/pkg/web/request"
+	"github.com/LNMMusic/msauth/pkg/web/response"
+	"github.com/LNMMusic/optional"
+)
+
+// NewHandlersLogin returns a new HandlersLogin struct
+// - ch issues the challenges of the two factor step, it should be scoped to its own purpose and short-lived
+func NewHandlersLogin(cr credential.Credential, stRead storage.StorageRead, jw jwtauth.JWTAuth, tf twofactor.TwoFactor, rc recovery.RecoveryCodes, ch linktoken.LinkToken, config *ConfigLogin) *HandlersLogin {
+	// default values
+	if !config.TokenTTL.IsSome() {
+		config.TokenTTL = optional.Some(24 * time.Hour)
+	}
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	return &HandlersLogin{
+		cr: credential.WithContext(cr),
+		stRead: storage.StorageReadWithContext(stRead),
+		jw: jwtauth.WithContext(jw),
+		tf: tf,
+		rc: rc,
+		ch: ch,
+		config: config,
+	}
+}
+
+// ConfigLogin is the configuration for the login handlers
+type ConfigLogin struct {
+	// TokenTTL is the time to live of the issued tokens
+	TokenTTL 	optional.Option[time.Duration]
+	// Logger logs the failures that are not responded, so emails can not be enumerated (default log.Default())
+	Logger 		*log.Logger
+}
+
+// HandlersLogin is the struct that contains the dependencies for the login handlers
+type HandlersLogin struct {
+	// cr is the credential interface to verify the user credentials
+	cr credential.CredentialContext
+	// stRead is the read interface for the user store
+	stRead storage.StorageReadContext
+	// jw is the jwt auth interface to sign the tokens
+	jw jwtauth.JWTAuthContext
+	// tf is the two factor interface to verify the codes
+	tf twofactor.TwoFactor
+	// rc is the recovery codes interface, the fallback of the two factor codes
+	rc recovery.RecoveryCodes
+	// ch is the link token interface to issue the challenges between the password and the two factor step
+	ch linktoken.LinkToken
+	// config is the configuration of the handlers
+	config *ConfigLogin
+}
+
+// UserSignIn is the request body for the sign in route
+// - either username or email is required
+type UserSignIn struct {
+	// Username is the username of the user
+	Username 	optional.Option[string] `json:"username"`
+	// Email is the email of the user
+	Email 		optional.Option[string] `json:"email"`
+	// Password is the password of the user
+	Password 	optional.Option[string] `json:"password"`
+}
+
+// SignIn is the handler for the sign in route
+// - if the user has two factor enabled, it responds a challenge instead of a token, see SignInTwoFactor
+func (h *HandlersLogin) SignIn() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - body: decode
+		var userSignIn UserSignIn
+		err := request.JSON(r, &userSignIn)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		// - body: validate
+		if !userSignIn.Password.IsSome() || (!userSignIn.Username.IsSome() && !userSignIn.Email.IsSome()) {
+			response.JSON(w, http.StatusBadRequest, "missing required fields")
+			return
+		}
+		password, _ := userSignIn.Password.Unwrap()
+
+		// process
+		// - verify credential and get user
+		var u user.User
+		if userSignIn.Username.IsSome() {
+			username, _ := userSignIn.Username.Unwrap()
+			err = h.cr.VerifyByUsernameContext(r.Context(), username, password)
+			if err == nil {
+				u, err = h.stRead.GetByUsernameContext(r.Context(), username)
+			}
+		} else {
+			email, _ := userSignIn.Email.Unwrap()
+			err = h.cr.VerifyByEmailContext(r.Context(), email, password)
+			if err == nil {
+				u, err = h.stRead.GetByEmailContext(r.Context(), email)
+			}
+		}
+		if err != nil {
+			switch err = credential.Generic(err); {
+			case errors.Is(err, credential.ErrCredentialInvalid):
+				response.JSON(w, http.StatusUnauthorized, "invalid credentials")
+			case errors.Is(err, credential.ErrCredentialUserInactive):
+				response.JSON(w, http.StatusForbidden, "user not active, verify your email first")
+			case errors.Is(err, credential.ErrCredentialLocked):
+				response.JSON(w, http.StatusTooManyRequests, "too many failed attempts, try again later")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+		// response
+		completeSignIn(r.Context(), w, h.jw, h.tf, h.ch, h.config, u)
+	}
+}
+
+// UserSignInTwoFactor is the request body for the two factor sign in route
+// - either code or recovery code is required
+type UserSignInTwoFactor struct {
+	// Challenge is the challenge responded by the sign in route
+	Challenge 		optional.Option[string] `json:"challenge"`
+	// Code is the code of the authenticator app
+	Code 			optional.Option[string] `json:"code"`
+	// RecoveryCode is a one-time recovery code
+	RecoveryCode 	optional.Option[string] `json:"recovery_code"`
+}
+
+// SignInTwoFactor is the handler for the second step of the sign in route
+// - the challenge is single-use, a wrong code requires to sign in again
+func (h *HandlersLogin) SignInTwoFactor() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body UserSignInTwoFactor
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if !body.Challenge.IsSome() || (!body.Code.IsSome() && !body.RecoveryCode.IsSome()) {
+			response.JSON(w, http.StatusBadRequest, "missing required fields")
+			return
+		}
+		challenge, _ := body.Challenge.Unwrap()
+
+		// process
+		// - consume challenge
+		userId, err := h.ch.Consume(challenge)
+		if err != nil {
+			switch {
+			case errors.Is(err, linktoken.ErrLinkTokenInvalid), errors.Is(err, linktoken.ErrLinkTokenExpired), errors.Is(err, linktoken.ErrLinkTokenUsed):
+				response.JSON(w, http.StatusUnauthorized, "invalid or expired challenge")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+		// - verify code
+		if body.Code.IsSome() {
+			code, _ := body.Code.Unwrap()
+			err = h.tf.Verify(userId, code)
+		} else {
+			code, _ := body.RecoveryCode.Unwrap()
+			err = h.rc.Use(userId, code)
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, twofactor.ErrTwoFactorCodeInvalid), errors.Is(err, twofactor.ErrTwoFactorNotEnrolled), errors.Is(err, recovery.ErrRecoveryCodeInvalid):
+				response.JSON(w, http.StatusUnauthorized, "invalid code")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+		// - get user
+		u, err := h.stRead.GetContext(r.Context(), userId)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrStorageNotFound):
+				response.JSON(w, http.StatusUnauthorized, "invalid or expired challenge")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		respondToken(r.Context(), w, h.jw, h.config, u)
+	}
+}
+
+// completeSignIn completes the first factor of a sign in
+// - if the user has two factor enabled, it responds a challenge for SignInTwoFactor instead of a token
+func completeSignIn(ctx context.Context, w http.ResponseWriter, jw jwtauth.JWTAuthContext, tf twofactor.TwoFactor, ch linktoken.LinkToken, config *ConfigLogin, u user.User) {
+	enabled, err := tf.Enabled(u.Id)
+	if err != nil {
+		response.JSON(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if enabled {
+		challenge, err := ch.Issue(u.Id)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "two factor code required",
+			"data": map[string]any{
+				"challenge": challenge,
+			},
+		})
+		return
+	}
+
+	respondToken(ctx, w, jw, config, u)
+}
+
+// respondToken signs a token for a user and responds it
+// - shared by all the sign in methods, so they produce the same token and session
+func respondToken(ctx context.Context, w http.ResponseWriter, jw jwtauth.JWTAuthContext, config *ConfigLogin, u user.User) {
+	sign, err := sign(ctx, jw, config, u)
+	if err != nil {
+		switch {
+		case errors.Is(err, jwtauth.ErrJWTAuthMaxSessions):
+			response.JSON(w, http.StatusForbidden, "max sessions reached")
+		default:
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": "user signed in",
+		"data": map[string]any{
+			"token": sign,
+		},
+	})
+}
+
+// sign generates a new signed token for a user
+func sign(ctx context.Context, jw jwtauth.JWTAuthContext, config *ConfigLogin, u user.User) (sign string, err error) {
+	ttl, _ := config.TokenTTL.Unwrap()
+	token, err := jwtauth.NewToken(strconv.Itoa(u.Id), ttl)
+	if err != nil {
+		return
+	}
+
+	sign, err = jw.GenerateSignContext(ctx, token)
+	return
+}
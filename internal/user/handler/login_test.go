@@ -0,0 +1,264 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/jwtauth"
+	"github.com/LNMMusic/msauth/internal/session"
+	"github.com/LNMMusic/msauth/internal/session/sessionauth"
+	sessionstorage "github.com/LNMMusic/msauth/internal/session/storage"
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/credential"
+	"github.com/LNMMusic/msauth/internal/user/handler"
+	"github.com/LNMMusic/msauth/internal/user/linktoken"
+	"github.com/LNMMusic/msauth/internal/user/recovery"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/twofactor"
+	"github.com/LNMMusic/optional"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newJWTAuthSessions returns a JWTAuth that tracks the sessions in a fresh session store, as on the first sign in of a user
+func newJWTAuthSessions() (jw *jwtauth.JWTAuthSessions, st *sessionstorage.StorageLocal) {
+	st = sessionstorage.NewStorageLocal(map[string][]*session.Session{})
+	jw = jwtauth.NewJWTAuthSessions(
+		jwtauth.NewJWTAuthBasic(&jwtauth.Config{SigningMethod: jwt.SigningMethodHS256, Secret: []byte("secret")}),
+		sessionauth.NewSessionAuthManagerDefault(st, &sessionauth.Config{}),
+	)
+	return
+}
+
+// requireSignedIn asserts a user signed in response and that its session is tracked in the session store
+func requireSignedIn(t *testing.T, res *httptest.ResponseRecorder, st *sessionstorage.StorageLocal, userId string) {
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	var body struct {
+		Message string 		`json:"message"`
+		Data 	struct {
+			Token string 	`json:"token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(t, "user signed in", body.Message)
+	require.NotEmpty(t, body.Data.Token)
+	sessions, err := st.Get(userId)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+}
+
+// mocksLogin are the dependencies of the login handlers
+type mocksLogin struct {
+	cr *credential.CredentialMock
+	rd *storage.StorageReadMock
+	jw *jwtauth.JWTAuthMock
+	tf *twofactor.TwoFactorMock
+	rc *recovery.RecoveryCodesMock
+	ch *linktoken.LinkTokenMock
+}
+
+// newMocksLogin returns the mocked dependencies of the login handlers
+func newMocksLogin() (m mocksLogin) {
+	m = mocksLogin{
+		cr: credential.NewCredentialMock(),
+		rd: storage.NewStorageReadMock(),
+		jw: jwtauth.NewJWTAuthMock(),
+		tf: twofactor.NewTwoFactorMock(),
+		rc: recovery.NewRecoveryCodesMock(),
+		ch: linktoken.NewLinkTokenMock(),
+	}
+	return
+}
+
+// assertExpectations asserts the expectations of all the mocks
+func (m mocksLogin) assertExpectations(t *testing.T) {
+	m.cr.AssertExpectations(t)
+	m.rd.AssertExpectations(t)
+	m.jw.AssertExpectations(t)
+	m.tf.AssertExpectations(t)
+	m.rc.AssertExpectations(t)
+	m.ch.AssertExpectations(t)
+}
+
+// Tests for HandlersLogin.SignIn
+func TestHandlersLogin_SignIn(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUp func(m mocksLogin)
+	}
+
+	johndoe := user.User{Id: 1, Username: optional.Some("johndoe"), Email: optional.Some("johndoe@gmail.com"), IsActive: optional.Some(true)}
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - sign in by username",
+			input: input{body: `{"username": "johndoe", "password": "password"}`},
+			output: output{code: http.StatusOK, body: `{"message": "user signed in", "data": {"token": "sign"}}`},
+			setUp: func(m mocksLogin) {
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(nil)
+				m.rd.On("GetByUsername", "johndoe").Return(johndoe, nil)
+				m.tf.On("Enabled", 1).Return(false, nil)
+				m.jw.On("GenerateSign", mock.MatchedBy(func(tk *jwtauth.Token) bool { return tk.Claims["user_id"] == "1" })).Return("sign", nil)
+			},
+		},
+		{
+			title: "valid case - sign in by email",
+			input: input{body: `{"email": "johndoe@gmail.com", "password": "password"}`},
+			output: output{code: http.StatusOK, body: `{"message": "user signed in", "data": {"token": "sign"}}`},
+			setUp: func(m mocksLogin) {
+				m.cr.On("VerifyByEmail", "johndoe@gmail.com", "password").Return(nil)
+				m.rd.On("GetByEmail", "johndoe@gmail.com").Return(johndoe, nil)
+				m.tf.On("Enabled", 1).Return(false, nil)
+				m.jw.On("GenerateSign", mock.Anything).Return("sign", nil)
+			},
+		},
+		{
+			title: "valid case - two factor enabled responds a challenge",
+			input: input{body: `{"username": "johndoe", "password": "password"}`},
+			output: output{code: http.StatusOK, body: `{"message": "two factor code required", "data": {"challenge": "challenge"}}`},
+			setUp: func(m mocksLogin) {
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(nil)
+				m.rd.On("GetByUsername", "johndoe").Return(johndoe, nil)
+				m.tf.On("Enabled", 1).Return(true, nil)
+				m.ch.On("Issue", 1).Return("challenge", nil)
+			},
+		},
+
+		// invalid cases
+		// -> request
+		{
+			title: "invalid json",
+			input: input{body: `{"username": }`},
+			output: output{code: http.StatusBadRequest, body: `"invalid json"`},
+			setUp: func(m mocksLogin) {},
+		},
+		{
+			title: "missing password",
+			input: input{body: `{"username": "johndoe"}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUp: func(m mocksLogin) {},
+		},
+		{
+			title: "missing username and email",
+			input: input{body: `{"password": "password"}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUp: func(m mocksLogin) {},
+		},
+		// -> credential
+		{
+			title: "unknown username",
+			input: input{body: `{"username": "johndoe", "password": "password"}`},
+			output: output{code: http.StatusUnauthorized, body: `"invalid credentials"`},
+			setUp: func(m mocksLogin) {
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(credential.ErrCredentialUsernameNotFound)
+			},
+		},
+		{
+			title: "wrong password",
+			input: input{body: `{"email": "johndoe@gmail.com", "password": "password"}`},
+			output: output{code: http.StatusUnauthorized, body: `"invalid credentials"`},
+			setUp: func(m mocksLogin) {
+				m.cr.On("VerifyByEmail", "johndoe@gmail.com", "password").Return(credential.ErrCredentialPasswordInvalid)
+			},
+		},
+		{
+			title: "user inactive",
+			input: input{body: `{"username": "johndoe", "password": "password"}`},
+			output: output{code: http.StatusForbidden, body: `"user not active, verify your email first"`},
+			setUp: func(m mocksLogin) {
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(credential.ErrCredentialUserInactive)
+			},
+		},
+		{
+			title: "user locked",
+			input: input{body: `{"username": "johndoe", "password": "password"}`},
+			output: output{code: http.StatusTooManyRequests, body: `"too many failed attempts, try again later"`},
+			setUp: func(m mocksLogin) {
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(credential.ErrCredentialLocked)
+			},
+		},
+		{
+			title: "credential internal error",
+			input: input{body: `{"username": "johndoe", "password": "password"}`},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksLogin) {
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(credential.ErrCredentialInternal)
+			},
+		},
+		// -> sign
+		{
+			title: "max sessions reached",
+			input: input{body: `{"username": "johndoe", "password": "password"}`},
+			output: output{code: http.StatusForbidden, body: `"max sessions reached"`},
+			setUp: func(m mocksLogin) {
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(nil)
+				m.rd.On("GetByUsername", "johndoe").Return(johndoe, nil)
+				m.tf.On("Enabled", 1).Return(false, nil)
+				m.jw.On("GenerateSign", mock.Anything).Return("", jwtauth.ErrJWTAuthMaxSessions)
+			},
+		},
+		{
+			title: "sign internal error",
+			input: input{body: `{"username": "johndoe", "password": "password"}`},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksLogin) {
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(nil)
+				m.rd.On("GetByUsername", "johndoe").Return(johndoe, nil)
+				m.tf.On("Enabled", 1).Return(false, nil)
+				m.jw.On("GenerateSign", mock.Anything).Return("", jwtauth.ErrJWTAuthInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			m := newMocksLogin()
+			c.setUp(m)
+			hd := handler.NewHandlersLogin(m.cr, m.rd, m.jw, m.tf, m.rc, m.ch, &handler.ConfigLogin{})
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/sign-in", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+			hd.SignIn().ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			m.assertExpectations(t)
+		})
+	}
+
+	t.Run("success - first sign in with a fresh session store", func(t *testing.T) {
+		// arrange
+		m := newMocksLogin()
+		m.cr.On("VerifyByUsername", "johndoe", "password").Return(nil)
+		m.rd.On("GetByUsername", "johndoe").Return(johndoe, nil)
+		m.tf.On("Enabled", 1).Return(false, nil)
+		jw, st := newJWTAuthSessions()
+		hd := handler.NewHandlersLogin(m.cr, m.rd, jw, m.tf, m.rc, m.ch, &handler.ConfigLogin{})
+
+		// act
+		req := httptest.NewRequest(http.MethodPost, "/sign-in", strings.NewReader(`{"username": "johndoe", "password": "password"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		hd.SignIn().ServeHTTP(res, req)
+
+		// assert
+		requireSignedIn(t, res, st, "1")
+		m.assertExpectations(t)
+	})
+}
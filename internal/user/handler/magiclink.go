@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/jwtauth"
+	"github.com/LNMMusic/msauth/internal/user/linktoken"
+	"github.com/LNMMusic/msauth/internal/user/magiclink"
+	"github.com/LNMMusic/msauth/internal/user/twofactor"
+	"github.com/LNMMusic/msauth/pkg/web/request"
+	"github.com/LNMMusic/msauth/pkg/web/response"
+	"github.com/LNMMusic/optional"
+)
+
+// NewHandlersMagicLink returns a new HandlersMagicLink struct
+// - tf, ch and config are the ones of the login handlers, so users with two factor enabled still need a code
+func NewHandlersMagicLink(mk magiclink.MagicLink, jw jwtauth.JWTAuth, tf twofactor.TwoFactor, ch linktoken.LinkToken, config *ConfigLogin) *HandlersMagicLink {
+	// default values
+	if !config.TokenTTL.IsSome() {
+		config.TokenTTL = optional.Some(24 * time.Hour)
+	}
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	return &HandlersMagicLink{
+		mk: mk,
+		jw: jwtauth.WithContext(jw),
+		tf: tf,
+		ch: ch,
+		config: config,
+	}
+}
+
+// HandlersMagicLink is the struct that contains the dependencies for the magic link handlers
+type HandlersMagicLink struct {
+	// mk is the magic link interface to send and consume the login links
+	mk magiclink.MagicLink
+	// jw is the jwt auth interface to sign the tokens
+	jw jwtauth.JWTAuthContext
+	// tf is the two factor interface to check if a code is required
+	tf twofactor.TwoFactor
+	// ch is the link token interface to issue the two factor challenges
+	ch linktoken.LinkToken
+	// config is the configuration of the tokens
+	config *ConfigLogin
+}
+
+// MagicLinkRequest is the request body for the request magic link route
+type MagicLinkRequest struct {
+	// Email is the email of the user
+	Email 	optional.Option[string] `json:"email"`
+}
+
+// Request is the handler for the request magic link route
+// - it responds the same whether the email is registered or not, failures are logged
+func (h *HandlersMagicLink) Request() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body MagicLinkRequest
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if !body.Email.IsSome() {
+			response.JSON(w, http.StatusBadRequest, "missing required fields")
+			return
+		}
+		email, _ := body.Email.Unwrap()
+
+		// process
+		err = h.mk.Send(email)
+		if err != nil {
+			h.config.Logger.Printf("handler: magic link request failed: %s", err.Error())
+		}
+
+		// response
+		response.JSON(w, http.StatusAccepted, map[string]any{
+			"message": "if the email is registered, a sign in link has been sent",
+		})
+	}
+}
+
+// Callback is the handler for the magic link callback route
+// - the token is read from the "token" query param
+func (h *HandlersMagicLink) Callback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			response.JSON(w, http.StatusBadRequest, "missing token")
+			return
+		}
+
+		// process
+		u, err := h.mk.Consume(token)
+		if err != nil {
+			switch {
+			case errors.Is(err, magiclink.ErrMagicLinkTokenInvalid):
+				response.JSON(w, http.StatusUnauthorized, "invalid or expired link")
+			case errors.Is(err, magiclink.ErrMagicLinkUserInactive):
+				response.JSON(w, http.StatusForbidden, "user not active, verify your email first")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		completeSignIn(r.Context(), w, h.jw, h.tf, h.ch, h.config, u)
+	}
+}
@@ -0,0 +1,207 @@
+package handler_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/handler"
+	"github.com/LNMMusic/msauth/internal/user/magiclink"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Tests for HandlersMagicLink.Request
+func TestHandlersMagicLink_Request(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string; log string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUpMagicLink func(mk *magiclink.MagicLinkMock)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - link requested",
+			input: input{body: `{"email": "johndoe@gmail.com"}`},
+			output: output{
+				code: http.StatusAccepted,
+				body: `{"message": "if the email is registered, a sign in link has been sent"}`,
+			},
+			setUpMagicLink: func(mk *magiclink.MagicLinkMock) {
+				mk.On("Send", "johndoe@gmail.com").Return(nil)
+			},
+		},
+		{
+			title: "valid case - failures are logged and responded the same",
+			input: input{body: `{"email": "johndoe@gmail.com"}`},
+			output: output{
+				code: http.StatusAccepted,
+				body: `{"message": "if the email is registered, a sign in link has been sent"}`,
+				log: "handler: magic link request failed: magiclink: internal error\n",
+			},
+			setUpMagicLink: func(mk *magiclink.MagicLinkMock) {
+				mk.On("Send", "johndoe@gmail.com").Return(magiclink.ErrMagicLinkInternal)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "invalid json",
+			input: input{body: `{"email": }`},
+			output: output{code: http.StatusBadRequest, body: `"invalid json"`},
+			setUpMagicLink: func(mk *magiclink.MagicLinkMock) {},
+		},
+		{
+			title: "missing email",
+			input: input{body: `{}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUpMagicLink: func(mk *magiclink.MagicLinkMock) {},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			mk := magiclink.NewMagicLinkMock()
+			c.setUpMagicLink(mk)
+			var buf bytes.Buffer
+			hd := handler.NewHandlersMagicLink(mk, nil, nil, nil, &handler.ConfigLogin{Logger: log.New(&buf, "", 0)})
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/magic-link", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+			hd.Request().ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			assert.Equal(t, c.output.log, buf.String())
+			mk.AssertExpectations(t)
+		})
+	}
+}
+
+// Tests for HandlersMagicLink.Callback
+func TestHandlersMagicLink_Callback(t *testing.T) {
+	type input struct { query string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUp func(mk *magiclink.MagicLinkMock, m mocksLogin)
+	}
+
+	johndoe := user.User{Id: 1, Username: optional.Some("johndoe"), IsActive: optional.Some(true)}
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - user signed in",
+			input: input{query: "?token=token"},
+			output: output{code: http.StatusOK, body: `{"message": "user signed in", "data": {"token": "sign"}}`},
+			setUp: func(mk *magiclink.MagicLinkMock, m mocksLogin) {
+				mk.On("Consume", "token").Return(johndoe, nil)
+				m.tf.On("Enabled", 1).Return(false, nil)
+				m.jw.On("GenerateSign", mock.Anything).Return("sign", nil)
+			},
+		},
+		{
+			title: "valid case - two factor enabled responds a challenge",
+			input: input{query: "?token=token"},
+			output: output{code: http.StatusOK, body: `{"message": "two factor code required", "data": {"challenge": "challenge"}}`},
+			setUp: func(mk *magiclink.MagicLinkMock, m mocksLogin) {
+				mk.On("Consume", "token").Return(johndoe, nil)
+				m.tf.On("Enabled", 1).Return(true, nil)
+				m.ch.On("Issue", 1).Return("challenge", nil)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "missing token",
+			input: input{query: ""},
+			output: output{code: http.StatusBadRequest, body: `"missing token"`},
+			setUp: func(mk *magiclink.MagicLinkMock, m mocksLogin) {},
+		},
+		{
+			title: "invalid or expired token",
+			input: input{query: "?token=token"},
+			output: output{code: http.StatusUnauthorized, body: `"invalid or expired link"`},
+			setUp: func(mk *magiclink.MagicLinkMock, m mocksLogin) {
+				mk.On("Consume", "token").Return(user.User{}, magiclink.ErrMagicLinkTokenInvalid)
+			},
+		},
+		{
+			title: "user inactive",
+			input: input{query: "?token=token"},
+			output: output{code: http.StatusForbidden, body: `"user not active, verify your email first"`},
+			setUp: func(mk *magiclink.MagicLinkMock, m mocksLogin) {
+				mk.On("Consume", "token").Return(user.User{}, magiclink.ErrMagicLinkUserInactive)
+			},
+		},
+		{
+			title: "magic link internal error",
+			input: input{query: "?token=token"},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(mk *magiclink.MagicLinkMock, m mocksLogin) {
+				mk.On("Consume", "token").Return(user.User{}, magiclink.ErrMagicLinkInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			mk := magiclink.NewMagicLinkMock()
+			m := newMocksLogin()
+			c.setUp(mk, m)
+			hd := handler.NewHandlersMagicLink(mk, m.jw, m.tf, m.ch, &handler.ConfigLogin{})
+
+			// act
+			req := httptest.NewRequest(http.MethodGet, "/magic-link/callback"+c.input.query, nil)
+			res := httptest.NewRecorder()
+			hd.Callback().ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			mk.AssertExpectations(t)
+			m.assertExpectations(t)
+		})
+	}
+
+	t.Run("success - first sign in with a fresh session store", func(t *testing.T) {
+		// arrange
+		mk := magiclink.NewMagicLinkMock()
+		mk.On("Consume", "token").Return(johndoe, nil)
+		m := newMocksLogin()
+		m.tf.On("Enabled", 1).Return(false, nil)
+		jw, st := newJWTAuthSessions()
+		hd := handler.NewHandlersMagicLink(mk, jw, m.tf, m.ch, &handler.ConfigLogin{})
+
+		// act
+		res := httptest.NewRecorder()
+		hd.Callback().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/magic-link/callback?token=token", nil))
+
+		// assert
+		requireSignedIn(t, res, st, "1")
+		mk.AssertExpectations(t)
+		m.assertExpectations(t)
+	})
+}
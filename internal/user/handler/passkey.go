@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/jwtauth"
+	"github.com/LNMMusic/msauth/internal/user/passkey"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/pkg/web/request"
+	"github.com/LNMMusic/msauth/pkg/web/response"
+	"github.com/LNMMusic/optional"
+)
+
+// NewHandlersPasskey returns a new HandlersPasskey struct
+// - config is the login configuration, passkey sign in issues the same tokens as the password sign in
+func NewHandlersPasskey(pk passkey.Passkey, stRead storage.StorageRead, jw jwtauth.JWTAuth, config *ConfigLogin) *HandlersPasskey {
+	// default values
+	if !config.TokenTTL.IsSome() {
+		config.TokenTTL = optional.Some(24 * time.Hour)
+	}
+
+	return &HandlersPasskey{
+		pk: pk,
+		stRead: storage.StorageReadWithContext(stRead),
+		jw: jwtauth.WithContext(jw),
+		config: config,
+	}
+}
+
+// HandlersPasskey is the struct that contains the dependencies for the passkey handlers
+type HandlersPasskey struct {
+	// pk is the passkey interface to run the WebAuthn ceremonies
+	pk passkey.Passkey
+	// stRead is the read interface for the user store
+	stRead storage.StorageReadContext
+	// jw is the jwt auth interface to sign the tokens
+	jw jwtauth.JWTAuthContext
+	// config is the configuration of the tokens
+	config *ConfigLogin
+}
+
+// PasskeyCredential is the request body of the finish routes, the PublicKeyCredential of the browser
+// - binary fields are base64url encoded
+type PasskeyCredential struct {
+	// RawID is the raw id of the credential
+	RawID 		optional.Option[string] `json:"rawId"`
+	// Response is the response of the authenticator
+	Response 	struct {
+		// ClientDataJSON is the client data
+		ClientDataJSON 		optional.Option[string] `json:"clientDataJSON"`
+		// AttestationObject is the attestation object (registration)
+		AttestationObject 	optional.Option[string] `json:"attestationObject"`
+		// AuthenticatorData is the authenticator data (login)
+		AuthenticatorData 	optional.Option[string] `json:"authenticatorData"`
+		// Signature is the signature (login)
+		Signature 			optional.Option[string] `json:"signature"`
+		// UserHandle is the user handle (login)
+		UserHandle 			optional.Option[string] `json:"userHandle"`
+	} `json:"response"`
+}
+
+// BeginRegistration is the handler for the begin passkey registration route
+// - it requires the jwtauth.Authenticate middleware
+func (h *HandlersPasskey) BeginRegistration() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := userIdFromContext(r)
+		if !ok {
+			response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		// process
+		u, err := h.stRead.GetContext(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrStorageNotFound):
+				response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+		name, _ := u.Username.Unwrap()
+		options, err := h.pk.BeginRegistration(id, name)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "passkey registration started",
+			"data": map[string]any{
+				"publicKey": options,
+			},
+		})
+	}
+}
+
+// FinishRegistration is the handler for the finish passkey registration route
+// - it requires the jwtauth.Authenticate middleware
+func (h *HandlersPasskey) FinishRegistration() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := userIdFromContext(r)
+		if !ok {
+			response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		var body PasskeyCredential
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		var rr passkey.RegistrationResponse
+		rr.ClientDataJSON, ok = decodeBase64URL(body.Response.ClientDataJSON)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid credential")
+			return
+		}
+		rr.AttestationObject, ok = decodeBase64URL(body.Response.AttestationObject)
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid credential")
+			return
+		}
+
+		// process
+		err = h.pk.FinishRegistration(id, rr)
+		if err != nil {
+			switch {
+			case errors.Is(err, passkey.ErrPasskeyInvalid), errors.Is(err, passkey.ErrPasskeyChallenge):
+				response.JSON(w, http.StatusBadRequest, "invalid credential")
+			case errors.Is(err, passkey.ErrPasskeyCredentialExists):
+				response.JSON(w, http.StatusConflict, "passkey already registered")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusCreated, map[string]any{
+			"message": "passkey registered",
+		})
+	}
+}
+
+// BeginSignIn is the handler for the begin passkey sign in route
+func (h *HandlersPasskey) BeginSignIn() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// process
+		options, err := h.pk.BeginLogin()
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "passkey sign in started",
+			"data": map[string]any{
+				"publicKey": options,
+			},
+		})
+	}
+}
+
+// FinishSignIn is the handler for the finish passkey sign in route
+// - it responds the same token as the password sign in
+func (h *HandlersPasskey) FinishSignIn() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body PasskeyCredential
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		var a passkey.AssertionResponse
+		var ok bool
+		a.CredentialID, ok = decodeBase64URL(body.RawID)
+		if ok {
+			a.ClientDataJSON, ok = decodeBase64URL(body.Response.ClientDataJSON)
+		}
+		if ok {
+			a.AuthenticatorData, ok = decodeBase64URL(body.Response.AuthenticatorData)
+		}
+		if ok {
+			a.Signature, ok = decodeBase64URL(body.Response.Signature)
+		}
+		if ok && body.Response.UserHandle.IsSome() {
+			a.UserHandle, ok = decodeBase64URL(body.Response.UserHandle)
+		}
+		if !ok {
+			response.JSON(w, http.StatusBadRequest, "invalid credential")
+			return
+		}
+
+		// process
+		// - verify assertion
+		userId, err := h.pk.FinishLogin(a)
+		if err != nil {
+			switch {
+			case errors.Is(err, passkey.ErrPasskeyInvalid), errors.Is(err, passkey.ErrPasskeyChallenge), errors.Is(err, passkey.ErrPasskeyCredentialNotFound), errors.Is(err, passkey.ErrPasskeySignCount):
+				response.JSON(w, http.StatusUnauthorized, "invalid credentials")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+		// - get user
+		u, err := h.stRead.GetContext(r.Context(), userId)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrStorageNotFound):
+				response.JSON(w, http.StatusUnauthorized, "invalid credentials")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+		if isActive, _ := u.IsActive.Unwrap(); !isActive {
+			response.JSON(w, http.StatusForbidden, "user not active, verify your email first")
+			return
+		}
+
+		// response
+		respondToken(r.Context(), w, h.jw, h.config, u)
+	}
+}
+
+// decodeBase64URL decodes a required base64url field
+func decodeBase64URL(field optional.Option[string]) (b []byte, ok bool) {
+	s, err := field.Unwrap()
+	if err != nil {
+		return
+	}
+	b, err = base64.RawURLEncoding.DecodeString(s)
+	ok = err == nil
+	return
+}
@@ -0,0 +1,399 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/jwtauth"
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/handler"
+	"github.com/LNMMusic/msauth/internal/user/passkey"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mocksPasskey are the dependencies of the passkey handlers
+type mocksPasskey struct {
+	pk *passkey.PasskeyMock
+	rd *storage.StorageReadMock
+	jw *jwtauth.JWTAuthMock
+}
+
+// newMocksPasskey returns the mocked dependencies of the passkey handlers
+func newMocksPasskey() (m mocksPasskey) {
+	m = mocksPasskey{pk: passkey.NewPasskeyMock(), rd: storage.NewStorageReadMock(), jw: jwtauth.NewJWTAuthMock()}
+	return
+}
+
+// assertExpectations asserts the expectations of all the mocks
+func (m mocksPasskey) assertExpectations(t *testing.T) {
+	m.pk.AssertExpectations(t)
+	m.rd.AssertExpectations(t)
+	m.jw.AssertExpectations(t)
+}
+
+// Tests for HandlersPasskey.BeginRegistration
+func TestHandlersPasskey_BeginRegistration(t *testing.T) {
+	type input struct { sign string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUp func(m mocksPasskey)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - options of the user",
+			input: input{sign: "sign"},
+			output: output{code: http.StatusOK, body: `{"message": "passkey registration started", "data": {"publicKey": {"challenge": "challenge"}}}`},
+			setUp: func(m mocksPasskey) {
+				m.rd.On("Get", 1).Return(user.User{Id: 1, Username: optional.Some("johndoe")}, nil)
+				m.pk.On("BeginRegistration", 1, "johndoe").Return(map[string]any{"challenge": "challenge"}, nil)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "token without user id",
+			input: input{sign: "sign-no-user"},
+			output: output{code: http.StatusUnauthorized, body: `"unauthorized"`},
+			setUp: func(m mocksPasskey) {},
+		},
+		{
+			title: "user not found",
+			input: input{sign: "sign"},
+			output: output{code: http.StatusUnauthorized, body: `"unauthorized"`},
+			setUp: func(m mocksPasskey) {
+				m.rd.On("Get", 1).Return(user.User{}, storage.ErrStorageNotFound)
+			},
+		},
+		{
+			title: "passkey internal error",
+			input: input{sign: "sign"},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksPasskey) {
+				m.rd.On("Get", 1).Return(user.User{Id: 1, Username: optional.Some("johndoe")}, nil)
+				m.pk.On("BeginRegistration", 1, "johndoe").Return(nil, passkey.ErrPasskeyInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			m := newMocksPasskey()
+			c.setUp(m)
+			hd := handler.NewHandlersPasskey(m.pk, m.rd, m.jw, &handler.ConfigLogin{})
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/passkeys/registration/begin", nil)
+			req.Header.Set("Authorization", "Bearer "+c.input.sign)
+			res := httptest.NewRecorder()
+			authenticated(hd.BeginRegistration()).ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			m.assertExpectations(t)
+		})
+	}
+}
+
+// Tests for HandlersPasskey.FinishRegistration
+func TestHandlersPasskey_FinishRegistration(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUp func(m mocksPasskey)
+	}
+
+	rr := passkey.RegistrationResponse{ClientDataJSON: []byte("cdj"), AttestationObject: []byte("att")}
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - passkey registered",
+			input: input{body: `{"rawId": "aWQ", "response": {"clientDataJSON": "Y2Rq", "attestationObject": "YXR0"}}`},
+			output: output{code: http.StatusCreated, body: `{"message": "passkey registered"}`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishRegistration", 1, rr).Return(nil)
+			},
+		},
+
+		// invalid cases
+		// -> request
+		{
+			title: "invalid json",
+			input: input{body: `{"rawId": }`},
+			output: output{code: http.StatusBadRequest, body: `"invalid json"`},
+			setUp: func(m mocksPasskey) {},
+		},
+		{
+			title: "missing attestation object",
+			input: input{body: `{"rawId": "aWQ", "response": {"clientDataJSON": "Y2Rq"}}`},
+			output: output{code: http.StatusBadRequest, body: `"invalid credential"`},
+			setUp: func(m mocksPasskey) {},
+		},
+		{
+			title: "client data not base64url",
+			input: input{body: `{"rawId": "aWQ", "response": {"clientDataJSON": "Y2Rq==", "attestationObject": "YXR0"}}`},
+			output: output{code: http.StatusBadRequest, body: `"invalid credential"`},
+			setUp: func(m mocksPasskey) {},
+		},
+		// -> passkey
+		{
+			title: "invalid attestation",
+			input: input{body: `{"rawId": "aWQ", "response": {"clientDataJSON": "Y2Rq", "attestationObject": "YXR0"}}`},
+			output: output{code: http.StatusBadRequest, body: `"invalid credential"`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishRegistration", 1, rr).Return(passkey.ErrPasskeyInvalid)
+			},
+		},
+		{
+			title: "challenge expired",
+			input: input{body: `{"rawId": "aWQ", "response": {"clientDataJSON": "Y2Rq", "attestationObject": "YXR0"}}`},
+			output: output{code: http.StatusBadRequest, body: `"invalid credential"`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishRegistration", 1, rr).Return(passkey.ErrPasskeyChallenge)
+			},
+		},
+		{
+			title: "passkey already registered",
+			input: input{body: `{"rawId": "aWQ", "response": {"clientDataJSON": "Y2Rq", "attestationObject": "YXR0"}}`},
+			output: output{code: http.StatusConflict, body: `"passkey already registered"`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishRegistration", 1, rr).Return(passkey.ErrPasskeyCredentialExists)
+			},
+		},
+		{
+			title: "passkey internal error",
+			input: input{body: `{"rawId": "aWQ", "response": {"clientDataJSON": "Y2Rq", "attestationObject": "YXR0"}}`},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishRegistration", 1, rr).Return(passkey.ErrPasskeyInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			m := newMocksPasskey()
+			c.setUp(m)
+			hd := handler.NewHandlersPasskey(m.pk, m.rd, m.jw, &handler.ConfigLogin{})
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/passkeys/registration/finish", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer sign")
+			res := httptest.NewRecorder()
+			authenticated(hd.FinishRegistration()).ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			m.assertExpectations(t)
+		})
+	}
+}
+
+// Tests for HandlersPasskey.BeginSignIn
+func TestHandlersPasskey_BeginSignIn(t *testing.T) {
+	t.Run("success - options responded", func(t *testing.T) {
+		// arrange
+		m := newMocksPasskey()
+		m.pk.On("BeginLogin").Return(map[string]any{"challenge": "challenge"}, nil)
+		hd := handler.NewHandlersPasskey(m.pk, m.rd, m.jw, &handler.ConfigLogin{})
+
+		// act
+		res := httptest.NewRecorder()
+		hd.BeginSignIn().ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/passkeys/sign-in/begin", nil))
+
+		// assert
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.JSONEq(t, `{"message": "passkey sign in started", "data": {"publicKey": {"challenge": "challenge"}}}`, res.Body.String())
+		m.assertExpectations(t)
+	})
+
+	t.Run("failure - passkey internal error", func(t *testing.T) {
+		// arrange
+		m := newMocksPasskey()
+		m.pk.On("BeginLogin").Return(nil, passkey.ErrPasskeyInternal)
+		hd := handler.NewHandlersPasskey(m.pk, m.rd, m.jw, &handler.ConfigLogin{})
+
+		// act
+		res := httptest.NewRecorder()
+		hd.BeginSignIn().ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/passkeys/sign-in/begin", nil))
+
+		// assert
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+		assert.JSONEq(t, `"internal server error"`, res.Body.String())
+		m.assertExpectations(t)
+	})
+}
+
+// Tests for HandlersPasskey.FinishSignIn
+func TestHandlersPasskey_FinishSignIn(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUp func(m mocksPasskey)
+	}
+
+	body := `{"rawId": "aWQ", "response": {"clientDataJSON": "Y2Rq", "authenticatorData": "YWQ", "signature": "c2ln", "userHandle": "dWg"}}`
+	a := passkey.AssertionResponse{CredentialID: []byte("id"), ClientDataJSON: []byte("cdj"), AuthenticatorData: []byte("ad"), Signature: []byte("sig"), UserHandle: []byte("uh")}
+	johndoe := user.User{Id: 1, Username: optional.Some("johndoe"), IsActive: optional.Some(true)}
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - user signed in",
+			input: input{body: body},
+			output: output{code: http.StatusOK, body: `{"message": "user signed in", "data": {"token": "sign"}}`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishLogin", a).Return(1, nil)
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.jw.On("GenerateSign", mock.Anything).Return("sign", nil)
+			},
+		},
+		{
+			title: "valid case - user handle is optional",
+			input: input{body: `{"rawId": "aWQ", "response": {"clientDataJSON": "Y2Rq", "authenticatorData": "YWQ", "signature": "c2ln"}}`},
+			output: output{code: http.StatusOK, body: `{"message": "user signed in", "data": {"token": "sign"}}`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishLogin", passkey.AssertionResponse{CredentialID: a.CredentialID, ClientDataJSON: a.ClientDataJSON, AuthenticatorData: a.AuthenticatorData, Signature: a.Signature}).Return(1, nil)
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.jw.On("GenerateSign", mock.Anything).Return("sign", nil)
+			},
+		},
+
+		// invalid cases
+		// -> request
+		{
+			title: "invalid json",
+			input: input{body: `{"rawId": }`},
+			output: output{code: http.StatusBadRequest, body: `"invalid json"`},
+			setUp: func(m mocksPasskey) {},
+		},
+		{
+			title: "missing signature",
+			input: input{body: `{"rawId": "aWQ", "response": {"clientDataJSON": "Y2Rq", "authenticatorData": "YWQ"}}`},
+			output: output{code: http.StatusBadRequest, body: `"invalid credential"`},
+			setUp: func(m mocksPasskey) {},
+		},
+		// -> passkey
+		{
+			title: "credential not registered",
+			input: input{body: body},
+			output: output{code: http.StatusUnauthorized, body: `"invalid credentials"`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishLogin", a).Return(0, passkey.ErrPasskeyCredentialNotFound)
+			},
+		},
+		{
+			title: "sign counter did not increase",
+			input: input{body: body},
+			output: output{code: http.StatusUnauthorized, body: `"invalid credentials"`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishLogin", a).Return(0, passkey.ErrPasskeySignCount)
+			},
+		},
+		{
+			title: "passkey internal error",
+			input: input{body: body},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishLogin", a).Return(0, passkey.ErrPasskeyInternal)
+			},
+		},
+		// -> user
+		{
+			title: "user not found",
+			input: input{body: body},
+			output: output{code: http.StatusUnauthorized, body: `"invalid credentials"`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishLogin", a).Return(1, nil)
+				m.rd.On("Get", 1).Return(user.User{}, storage.ErrStorageNotFound)
+			},
+		},
+		{
+			title: "storage internal error",
+			input: input{body: body},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishLogin", a).Return(1, nil)
+				m.rd.On("Get", 1).Return(user.User{}, errors.New("connection refused"))
+			},
+		},
+		{
+			title: "user inactive",
+			input: input{body: body},
+			output: output{code: http.StatusForbidden, body: `"user not active, verify your email first"`},
+			setUp: func(m mocksPasskey) {
+				m.pk.On("FinishLogin", a).Return(1, nil)
+				m.rd.On("Get", 1).Return(user.User{Id: 1, IsActive: optional.Some(false)}, nil)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			m := newMocksPasskey()
+			c.setUp(m)
+			hd := handler.NewHandlersPasskey(m.pk, m.rd, m.jw, &handler.ConfigLogin{})
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/passkeys/sign-in/finish", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+			hd.FinishSignIn().ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			m.assertExpectations(t)
+		})
+	}
+
+	t.Run("success - first sign in with a fresh session store", func(t *testing.T) {
+		// arrange
+		m := newMocksPasskey()
+		m.pk.On("FinishLogin", a).Return(1, nil)
+		m.rd.On("Get", 1).Return(johndoe, nil)
+		jw, st := newJWTAuthSessions()
+		hd := handler.NewHandlersPasskey(m.pk, m.rd, jw, &handler.ConfigLogin{})
+
+		// act
+		req := httptest.NewRequest(http.MethodPost, "/passkeys/sign-in/finish", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		hd.FinishSignIn().ServeHTTP(res, req)
+
+		// assert
+		requireSignedIn(t, res, st, "1")
+		m.pk.AssertExpectations(t)
+		m.rd.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/LNMMusic/msauth/internal/jwtauth"
+	"github.com/LNMMusic/msauth/internal/session/sessionauth"
+	"github.com/LNMMusic/msauth/internal/user/credential"
+	"github.com/LNMMusic/msauth/internal/user/passwordreset"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/pkg/web/request"
+	"github.com/LNMMusic/msauth/pkg/web/response"
+	"github.com/LNMMusic/optional"
+)
+
+// NewHandlersPassword returns a new HandlersPassword struct
+// - logger logs the failed reset requests, in case of nil log.Default() is used
+func NewHandlersPassword(pr passwordreset.PasswordReset, cr credential.Credential, stRead storage.StorageRead, stWrite storage.StorageWrite, ss sessionauth.SessionAuthManager, logger *log.Logger) *HandlersPassword {
+	// default values
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	return &HandlersPassword{
+		pr: pr,
+		cr: credential.WithContext(cr),
+		stRead: storage.StorageReadWithContext(stRead),
+		stWrite: storage.StorageWriteWithContext(stWrite),
+		ss: sessionauth.WithContext(ss),
+		logger: logger,
+	}
+}
+
+// HandlersPassword is the struct that contains the dependencies for the password handlers
+type HandlersPassword struct {
+	// pr is the password reset interface to recover the accounts
+	pr passwordreset.PasswordReset
+	// cr is the credential interface to verify the current password
+	cr credential.CredentialContext
+	// stRead is the read interface for the user store
+	stRead storage.StorageReadContext
+	// stWrite is the write interface for the user store
+	// - it is expected to validate and encrypt the password (e.g. storage.StorageWriteValidation)
+	stWrite storage.StorageWriteContext
+	// ss is the session auth manager to revoke the other sessions
+	ss sessionauth.SessionAuthManagerContext
+	// logger logs the failed reset requests, they are not responded so emails can not be enumerated
+	logger *log.Logger
+}
+
+// PasswordResetRequest is the request body for the request reset route
+type PasswordResetRequest struct {
+	// Email is the email of the user
+	Email 		optional.Option[string] `json:"email"`
+}
+
+// RequestReset is the handler for the request reset route
+// - it responds the same whether the email is registered or not, failures are logged
+func (h *HandlersPassword) RequestReset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body PasswordResetRequest
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if !body.Email.IsSome() {
+			response.JSON(w, http.StatusBadRequest, "missing required fields")
+			return
+		}
+		email, _ := body.Email.Unwrap()
+
+		// process
+		err = h.pr.Request(email)
+		if err != nil {
+			h.logger.Printf("handler: password reset request failed: %s", err.Error())
+		}
+
+		// response
+		response.JSON(w, http.StatusAccepted, map[string]any{
+			"message": "if the email is registered, a reset link has been sent",
+		})
+	}
+}
+
+// PasswordResetConfirm is the request body for the confirm reset route
+type PasswordResetConfirm struct {
+	// Token is the reset token
+	Token 		optional.Option[string] `json:"token"`
+	// Password is the new password of the user
+	Password 	optional.Option[string] `json:"password"`
+}
+
+// ConfirmReset is the handler for the confirm reset route
+func (h *HandlersPassword) ConfirmReset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body PasswordResetConfirm
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if !body.Token.IsSome() || !body.Password.IsSome() {
+			response.JSON(w, http.StatusBadRequest, "missing required fields")
+			return
+		}
+		token, _ := body.Token.Unwrap()
+		password, _ := body.Password.Unwrap()
+
+		// process
+		err = h.pr.Confirm(token, password)
+		if err != nil {
+			switch {
+			case errors.Is(err, passwordreset.ErrPasswordResetTokenInvalid):
+				response.JSON(w, http.StatusBadRequest, "invalid or expired token")
+			case errors.Is(err, passwordreset.ErrPasswordResetPasswordInvalid):
+				response.JSON(w, http.StatusUnprocessableEntity, "invalid password")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "password reset, sign in again",
+		})
+	}
+}
+
+// PasswordChange is the request body for the change password route
+type PasswordChange struct {
+	// CurrentPassword is the current password of the user
+	CurrentPassword 	optional.Option[string] `json:"current_password"`
+	// NewPassword is the new password of the user
+	NewPassword 		optional.Option[string] `json:"new_password"`
+	// RevokeOtherSessions revokes all the sessions of the user but the current one (default false)
+	RevokeOtherSessions optional.Option[bool] 	`json:"revoke_other_sessions"`
+}
+
+// Change is the handler for the change password route
+// - it requires the jwtauth.Authenticate middleware
+func (h *HandlersPassword) Change() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - token
+		id, ok := userIdFromContext(r)
+		if !ok {
+			response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		// - body
+		var body PasswordChange
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if !body.CurrentPassword.IsSome() || !body.NewPassword.IsSome() {
+			response.JSON(w, http.StatusBadRequest, "missing required fields")
+			return
+		}
+		currentPassword, _ := body.CurrentPassword.Unwrap()
+		newPassword, _ := body.NewPassword.Unwrap()
+		revokeOtherSessions, _ := body.RevokeOtherSessions.Unwrap()
+
+		// process
+		// - verify current password
+		u, err := h.stRead.GetContext(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrStorageNotFound):
+				response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+		username, _ := u.Username.Unwrap()
+		err = h.cr.VerifyByUsernameContext(r.Context(), username, currentPassword)
+		if err != nil {
+			switch err = credential.Generic(err); {
+			case errors.Is(err, credential.ErrCredentialInvalid):
+				response.JSON(w, http.StatusForbidden, "invalid current password")
+			case errors.Is(err, credential.ErrCredentialUserInactive):
+				response.JSON(w, http.StatusForbidden, "user not active, verify your email first")
+			case errors.Is(err, credential.ErrCredentialLocked):
+				response.JSON(w, http.StatusTooManyRequests, "too many failed attempts, try again later")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+		// - update password
+		err = h.stWrite.UpdatePasswordContext(r.Context(), id, newPassword)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrStorageInvalid):
+				response.JSON(w, http.StatusUnprocessableEntity, "invalid password")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+		// - revoke other sessions
+		// - the sessions are tracked by the "user_id" claim, set by jwtauth.NewToken as the decimal id
+		if revokeOtherSessions {
+			token, _ := jwtauth.TokenFromContext(r.Context())
+			err = h.ss.RevokeOtherSessionsContext(r.Context(), strconv.Itoa(id), token.ID)
+			if err != nil {
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "password changed",
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package handler_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/session/sessionauth"
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/credential"
+	"github.com/LNMMusic/msauth/internal/user/handler"
+	"github.com/LNMMusic/msauth/internal/user/passwordreset"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests for HandlersPassword.RequestReset
+func TestHandlersPassword_RequestReset(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string; log string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUpPasswordReset func(mk *passwordreset.PasswordResetMock)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - reset requested",
+			input: input{body: `{"email": "johndoe@gmail.com"}`},
+			output: output{
+				code: http.StatusAccepted,
+				body: `{"message": "if the email is registered, a reset link has been sent"}`,
+			},
+			setUpPasswordReset: func(mk *passwordreset.PasswordResetMock) {
+				mk.On("Request", "johndoe@gmail.com").Return(nil)
+			},
+		},
+		{
+			title: "valid case - failures are logged and responded the same",
+			input: input{body: `{"email": "johndoe@gmail.com"}`},
+			output: output{
+				code: http.StatusAccepted,
+				body: `{"message": "if the email is registered, a reset link has been sent"}`,
+				log: "handler: password reset request failed: passwordreset: internal error\n",
+			},
+			setUpPasswordReset: func(mk *passwordreset.PasswordResetMock) {
+				mk.On("Request", "johndoe@gmail.com").Return(passwordreset.ErrPasswordResetInternal)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "invalid json",
+			input: input{body: `{"email": }`},
+			output: output{code: http.StatusBadRequest, body: `"invalid json"`},
+			setUpPasswordReset: func(mk *passwordreset.PasswordResetMock) {},
+		},
+		{
+			title: "missing email",
+			input: input{body: `{}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUpPasswordReset: func(mk *passwordreset.PasswordResetMock) {},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			pr := passwordreset.NewPasswordResetMock()
+			c.setUpPasswordReset(pr)
+			var buf bytes.Buffer
+			hd := handler.NewHandlersPassword(pr, nil, nil, nil, nil, log.New(&buf, "", 0))
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/password/reset", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+			hd.RequestReset().ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			assert.Equal(t, c.output.log, buf.String())
+			pr.AssertExpectations(t)
+		})
+	}
+}
+
+// Tests for HandlersPassword.ConfirmReset
+func TestHandlersPassword_ConfirmReset(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUpPasswordReset func(mk *passwordreset.PasswordResetMock)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - password reset",
+			input: input{body: `{"token": "token", "password": "N3w-Password!"}`},
+			output: output{code: http.StatusOK, body: `{"message": "password reset, sign in again"}`},
+			setUpPasswordReset: func(mk *passwordreset.PasswordResetMock) {
+				mk.On("Confirm", "token", "N3w-Password!").Return(nil)
+			},
+		},
+
+		// invalid cases
+		// -> request
+		{
+			title: "invalid json",
+			input: input{body: `{"token": }`},
+			output: output{code: http.StatusBadRequest, body: `"invalid json"`},
+			setUpPasswordReset: func(mk *passwordreset.PasswordResetMock) {},
+		},
+		{
+			title: "missing password",
+			input: input{body: `{"token": "token"}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUpPasswordReset: func(mk *passwordreset.PasswordResetMock) {},
+		},
+		{
+			title: "missing token",
+			input: input{body: `{"password": "N3w-Password!"}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUpPasswordReset: func(mk *passwordreset.PasswordResetMock) {},
+		},
+		// -> password reset
+		{
+			title: "invalid or expired token",
+			input: input{body: `{"token": "token", "password": "N3w-Password!"}`},
+			output: output{code: http.StatusBadRequest, body: `"invalid or expired token"`},
+			setUpPasswordReset: func(mk *passwordreset.PasswordResetMock) {
+				mk.On("Confirm", "token", "N3w-Password!").Return(passwordreset.ErrPasswordResetTokenInvalid)
+			},
+		},
+		{
+			title: "invalid password",
+			input: input{body: `{"token": "token", "password": "weak"}`},
+			output: output{code: http.StatusUnprocessableEntity, body: `"invalid password"`},
+			setUpPasswordReset: func(mk *passwordreset.PasswordResetMock) {
+				mk.On("Confirm", "token", "weak").Return(passwordreset.ErrPasswordResetPasswordInvalid)
+			},
+		},
+		{
+			title: "password reset internal error",
+			input: input{body: `{"token": "token", "password": "N3w-Password!"}`},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUpPasswordReset: func(mk *passwordreset.PasswordResetMock) {
+				mk.On("Confirm", "token", "N3w-Password!").Return(passwordreset.ErrPasswordResetInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			pr := passwordreset.NewPasswordResetMock()
+			c.setUpPasswordReset(pr)
+			hd := handler.NewHandlersPassword(pr, nil, nil, nil, nil, nil)
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/password/reset/confirm", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+			hd.ConfirmReset().ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			pr.AssertExpectations(t)
+		})
+	}
+}
+
+// mocksPassword are the dependencies of the change password handler
+type mocksPassword struct {
+	cr *credential.CredentialMock
+	rd *storage.StorageReadMock
+	wr *storage.StorageWriteMock
+	ss *sessionauth.SessionAuthMock
+}
+
+// Tests for HandlersPassword.Change
+func TestHandlersPassword_Change(t *testing.T) {
+	type input struct { sign string; body string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUp func(m mocksPassword)
+	}
+
+	body := `{"current_password": "password", "new_password": "N3w-Password!"}`
+	johndoe := user.User{Id: 1, Username: optional.Some("johndoe"), IsActive: optional.Some(true)}
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - password changed",
+			input: input{sign: "sign", body: body},
+			output: output{code: http.StatusOK, body: `{"message": "password changed"}`},
+			setUp: func(m mocksPassword) {
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(nil)
+				m.wr.On("UpdatePassword", 1, "N3w-Password!").Return(nil)
+			},
+		},
+		{
+			title: "valid case - password changed and other sessions revoked",
+			input: input{sign: "sign", body: `{"current_password": "password", "new_password": "N3w-Password!", "revoke_other_sessions": true}`},
+			output: output{code: http.StatusOK, body: `{"message": "password changed"}`},
+			setUp: func(m mocksPassword) {
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(nil)
+				m.wr.On("UpdatePassword", 1, "N3w-Password!").Return(nil)
+				m.ss.On("RevokeOtherSessions", "1", "token_id").Return(nil)
+			},
+		},
+
+		// invalid cases
+		// -> request
+		{
+			title: "token without user id",
+			input: input{sign: "sign-no-user", body: body},
+			output: output{code: http.StatusUnauthorized, body: `"unauthorized"`},
+			setUp: func(m mocksPassword) {},
+		},
+		{
+			title: "invalid json",
+			input: input{sign: "sign", body: `{"current_password": }`},
+			output: output{code: http.StatusBadRequest, body: `"invalid json"`},
+			setUp: func(m mocksPassword) {},
+		},
+		{
+			title: "missing new password",
+			input: input{sign: "sign", body: `{"current_password": "password"}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUp: func(m mocksPassword) {},
+		},
+		// -> user
+		{
+			title: "user not found",
+			input: input{sign: "sign", body: body},
+			output: output{code: http.StatusUnauthorized, body: `"unauthorized"`},
+			setUp: func(m mocksPassword) {
+				m.rd.On("Get", 1).Return(user.User{}, storage.ErrStorageNotFound)
+			},
+		},
+		{
+			title: "storage internal error",
+			input: input{sign: "sign", body: body},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksPassword) {
+				m.rd.On("Get", 1).Return(user.User{}, errors.New("connection refused"))
+			},
+		},
+		// -> credential
+		{
+			title: "wrong current password",
+			input: input{sign: "sign", body: body},
+			output: output{code: http.StatusForbidden, body: `"invalid current password"`},
+			setUp: func(m mocksPassword) {
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(credential.ErrCredentialPasswordInvalid)
+			},
+		},
+		{
+			title: "user inactive",
+			input: input{sign: "sign", body: body},
+			output: output{code: http.StatusForbidden, body: `"user not active, verify your email first"`},
+			setUp: func(m mocksPassword) {
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(credential.ErrCredentialUserInactive)
+			},
+		},
+		{
+			title: "user locked",
+			input: input{sign: "sign", body: body},
+			output: output{code: http.StatusTooManyRequests, body: `"too many failed attempts, try again later"`},
+			setUp: func(m mocksPassword) {
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(credential.ErrCredentialLocked)
+			},
+		},
+		{
+			title: "credential internal error",
+			input: input{sign: "sign", body: body},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksPassword) {
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(credential.ErrCredentialInternal)
+			},
+		},
+		// -> update
+		{
+			title: "invalid new password",
+			input: input{sign: "sign", body: `{"current_password": "password", "new_password": "weak"}`},
+			output: output{code: http.StatusUnprocessableEntity, body: `"invalid password"`},
+			setUp: func(m mocksPassword) {
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(nil)
+				m.wr.On("UpdatePassword", 1, "weak").Return(storage.ErrStorageInvalid)
+			},
+		},
+		{
+			title: "update internal error",
+			input: input{sign: "sign", body: body},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksPassword) {
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(nil)
+				m.wr.On("UpdatePassword", 1, "N3w-Password!").Return(errors.New("connection refused"))
+			},
+		},
+		// -> sessions
+		{
+			title: "revoke sessions internal error",
+			input: input{sign: "sign", body: `{"current_password": "password", "new_password": "N3w-Password!", "revoke_other_sessions": true}`},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksPassword) {
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.cr.On("VerifyByUsername", "johndoe", "password").Return(nil)
+				m.wr.On("UpdatePassword", 1, "N3w-Password!").Return(nil)
+				m.ss.On("RevokeOtherSessions", "1", "token_id").Return(sessionauth.ErrSessionAuthManagerInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			m := mocksPassword{
+				cr: credential.NewCredentialMock(),
+				rd: storage.NewStorageReadMock(),
+				wr: storage.NewStorageWriteMock(),
+				ss: sessionauth.NewSessionAuthMock(),
+			}
+			c.setUp(m)
+			hd := handler.NewHandlersPassword(nil, m.cr, m.rd, m.wr, m.ss, nil)
+
+			// act
+			req := httptest.NewRequest(http.MethodPut, "/password", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+c.input.sign)
+			res := httptest.NewRecorder()
+			authenticated(hd.Change()).ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			m.cr.AssertExpectations(t)
+			m.rd.AssertExpectations(t)
+			m.wr.AssertExpectations(t)
+			m.ss.AssertExpectations(t)
+		})
+	}
+}
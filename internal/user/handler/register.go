@@ -6,6 +6,8 @@ import (
 
 	"github.com/LNMMusic/msauth/internal/user"
 	"github.com/LNMMusic/msauth/internal/user/storage"
+	uservalidator "github.com/LNMMusic/msauth/internal/user/validator"
+	"github.com/LNMMusic/msauth/internal/user/verification"
 	"github.com/LNMMusic/msauth/pkg/web/request"
 	"github.com/LNMMusic/msauth/pkg/web/response"
 	"github.com/LNMMusic/msauth/pkg/web/validator"
@@ -13,16 +15,19 @@ import (
 )
 
 // NewHandlersRegister returns a new HandlersRegister struct
-func NewHandlersRegister(stWrite storage.StorageWrite) *HandlersRegister {
+func NewHandlersRegister(stWrite storage.StorageWrite, vr verification.Verification) *HandlersRegister {
 	return &HandlersRegister{
-		stWrite: stWrite,
+		stWrite: storage.StorageWriteWithContext(stWrite),
+		vr: vr,
 	}
 }
 
 // HandlersRegister is the struct that contains the dependencies for the register handlers
 type HandlersRegister struct {
 	// stWrite is the write interface for the user store
-	stWrite storage.StorageWrite
+	stWrite storage.StorageWriteContext
+	// vr is the verification interface to verify the email of the users
+	vr verification.Verification
 }
 
 // SignUp is the handler for the sign up route
@@ -59,26 +64,84 @@ func (h *HandlersRegister) SignUp() http.HandlerFunc {
 			Email: userSignUp.Email,
 		}
 		// - store
-		err = h.stWrite.Create(&u)
+		err = h.stWrite.CreateContext(r.Context(), &u)
 		if err != nil {
 			switch {
 			case errors.Is(err, storage.ErrStorageExists):
 				response.JSON(w, http.StatusConflict, "user already exists")
 			case errors.Is(err, storage.ErrStorageInvalid):
-				response.JSON(w, http.StatusUnprocessableEntity, "invalid user")
+				respondInvalidUser(w, err)
 			default:
 				response.JSON(w, http.StatusInternalServerError, "internal server error")
 			}
 			return
 		}
 
+		// - send verification
+		message := "user created, check your email to verify your account"
+		err = h.vr.Send(u)
+		if err != nil {
+			message = "user created, verification email could not be sent"
+		}
+
 		// response
 		response.JSON(w, http.StatusCreated, map[string]any{
-			"message": "user created",
+			"message": message,
 			"data": map[string]any{
 				"username": u.Username,
 				"email": u.Email,
 			},
 		})
 	}
-}
\ No newline at end of file
+}
+
+// Verify is the handler for the email verification route
+// - the verification token is expected in the "token" query param
+func (h *HandlersRegister) Verify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			response.JSON(w, http.StatusBadRequest, "missing token")
+			return
+		}
+
+		// process
+		err := h.vr.Confirm(token)
+		if err != nil {
+			switch {
+			case errors.Is(err, verification.ErrVerificationTokenInvalid):
+				response.JSON(w, http.StatusBadRequest, "invalid or expired token")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "user verified",
+		})
+	}
+}
+
+// respondInvalidUser responds the violations of an invalid user, rendered per field
+// - a weak password is explained so the user can pick a stronger one
+func respondInvalidUser(w http.ResponseWriter, err error) {
+	data := map[string]any{
+		"errors": uservalidator.Fields(err),
+	}
+	var weak *uservalidator.WeakPasswordError
+	if errors.As(err, &weak) {
+		data["password_strength"] = map[string]any{
+			"score": weak.Score,
+			"min_score": weak.MinScore,
+			"warning": weak.Warning,
+			"suggestions": weak.Suggestions,
+		}
+	}
+	response.JSON(w, http.StatusUnprocessableEntity, map[string]any{
+		"message": "invalid user",
+		"data": data,
+	})
+}
@@ -0,0 +1,81 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user/handler"
+	"github.com/LNMMusic/msauth/internal/user/verification"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests for HandlersRegister.Verify
+func TestHandlersRegister_Verify(t *testing.T) {
+	type input struct { query string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUpVerification func(mk *verification.VerificationMock)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - user verified",
+			input: input{query: "?token=token"},
+			output: output{code: http.StatusOK, body: `{"message": "user verified"}`},
+			setUpVerification: func(mk *verification.VerificationMock) {
+				mk.On("Confirm", "token").Return(nil)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "missing token",
+			input: input{query: ""},
+			output: output{code: http.StatusBadRequest, body: `"missing token"`},
+			setUpVerification: func(mk *verification.VerificationMock) {},
+		},
+		{
+			title: "invalid or expired token",
+			input: input{query: "?token=token"},
+			output: output{code: http.StatusBadRequest, body: `"invalid or expired token"`},
+			setUpVerification: func(mk *verification.VerificationMock) {
+				mk.On("Confirm", "token").Return(verification.ErrVerificationTokenInvalid)
+			},
+		},
+		{
+			title: "verification internal error",
+			input: input{query: "?token=token"},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUpVerification: func(mk *verification.VerificationMock) {
+				mk.On("Confirm", "token").Return(verification.ErrVerificationInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			vr := verification.NewVerificationMock()
+			c.setUpVerification(vr)
+			hd := handler.NewHandlersRegister(nil, vr)
+
+			// act
+			req := httptest.NewRequest(http.MethodGet, "/verify"+c.input.query, nil)
+			res := httptest.NewRecorder()
+			hd.Verify().ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			vr.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/LNMMusic/msauth/internal/jwtauth"
+	"github.com/LNMMusic/msauth/internal/user/recovery"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/twofactor"
+	"github.com/LNMMusic/msauth/pkg/web/request"
+	"github.com/LNMMusic/msauth/pkg/web/response"
+	"github.com/LNMMusic/optional"
+)
+
+// NewHandlersTwoFactor returns a new HandlersTwoFactor struct
+func NewHandlersTwoFactor(tf twofactor.TwoFactor, rc recovery.RecoveryCodes, stRead storage.StorageRead) *HandlersTwoFactor {
+	return &HandlersTwoFactor{
+		tf: tf,
+		rc: rc,
+		stRead: storage.StorageReadWithContext(stRead),
+	}
+}
+
+// HandlersTwoFactor is the struct that contains the dependencies for the two factor handlers
+// - all the handlers require the jwtauth.Authenticate middleware
+type HandlersTwoFactor struct {
+	// tf is the two factor interface to enroll the users
+	tf twofactor.TwoFactor
+	// rc is the recovery codes interface to generate the fallback codes
+	rc recovery.RecoveryCodes
+	// stRead is the read interface for the user store
+	stRead storage.StorageReadContext
+}
+
+// Enroll is the handler for the two factor enroll route
+// - it responds the secret and the otpauth:// uri to encode as QR code
+func (h *HandlersTwoFactor) Enroll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := userIdFromContext(r)
+		if !ok {
+			response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		// process
+		// - account name shown by the authenticator app
+		u, err := h.stRead.GetContext(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrStorageNotFound):
+				response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+		account, _ := u.Username.Unwrap()
+		if u.Email.IsSome() {
+			account, _ = u.Email.Unwrap()
+		}
+		// - enroll
+		e, err := h.tf.Enroll(id, account)
+		if err != nil {
+			switch {
+			case errors.Is(err, twofactor.ErrTwoFactorAlreadyEnabled):
+				response.JSON(w, http.StatusConflict, "two factor already enabled")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "two factor pending, confirm it with a code",
+			"data": map[string]any{
+				"secret": e.Secret,
+				"uri": e.URI,
+				"qr_payload": e.URI,
+			},
+		})
+	}
+}
+
+// TwoFactorConfirm is the request body for the two factor confirm route
+type TwoFactorConfirm struct {
+	// Code is the code of the authenticator app
+	Code 	optional.Option[string] `json:"code"`
+}
+
+// Confirm is the handler for the two factor confirm route
+// - it responds the first batch of recovery codes, they are only shown once
+func (h *HandlersTwoFactor) Confirm() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := userIdFromContext(r)
+		if !ok {
+			response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		var body TwoFactorConfirm
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if !body.Code.IsSome() {
+			response.JSON(w, http.StatusBadRequest, "missing required fields")
+			return
+		}
+		code, _ := body.Code.Unwrap()
+
+		// process
+		// - recovery codes, generated before two factor is enabled, so an enabled user always has them
+		// - the codes of a user with two factor enabled are not replaced
+		enabled, err := h.tf.Enabled(id)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		if enabled {
+			response.JSON(w, http.StatusConflict, "two factor already enabled")
+			return
+		}
+		codes, err := h.rc.Generate(id)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		// - confirm
+		err = h.tf.Confirm(id, code)
+		if err != nil {
+			switch {
+			case errors.Is(err, twofactor.ErrTwoFactorCodeInvalid):
+				response.JSON(w, http.StatusUnprocessableEntity, "invalid code")
+			case errors.Is(err, twofactor.ErrTwoFactorNotEnrolled):
+				response.JSON(w, http.StatusConflict, "two factor not enrolled")
+			case errors.Is(err, twofactor.ErrTwoFactorAlreadyEnabled):
+				response.JSON(w, http.StatusConflict, "two factor already enabled")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "two factor enabled, store the recovery codes in a safe place",
+			"data": map[string]any{
+				"recovery_codes": codes,
+			},
+		})
+	}
+}
+
+// RegenerateRecoveryCodes is the handler for the regenerate recovery codes route
+// - the previous codes are invalidated
+func (h *HandlersTwoFactor) RegenerateRecoveryCodes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := userIdFromContext(r)
+		if !ok {
+			response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		// process
+		enabled, err := h.tf.Enabled(id)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		if !enabled {
+			response.JSON(w, http.StatusConflict, "two factor not enabled")
+			return
+		}
+		codes, err := h.rc.Generate(id)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "recovery codes regenerated, the previous ones are no longer valid",
+			"data": map[string]any{
+				"recovery_codes": codes,
+			},
+		})
+	}
+}
+
+// RecoveryCodesRemaining is the handler for the recovery codes status route
+func (h *HandlersTwoFactor) RecoveryCodesRemaining() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, ok := userIdFromContext(r)
+		if !ok {
+			response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		// process
+		n, err := h.rc.Remaining(id)
+		if err != nil {
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{
+			"message": "recovery codes",
+			"data": map[string]any{
+				"remaining": n,
+			},
+		})
+	}
+}
+
+// userIdFromContext returns the user id of the token set by the jwtauth.Authenticate middleware
+func userIdFromContext(r *http.Request) (id int, ok bool) {
+	token, ok := jwtauth.TokenFromContext(r.Context())
+	if !ok {
+		return
+	}
+	userId, _ := token.Claims["user_id"].(string)
+	id, err := strconv.Atoi(userId)
+	ok = err == nil
+	return
+}
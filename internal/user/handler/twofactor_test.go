@@ -0,0 +1,572 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/jwtauth"
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/handler"
+	"github.com/LNMMusic/msauth/internal/user/linktoken"
+	"github.com/LNMMusic/msauth/internal/user/recovery"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/twofactor"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// authenticated returns a handler behind the jwtauth.Authenticate middleware
+// - the "sign" bearer token is the one of the user 1, the "sign-no-user" one has no valid user id
+func authenticated(h http.Handler) http.Handler {
+	jw := jwtauth.NewJWTAuthMock()
+	jw.On("ValidateSign", "sign").Return(&jwtauth.Token{ID: "token_id", Claims: map[string]any{"user_id": "1"}}, nil)
+	jw.On("ValidateSign", "sign-no-user").Return(&jwtauth.Token{ID: "token_id", Claims: map[string]any{}}, nil)
+	return jwtauth.Authenticate(jw)(h)
+}
+
+// mocksTwoFactor are the dependencies of the two factor handlers
+type mocksTwoFactor struct {
+	tf *twofactor.TwoFactorMock
+	rc *recovery.RecoveryCodesMock
+	rd *storage.StorageReadMock
+}
+
+// Tests for HandlersTwoFactor.Enroll
+func TestHandlersTwoFactor_Enroll(t *testing.T) {
+	type input struct { sign string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUp func(m mocksTwoFactor)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - account is the email",
+			input: input{sign: "sign"},
+			output: output{
+				code: http.StatusOK,
+				body: `{"message": "two factor pending, confirm it with a code", "data": {"secret": "SECRET", "uri": "otpauth://totp/msauth:johndoe@gmail.com", "qr_payload": "otpauth://totp/msauth:johndoe@gmail.com"}}`,
+			},
+			setUp: func(m mocksTwoFactor) {
+				m.rd.On("Get", 1).Return(user.User{Id: 1, Username: optional.Some("johndoe"), Email: optional.Some("johndoe@gmail.com")}, nil)
+				m.tf.On("Enroll", 1, "johndoe@gmail.com").Return(twofactor.Enrollment{Secret: "SECRET", URI: "otpauth://totp/msauth:johndoe@gmail.com"}, nil)
+			},
+		},
+		{
+			title: "valid case - account is the username without email",
+			input: input{sign: "sign"},
+			output: output{
+				code: http.StatusOK,
+				body: `{"message": "two factor pending, confirm it with a code", "data": {"secret": "SECRET", "uri": "otpauth://totp/msauth:johndoe", "qr_payload": "otpauth://totp/msauth:johndoe"}}`,
+			},
+			setUp: func(m mocksTwoFactor) {
+				m.rd.On("Get", 1).Return(user.User{Id: 1, Username: optional.Some("johndoe")}, nil)
+				m.tf.On("Enroll", 1, "johndoe").Return(twofactor.Enrollment{Secret: "SECRET", URI: "otpauth://totp/msauth:johndoe"}, nil)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "token without user id",
+			input: input{sign: "sign-no-user"},
+			output: output{code: http.StatusUnauthorized, body: `"unauthorized"`},
+			setUp: func(m mocksTwoFactor) {},
+		},
+		{
+			title: "user not found",
+			input: input{sign: "sign"},
+			output: output{code: http.StatusUnauthorized, body: `"unauthorized"`},
+			setUp: func(m mocksTwoFactor) {
+				m.rd.On("Get", 1).Return(user.User{}, storage.ErrStorageNotFound)
+			},
+		},
+		{
+			title: "storage internal error",
+			input: input{sign: "sign"},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksTwoFactor) {
+				m.rd.On("Get", 1).Return(user.User{}, errors.New("connection refused"))
+			},
+		},
+		{
+			title: "two factor already enabled",
+			input: input{sign: "sign"},
+			output: output{code: http.StatusConflict, body: `"two factor already enabled"`},
+			setUp: func(m mocksTwoFactor) {
+				m.rd.On("Get", 1).Return(user.User{Id: 1, Username: optional.Some("johndoe")}, nil)
+				m.tf.On("Enroll", 1, "johndoe").Return(twofactor.Enrollment{}, twofactor.ErrTwoFactorAlreadyEnabled)
+			},
+		},
+		{
+			title: "two factor internal error",
+			input: input{sign: "sign"},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksTwoFactor) {
+				m.rd.On("Get", 1).Return(user.User{Id: 1, Username: optional.Some("johndoe")}, nil)
+				m.tf.On("Enroll", 1, "johndoe").Return(twofactor.Enrollment{}, twofactor.ErrTwoFactorInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			m := mocksTwoFactor{tf: twofactor.NewTwoFactorMock(), rc: recovery.NewRecoveryCodesMock(), rd: storage.NewStorageReadMock()}
+			c.setUp(m)
+			hd := handler.NewHandlersTwoFactor(m.tf, m.rc, m.rd)
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/two-factor/enroll", nil)
+			req.Header.Set("Authorization", "Bearer "+c.input.sign)
+			res := httptest.NewRecorder()
+			authenticated(hd.Enroll()).ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			m.tf.AssertExpectations(t)
+			m.rd.AssertExpectations(t)
+		})
+	}
+}
+
+// Tests for HandlersTwoFactor.Confirm
+func TestHandlersTwoFactor_Confirm(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUp func(m mocksTwoFactor)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - two factor enabled with its recovery codes",
+			input: input{body: `{"code": "123456"}`},
+			output: output{
+				code: http.StatusOK,
+				body: `{"message": "two factor enabled, store the recovery codes in a safe place", "data": {"recovery_codes": ["code-1", "code-2"]}}`,
+			},
+			setUp: func(m mocksTwoFactor) {
+				m.tf.On("Enabled", 1).Return(false, nil)
+				m.rc.On("Generate", 1).Return([]string{"code-1", "code-2"}, nil)
+				m.tf.On("Confirm", 1, "123456").Return(nil)
+			},
+		},
+
+		// invalid cases
+		// -> request
+		{
+			title: "invalid json",
+			input: input{body: `{"code": }`},
+			output: output{code: http.StatusBadRequest, body: `"invalid json"`},
+			setUp: func(m mocksTwoFactor) {},
+		},
+		{
+			title: "missing code",
+			input: input{body: `{}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUp: func(m mocksTwoFactor) {},
+		},
+		// -> two factor
+		{
+			title: "two factor already enabled, its recovery codes are not replaced",
+			input: input{body: `{"code": "123456"}`},
+			output: output{code: http.StatusConflict, body: `"two factor already enabled"`},
+			setUp: func(m mocksTwoFactor) {
+				m.tf.On("Enabled", 1).Return(true, nil)
+			},
+		},
+		{
+			title: "invalid code",
+			input: input{body: `{"code": "123456"}`},
+			output: output{code: http.StatusUnprocessableEntity, body: `"invalid code"`},
+			setUp: func(m mocksTwoFactor) {
+				m.tf.On("Enabled", 1).Return(false, nil)
+				m.rc.On("Generate", 1).Return([]string{"code-1", "code-2"}, nil)
+				m.tf.On("Confirm", 1, "123456").Return(twofactor.ErrTwoFactorCodeInvalid)
+			},
+		},
+		{
+			title: "not enrolled",
+			input: input{body: `{"code": "123456"}`},
+			output: output{code: http.StatusConflict, body: `"two factor not enrolled"`},
+			setUp: func(m mocksTwoFactor) {
+				m.tf.On("Enabled", 1).Return(false, nil)
+				m.rc.On("Generate", 1).Return([]string{"code-1", "code-2"}, nil)
+				m.tf.On("Confirm", 1, "123456").Return(twofactor.ErrTwoFactorNotEnrolled)
+			},
+		},
+		{
+			title: "two factor internal error",
+			input: input{body: `{"code": "123456"}`},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksTwoFactor) {
+				m.tf.On("Enabled", 1).Return(false, twofactor.ErrTwoFactorInternal)
+			},
+		},
+		// -> recovery codes
+		{
+			title: "recovery codes internal error, two factor is not enabled",
+			input: input{body: `{"code": "123456"}`},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksTwoFactor) {
+				m.tf.On("Enabled", 1).Return(false, nil)
+				m.rc.On("Generate", 1).Return([]string(nil), recovery.ErrRecoveryInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			m := mocksTwoFactor{tf: twofactor.NewTwoFactorMock(), rc: recovery.NewRecoveryCodesMock(), rd: storage.NewStorageReadMock()}
+			c.setUp(m)
+			hd := handler.NewHandlersTwoFactor(m.tf, m.rc, m.rd)
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/two-factor/confirm", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer sign")
+			res := httptest.NewRecorder()
+			authenticated(hd.Confirm()).ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			m.tf.AssertExpectations(t)
+			m.rc.AssertExpectations(t)
+		})
+	}
+}
+
+// Tests for HandlersTwoFactor.RegenerateRecoveryCodes
+func TestHandlersTwoFactor_RegenerateRecoveryCodes(t *testing.T) {
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		output output
+		// set-up
+		setUp func(m mocksTwoFactor)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - codes regenerated",
+			output: output{
+				code: http.StatusOK,
+				body: `{"message": "recovery codes regenerated, the previous ones are no longer valid", "data": {"recovery_codes": ["code-1", "code-2"]}}`,
+			},
+			setUp: func(m mocksTwoFactor) {
+				m.tf.On("Enabled", 1).Return(true, nil)
+				m.rc.On("Generate", 1).Return([]string{"code-1", "code-2"}, nil)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "two factor not enabled",
+			output: output{code: http.StatusConflict, body: `"two factor not enabled"`},
+			setUp: func(m mocksTwoFactor) {
+				m.tf.On("Enabled", 1).Return(false, nil)
+			},
+		},
+		{
+			title: "two factor internal error",
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksTwoFactor) {
+				m.tf.On("Enabled", 1).Return(false, twofactor.ErrTwoFactorInternal)
+			},
+		},
+		{
+			title: "recovery codes internal error",
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksTwoFactor) {
+				m.tf.On("Enabled", 1).Return(true, nil)
+				m.rc.On("Generate", 1).Return([]string(nil), recovery.ErrRecoveryInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			m := mocksTwoFactor{tf: twofactor.NewTwoFactorMock(), rc: recovery.NewRecoveryCodesMock(), rd: storage.NewStorageReadMock()}
+			c.setUp(m)
+			hd := handler.NewHandlersTwoFactor(m.tf, m.rc, m.rd)
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/two-factor/recovery-codes", nil)
+			req.Header.Set("Authorization", "Bearer sign")
+			res := httptest.NewRecorder()
+			authenticated(hd.RegenerateRecoveryCodes()).ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			m.tf.AssertExpectations(t)
+			m.rc.AssertExpectations(t)
+		})
+	}
+}
+
+// Tests for HandlersTwoFactor.RecoveryCodesRemaining
+func TestHandlersTwoFactor_RecoveryCodesRemaining(t *testing.T) {
+	type input struct { sign string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUp func(m mocksTwoFactor)
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - remaining codes",
+			input: input{sign: "sign"},
+			output: output{code: http.StatusOK, body: `{"message": "recovery codes", "data": {"remaining": 8}}`},
+			setUp: func(m mocksTwoFactor) {
+				m.rc.On("Remaining", 1).Return(8, nil)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "token without user id",
+			input: input{sign: "sign-no-user"},
+			output: output{code: http.StatusUnauthorized, body: `"unauthorized"`},
+			setUp: func(m mocksTwoFactor) {},
+		},
+		{
+			title: "recovery codes internal error",
+			input: input{sign: "sign"},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksTwoFactor) {
+				m.rc.On("Remaining", 1).Return(0, recovery.ErrRecoveryInternal)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			m := mocksTwoFactor{tf: twofactor.NewTwoFactorMock(), rc: recovery.NewRecoveryCodesMock(), rd: storage.NewStorageReadMock()}
+			c.setUp(m)
+			hd := handler.NewHandlersTwoFactor(m.tf, m.rc, m.rd)
+
+			// act
+			req := httptest.NewRequest(http.MethodGet, "/two-factor/recovery-codes", nil)
+			req.Header.Set("Authorization", "Bearer "+c.input.sign)
+			res := httptest.NewRecorder()
+			authenticated(hd.RecoveryCodesRemaining()).ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			m.rc.AssertExpectations(t)
+		})
+	}
+}
+
+// Tests for HandlersLogin.SignInTwoFactor
+func TestHandlersLogin_SignInTwoFactor(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUp func(m mocksLogin)
+	}
+
+	johndoe := user.User{Id: 1, Username: optional.Some("johndoe"), IsActive: optional.Some(true)}
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - code of the authenticator app",
+			input: input{body: `{"challenge": "challenge", "code": "123456"}`},
+			output: output{code: http.StatusOK, body: `{"message": "user signed in", "data": {"token": "sign"}}`},
+			setUp: func(m mocksLogin) {
+				m.ch.On("Consume", "challenge").Return(1, nil)
+				m.tf.On("Verify", 1, "123456").Return(nil)
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.jw.On("GenerateSign", mock.Anything).Return("sign", nil)
+			},
+		},
+
+		{
+			title: "valid case - recovery code",
+			input: input{body: `{"challenge": "challenge", "recovery_code": "recovery-code"}`},
+			output: output{code: http.StatusOK, body: `{"message": "user signed in", "data": {"token": "sign"}}`},
+			setUp: func(m mocksLogin) {
+				m.ch.On("Consume", "challenge").Return(1, nil)
+				m.rc.On("Use", 1, "recovery-code").Return(nil)
+				m.rd.On("Get", 1).Return(johndoe, nil)
+				m.jw.On("GenerateSign", mock.Anything).Return("sign", nil)
+			},
+		},
+
+		// invalid cases
+		// -> request
+		{
+			title: "invalid json",
+			input: input{body: `{"challenge": }`},
+			output: output{code: http.StatusBadRequest, body: `"invalid json"`},
+			setUp: func(m mocksLogin) {},
+		},
+		{
+			title: "missing challenge",
+			input: input{body: `{"code": "123456"}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUp: func(m mocksLogin) {},
+		},
+		{
+			title: "missing code",
+			input: input{body: `{"challenge": "challenge"}`},
+			output: output{code: http.StatusBadRequest, body: `"missing required fields"`},
+			setUp: func(m mocksLogin) {},
+		},
+		// -> challenge
+		{
+			title: "challenge already used",
+			input: input{body: `{"challenge": "challenge", "code": "123456"}`},
+			output: output{code: http.StatusUnauthorized, body: `"invalid or expired challenge"`},
+			setUp: func(m mocksLogin) {
+				m.ch.On("Consume", "challenge").Return(0, linktoken.ErrLinkTokenUsed)
+			},
+		},
+		{
+			title: "challenge internal error",
+			input: input{body: `{"challenge": "challenge", "code": "123456"}`},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksLogin) {
+				m.ch.On("Consume", "challenge").Return(0, linktoken.ErrLinkTokenInternal)
+			},
+		},
+		// -> code
+		{
+			title: "invalid code",
+			input: input{body: `{"challenge": "challenge", "code": "123456"}`},
+			output: output{code: http.StatusUnauthorized, body: `"invalid code"`},
+			setUp: func(m mocksLogin) {
+				m.ch.On("Consume", "challenge").Return(1, nil)
+				m.tf.On("Verify", 1, "123456").Return(twofactor.ErrTwoFactorCodeInvalid)
+			},
+		},
+		{
+			title: "two factor disabled after the challenge",
+			input: input{body: `{"challenge": "challenge", "code": "123456"}`},
+			output: output{code: http.StatusUnauthorized, body: `"invalid code"`},
+			setUp: func(m mocksLogin) {
+				m.ch.On("Consume", "challenge").Return(1, nil)
+				m.tf.On("Verify", 1, "123456").Return(twofactor.ErrTwoFactorNotEnrolled)
+			},
+		},
+		{
+			title: "two factor internal error",
+			input: input{body: `{"challenge": "challenge", "code": "123456"}`},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksLogin) {
+				m.ch.On("Consume", "challenge").Return(1, nil)
+				m.tf.On("Verify", 1, "123456").Return(twofactor.ErrTwoFactorInternal)
+			},
+		},
+		{
+			title: "invalid recovery code",
+			input: input{body: `{"challenge": "challenge", "recovery_code": "recovery-code"}`},
+			output: output{code: http.StatusUnauthorized, body: `"invalid code"`},
+			setUp: func(m mocksLogin) {
+				m.ch.On("Consume", "challenge").Return(1, nil)
+				m.rc.On("Use", 1, "recovery-code").Return(recovery.ErrRecoveryCodeInvalid)
+			},
+		},
+		{
+			title: "recovery codes internal error",
+			input: input{body: `{"challenge": "challenge", "recovery_code": "recovery-code"}`},
+			output: output{code: http.StatusInternalServerError, body: `"internal server error"`},
+			setUp: func(m mocksLogin) {
+				m.ch.On("Consume", "challenge").Return(1, nil)
+				m.rc.On("Use", 1, "recovery-code").Return(recovery.ErrRecoveryInternal)
+			},
+		},
+		// -> user
+		{
+			title: "user deleted after the challenge",
+			input: input{body: `{"challenge": "challenge", "code": "123456"}`},
+			output: output{code: http.StatusUnauthorized, body: `"invalid or expired challenge"`},
+			setUp: func(m mocksLogin) {
+				m.ch.On("Consume", "challenge").Return(1, nil)
+				m.tf.On("Verify", 1, "123456").Return(nil)
+				m.rd.On("Get", 1).Return(user.User{}, storage.ErrStorageNotFound)
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			m := newMocksLogin()
+			c.setUp(m)
+			hd := handler.NewHandlersLogin(m.cr, m.rd, m.jw, m.tf, m.rc, m.ch, &handler.ConfigLogin{})
+
+			// act
+			req := httptest.NewRequest(http.MethodPost, "/sign-in/two-factor", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			res := httptest.NewRecorder()
+			hd.SignInTwoFactor().ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			m.assertExpectations(t)
+		})
+	}
+
+	t.Run("success - first sign in with a fresh session store", func(t *testing.T) {
+		// arrange
+		m := newMocksLogin()
+		m.ch.On("Consume", "challenge").Return(1, nil)
+		m.tf.On("Verify", 1, "123456").Return(nil)
+		m.rd.On("Get", 1).Return(johndoe, nil)
+		jw, st := newJWTAuthSessions()
+		hd := handler.NewHandlersLogin(m.cr, m.rd, jw, m.tf, m.rc, m.ch, &handler.ConfigLogin{})
+
+		// act
+		req := httptest.NewRequest(http.MethodPost, "/sign-in/two-factor", strings.NewReader(`{"challenge": "challenge", "code": "123456"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		hd.SignInTwoFactor().ServeHTTP(res, req)
+
+		// assert
+		requireSignedIn(t, res, st, "1")
+		m.assertExpectations(t)
+	})
+}
@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/credential"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	uservalidator "github.com/LNMMusic/msauth/internal/user/validator"
+	"github.com/LNMMusic/msauth/internal/user/verification"
+	"github.com/LNMMusic/msauth/pkg/web/request"
+	"github.com/LNMMusic/msauth/pkg/web/response"
+	"github.com/LNMMusic/optional"
+)
+
+// NewHandlersUser returns a new HandlersUser struct
+// - nm must be the same normalizer used by the validator, in case of nil NormalizerDefault without provider rules is used
+func NewHandlersUser(cr credential.Credential, stRead storage.StorageRead, stWrite storage.StorageWrite, vr verification.Verification, nm uservalidator.Normalizer) *HandlersUser {
+	// default values
+	if nm == nil {
+		nm = uservalidator.NewNormalizerDefault(nil)
+	}
+
+	return &HandlersUser{
+		cr: credential.WithContext(cr),
+		stRead: storage.StorageReadWithContext(stRead),
+		stWrite: storage.StorageWriteWithContext(stWrite),
+		vr: vr,
+		nm: nm,
+	}
+}
+
+// HandlersUser is the struct that contains the dependencies for the handlers of the signed in user
+type HandlersUser struct {
+	// cr is the credential interface to verify the current password
+	cr credential.CredentialContext
+	// stRead is the read interface for the user store
+	stRead storage.StorageReadContext
+	// stWrite is the write interface for the user store
+	// - it is expected to validate and encrypt the password (e.g. storage.StorageWriteValidation)
+	stWrite storage.StorageWriteContext
+	// vr is the verification interface to verify a new email
+	vr verification.Verification
+	// nm is the normalizer interface to compare the emails by their canonical form
+	nm uservalidator.Normalizer
+}
+
+// Get is the handler for the get user route (GET /users/me)
+// - it requires the jwtauth.Authenticate middleware
+// - the version of the user is responded, to be sent on the next update
+func (h *HandlersUser) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - token
+		id, ok := userIdFromContext(r)
+		if !ok {
+			response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		// process
+		u, err := h.stRead.GetContext(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrStorageNotFound):
+				response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			default:
+				response.JSON(w, http.StatusInternalServerError, "internal server error")
+			}
+			return
+		}
+
+		// response
+		respondUser(w, "user found", u)
+	}
+}
+
+// UserUpdate is the request body for the update user route
+type UserUpdate struct {
+	// Username is the new username of the user
+	Username 		optional.Option[string] `json:"username"`
+	// Email is the new email of the user
+	Email 			optional.Option[string] `json:"email"`
+	// Password is the new password of the user
+	Password 		optional.Option[string] `json:"password"`
+	// CurrentPassword is the current password of the user
+	CurrentPassword optional.Option[string] `json:"current_password"`
+	// Version is the version of the user the changes were made on
+	Version 		optional.Option[int] 	`json:"version"`
+}
+
+// Update is the handler for the update user route (PUT /users/me)
+// - it requires the jwtauth.Authenticate middleware
+// - the user is replaced as a whole, so the current password is required
+// - a new email deactivates the user until it is verified
+// - in case the user was modified since the version was read, it responds a conflict and nothing is applied
+func (h *HandlersUser) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - token
+		id, ok := userIdFromContext(r)
+		if !ok {
+			response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		// - body
+		var body UserUpdate
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if !body.Username.IsSome() || !body.Email.IsSome() || !body.Password.IsSome() || !body.CurrentPassword.IsSome() || !body.Version.IsSome() {
+			response.JSON(w, http.StatusBadRequest, "missing required fields")
+			return
+		}
+		currentPassword, _ := body.CurrentPassword.Unwrap()
+		version, _ := body.Version.Unwrap()
+
+		// process
+		// - verify current password
+		current, ok := h.verify(w, r, id, currentPassword)
+		if !ok {
+			return
+		}
+		// - update
+		u := user.User{
+			Id: id,
+			Username: body.Username,
+			Email: body.Email,
+			Password: body.Password,
+			IsActive: current.IsActive,
+			Version: version,
+		}
+		emailChanged := h.emailChanged(body.Email, current.Email)
+		if emailChanged {
+			u.IsActive = optional.Some(false)
+		}
+		err = h.stWrite.UpdateContext(r.Context(), &u)
+		if err != nil {
+			respondUpdateError(w, err)
+			return
+		}
+
+		// response
+		respondUser(w, h.verifyEmail(u, emailChanged), u)
+	}
+}
+
+// UserPatch is the request body for the patch user route
+// - fields that are missing or null are left as they are
+type UserPatch struct {
+	// Username is the new username of the user
+	Username 		optional.Option[string] `json:"username"`
+	// Email is the new email of the user
+	Email 			optional.Option[string] `json:"email"`
+	// Password is the new password of the user
+	Password 		optional.Option[string] `json:"password"`
+	// CurrentPassword is the current password of the user
+	// - required to change the email or the password
+	CurrentPassword optional.Option[string] `json:"current_password"`
+	// Version is the version of the user the changes were made on
+	Version 		optional.Option[int] 	`json:"version"`
+}
+
+// Patch is the handler for the patch user route (PATCH /users/me)
+// - it requires the jwtauth.Authenticate middleware
+// - only the fields in the body are validated and applied, the password is encrypted only if it is changed
+// - a new email deactivates the user until it is verified
+// - in case the user was modified since the version was read, it responds a conflict and nothing is applied
+func (h *HandlersUser) Patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		// - token
+		id, ok := userIdFromContext(r)
+		if !ok {
+			response.JSON(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		// - body
+		var body UserPatch
+		err := request.JSON(r, &body)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		if !body.Version.IsSome() {
+			response.JSON(w, http.StatusBadRequest, "missing required fields")
+			return
+		}
+		if !body.Username.IsSome() && !body.Email.IsSome() && !body.Password.IsSome() {
+			response.JSON(w, http.StatusBadRequest, "no fields to update")
+			return
+		}
+		version, _ := body.Version.Unwrap()
+
+		// process
+		// - verify current password, to change the credentials
+		var current user.User
+		if body.Email.IsSome() || body.Password.IsSome() {
+			if !body.CurrentPassword.IsSome() {
+				response.JSON(w, http.StatusBadRequest, "missing required fields")
+				return
+			}
+			currentPassword, _ := body.CurrentPassword.Unwrap()
+			current, ok = h.verify(w, r, id, currentPassword)
+			if !ok {
+				return
+			}
+		}
+		// - patch
+		u := user.User{
+			Id: id,
+			Username: body.Username,
+			Email: body.Email,
+			Password: body.Password,
+			Version: version,
+		}
+		emailChanged := body.Email.IsSome() && h.emailChanged(body.Email, current.Email)
+		if emailChanged {
+			u.IsActive = optional.Some(false)
+		}
+		err = h.stWrite.PatchContext(r.Context(), &u)
+		if err != nil {
+			respondUpdateError(w, err)
+			return
+		}
+
+		// response
+		respondUser(w, h.verifyEmail(u, emailChanged), u)
+	}
+}
+
+// verify gets the signed in user and verifies its current password
+// - in case of failure, the error is responded and ok is false
+func (h *HandlersUser) verify(w http.ResponseWriter, r *http.Request, id int, currentPassword string) (u user.User, ok bool) {
+	u, err := h.stRead.GetContext(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrStorageNotFound):
+			response.JSON(w, http.StatusUnauthorized, "unauthorized")
+		default:
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+	username, _ := u.Username.Unwrap()
+	err = h.cr.VerifyByUsernameContext(r.Context(), username, currentPassword)
+	if err != nil {
+		switch err = credential.Generic(err); {
+		case errors.Is(err, credential.ErrCredentialInvalid):
+			response.JSON(w, http.StatusForbidden, "invalid current password")
+		case errors.Is(err, credential.ErrCredentialUserInactive):
+			response.JSON(w, http.StatusForbidden, "user not active, verify your email first")
+		case errors.Is(err, credential.ErrCredentialLocked):
+			response.JSON(w, http.StatusTooManyRequests, "too many failed attempts, try again later")
+		default:
+			response.JSON(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	ok = true
+	return
+}
+
+// emailChanged returns whether a new email is another address than the current one
+// - the canonical forms are compared, so a change of case or of ignored characters is not a new email
+func (h *HandlersUser) emailChanged(email optional.Option[string], current optional.Option[string]) (changed bool) {
+	e, _ := email.Unwrap()
+	c, _ := current.Unwrap()
+	changed = h.nm.Email(e) != h.nm.Email(c)
+	return
+}
+
+// verifyEmail sends the verification of the new email of an updated user and returns the message of the response
+func (h *HandlersUser) verifyEmail(u user.User, emailChanged bool) (message string) {
+	if !emailChanged {
+		message = "user updated"
+		return
+	}
+
+	message = "user updated, check your email to verify your account"
+	err := h.vr.Send(u)
+	if err != nil {
+		message = "user updated, verification email could not be sent"
+	}
+	return
+}
+
+// respondUpdateError responds the error of an update of a user
+func respondUpdateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrStorageConflict):
+		response.JSON(w, http.StatusConflict, "user was modified, get it again and retry")
+	case errors.Is(err, storage.ErrStorageExists):
+		response.JSON(w, http.StatusConflict, "user already exists")
+	case errors.Is(err, storage.ErrStorageInvalid):
+		respondInvalidUser(w, err)
+	case errors.Is(err, storage.ErrStorageNotFound):
+		response.JSON(w, http.StatusUnauthorized, "unauthorized")
+	default:
+		response.JSON(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// respondUser responds a user, with the version to send on its next update
+func respondUser(w http.ResponseWriter, message string, u user.User) {
+	response.JSON(w, http.StatusOK, map[string]any{
+		"message": message,
+		"data": map[string]any{
+			"id": u.Id,
+			"username": u.Username,
+			"email": u.Email,
+			"version": u.Version,
+		},
+	})
+}
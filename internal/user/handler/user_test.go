@@ -0,0 +1,226 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/jwtauth"
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/credential"
+	"github.com/LNMMusic/msauth/internal/user/handler"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/validator"
+	"github.com/LNMMusic/msauth/internal/user/verification"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for HandlersUser.Update
+func TestHandlersUser_Update(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string; stored user.User }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUpVerification func(mk *verification.VerificationMock)
+	}
+
+	// stored user
+	stored := user.User{
+		Id: 1,
+		Username: optional.Some("johndoe"),
+		Password: optional.Some("current"),
+		Email: optional.Some("johndoe@gmail.com"),
+		IsActive: optional.Some(true),
+		Version: 2,
+	}
+
+	nm := validator.NewNormalizerDefault(&validator.ConfigNormalizer{Providers: []validator.EmailProvider{validator.EmailProviderGmail}})
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - same email",
+			input: input{body: `{"username": "johnny", "email": "johndoe@gmail.com", "password": "new", "current_password": "current", "version": 2}`},
+			output: output{
+				code: http.StatusOK,
+				body: `{"message": "user updated", "data": {"id": 1, "username": "johnny", "email": "johndoe@gmail.com", "version": 3}}`,
+				stored: user.User{Id: 1, Username: optional.Some("johnny"), Password: optional.Some("new"), Email: optional.Some("johndoe@gmail.com"), IsActive: optional.Some(true), Version: 3},
+			},
+			setUpVerification: func(mk *verification.VerificationMock) {},
+		},
+		{
+			title: "valid case - same email in another form is not verified again",
+			input: input{body: `{"username": "johndoe", "email": "John.Doe@Gmail.com", "password": "current", "current_password": "current", "version": 2}`},
+			output: output{
+				code: http.StatusOK,
+				body: `{"message": "user updated", "data": {"id": 1, "username": "johndoe", "email": "John.Doe@Gmail.com", "version": 3}}`,
+				stored: user.User{Id: 1, Username: optional.Some("johndoe"), Password: optional.Some("current"), Email: optional.Some("John.Doe@Gmail.com"), IsActive: optional.Some(true), Version: 3},
+			},
+			setUpVerification: func(mk *verification.VerificationMock) {},
+		},
+		{
+			title: "valid case - new email is verified",
+			input: input{body: `{"username": "johndoe", "email": "johnny@gmail.com", "password": "current", "current_password": "current", "version": 2}`},
+			output: output{
+				code: http.StatusOK,
+				body: `{"message": "user updated, check your email to verify your account", "data": {"id": 1, "username": "johndoe", "email": "johnny@gmail.com", "version": 3}}`,
+				stored: user.User{Id: 1, Username: optional.Some("johndoe"), Password: optional.Some("current"), Email: optional.Some("johnny@gmail.com"), IsActive: optional.Some(false), Version: 3},
+			},
+			setUpVerification: func(mk *verification.VerificationMock) {
+				mk.On("Send", mock.MatchedBy(func(u user.User) bool { email, _ := u.Email.Unwrap(); return email == "johnny@gmail.com" })).Return(nil)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "stale version",
+			input: input{body: `{"username": "johnny", "email": "johndoe@gmail.com", "password": "new", "current_password": "current", "version": 1}`},
+			output: output{
+				code: http.StatusConflict,
+				body: `"user was modified, get it again and retry"`,
+				stored: stored,
+			},
+			setUpVerification: func(mk *verification.VerificationMock) {},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			db := storage.NewStoreMap(stored)
+			cr := credential.NewCredentialMock()
+			cr.On("VerifyByUsername", "johndoe", "current").Return(nil)
+			vr := verification.NewVerificationMock()
+			c.setUpVerification(vr)
+			jw := jwtauth.NewJWTAuthMock()
+			jw.On("ValidateSign", "sign").Return(&jwtauth.Token{ID: "token_id", Claims: map[string]any{"user_id": "1"}}, nil)
+			hd := handler.NewHandlersUser(cr, storage.NewStorageReadMap(db), storage.NewStorageWriteMap(db), vr, nm)
+
+			// act
+			req := httptest.NewRequest(http.MethodPut, "/users/me", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer sign")
+			res := httptest.NewRecorder()
+			jwtauth.Authenticate(jw)(hd.Update()).ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			u, err := storage.NewStorageReadMap(db).Get(1)
+			require.NoError(t, err)
+			assert.Equal(t, c.output.stored, u)
+			vr.AssertExpectations(t)
+		})
+	}
+}
+
+// Tests for HandlersUser.Patch
+func TestHandlersUser_Patch(t *testing.T) {
+	type input struct { body string }
+	type output struct { code int; body string; stored user.User }
+	type testCase struct {
+		// base
+		title  string
+		input  input
+		output output
+		// set-up
+		setUpVerification func(mk *verification.VerificationMock)
+	}
+
+	// stored user
+	stored := user.User{
+		Id: 1,
+		Username: optional.Some("johndoe"),
+		Password: optional.Some("current"),
+		Email: optional.Some("johndoe@gmail.com"),
+		IsActive: optional.Some(true),
+		Version: 2,
+	}
+
+	nm := validator.NewNormalizerDefault(&validator.ConfigNormalizer{Providers: []validator.EmailProvider{validator.EmailProviderGmail}})
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - same email",
+			input: input{body: `{"email": "johndoe@gmail.com", "current_password": "current", "version": 2}`},
+			output: output{
+				code: http.StatusOK,
+				body: `{"message": "user updated", "data": {"id": 1, "username": "johndoe", "email": "johndoe@gmail.com", "version": 3}}`,
+				stored: user.User{Id: 1, Username: optional.Some("johndoe"), Password: optional.Some("current"), Email: optional.Some("johndoe@gmail.com"), IsActive: optional.Some(true), Version: 3},
+			},
+			setUpVerification: func(mk *verification.VerificationMock) {},
+		},
+		{
+			title: "valid case - same email in another form is not verified again",
+			input: input{body: `{"email": "John.Doe@Gmail.com", "current_password": "current", "version": 2}`},
+			output: output{
+				code: http.StatusOK,
+				body: `{"message": "user updated", "data": {"id": 1, "username": "johndoe", "email": "John.Doe@Gmail.com", "version": 3}}`,
+				stored: user.User{Id: 1, Username: optional.Some("johndoe"), Password: optional.Some("current"), Email: optional.Some("John.Doe@Gmail.com"), IsActive: optional.Some(true), Version: 3},
+			},
+			setUpVerification: func(mk *verification.VerificationMock) {},
+		},
+		{
+			title: "valid case - new email is verified",
+			input: input{body: `{"email": "johnny@gmail.com", "current_password": "current", "version": 2}`},
+			output: output{
+				code: http.StatusOK,
+				body: `{"message": "user updated, check your email to verify your account", "data": {"id": 1, "username": "johndoe", "email": "johnny@gmail.com", "version": 3}}`,
+				stored: user.User{Id: 1, Username: optional.Some("johndoe"), Password: optional.Some("current"), Email: optional.Some("johnny@gmail.com"), IsActive: optional.Some(false), Version: 3},
+			},
+			setUpVerification: func(mk *verification.VerificationMock) {
+				mk.On("Send", mock.MatchedBy(func(u user.User) bool { email, _ := u.Email.Unwrap(); return email == "johnny@gmail.com" })).Return(nil)
+			},
+		},
+
+		// invalid cases
+		{
+			title: "stale version",
+			input: input{body: `{"username": "johnny", "version": 1}`},
+			output: output{
+				code: http.StatusConflict,
+				body: `"user was modified, get it again and retry"`,
+				stored: stored,
+			},
+			setUpVerification: func(mk *verification.VerificationMock) {},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			db := storage.NewStoreMap(stored)
+			cr := credential.NewCredentialMock()
+			cr.On("VerifyByUsername", "johndoe", "current").Return(nil)
+			vr := verification.NewVerificationMock()
+			c.setUpVerification(vr)
+			jw := jwtauth.NewJWTAuthMock()
+			jw.On("ValidateSign", "sign").Return(&jwtauth.Token{ID: "token_id", Claims: map[string]any{"user_id": "1"}}, nil)
+			hd := handler.NewHandlersUser(cr, storage.NewStorageReadMap(db), storage.NewStorageWriteMap(db), vr, nm)
+
+			// act
+			req := httptest.NewRequest(http.MethodPatch, "/users/me", strings.NewReader(c.input.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer sign")
+			res := httptest.NewRecorder()
+			jwtauth.Authenticate(jw)(hd.Patch()).ServeHTTP(res, req)
+
+			// assert
+			assert.Equal(t, c.output.code, res.Code)
+			assert.JSONEq(t, c.output.body, res.Body.String())
+			u, err := storage.NewStorageReadMap(db).Get(1)
+			require.NoError(t, err)
+			assert.Equal(t, c.output.stored, u)
+			vr.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package linktoken
+
+import "errors"
+
+var (
+	// ErrLinkTokenConfig is returned when the configuration of the tokens is not valid
+	ErrLinkTokenConfig = errors.New("linktoken: invalid config")
+	// ErrLinkTokenInternal is returned when an internal error occurs
+	ErrLinkTokenInternal = errors.New("linktoken: internal error")
+	// ErrLinkTokenInvalid is returned when a token is malformed, tampered or issued for another purpose
+	ErrLinkTokenInvalid = errors.New("linktoken: invalid token")
+	// ErrLinkTokenExpired is returned when a token is expired
+	ErrLinkTokenExpired = errors.New("linktoken: expired token")
+	// ErrLinkTokenUsed is returned when a token was already consumed
+	ErrLinkTokenUsed = errors.New("linktoken: token already used")
+)
+
+// LinkToken interface to handle signed, single-use and expiring tokens for a user
+// - tokens are meant to be sent in links by email (email verification, magic links, etc)
+type LinkToken interface {
+	// Issue issues a new token for a user
+	Issue(userId int) (token string, err error)
+
+	// Consume validates a token and marks it as used, returning its user
+	Consume(token string) (userId int, err error)
+}
@@ -0,0 +1,181 @@
+package linktoken
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LNMMusic/optional"
+)
+
+// MinSecretLength is the minimum length in bytes of the secret key of the tokens
+const MinSecretLength = 32
+
+// NewLinkTokenHMAC returns a new LinkTokenHMAC
+// - the secret must be at least MinSecretLength bytes long and the purpose must not be empty
+func NewLinkTokenHMAC(config *ConfigHMAC) (l *LinkTokenHMAC, err error) {
+	// validate config
+	// - with a short or empty secret, anyone could sign valid tokens
+	if len(config.Secret) < MinSecretLength {
+		err = fmt.Errorf("%w. secret should be at least %d bytes", ErrLinkTokenConfig, MinSecretLength)
+		return
+	}
+	if config.Purpose == "" {
+		err = fmt.Errorf("%w. purpose is empty", ErrLinkTokenConfig)
+		return
+	}
+
+	// default values
+	if !config.TTL.IsSome() {
+		config.TTL = optional.Some(24 * time.Hour)
+	}
+
+	l = &LinkTokenHMAC{
+		config: config,
+		used: make(map[string]time.Time),
+		mu: &sync.Mutex{},
+		now: time.Now,
+	}
+	return
+}
+
+// ConfigHMAC is the configuration of LinkTokenHMAC
+type ConfigHMAC struct {
+	// Secret is the secret key to sign the tokens
+	Secret 	[]byte
+	// Purpose scopes the tokens, so a token issued for one purpose is invalid for another
+	// - e.g. "email-verification", "magic-link"
+	Purpose string
+	// TTL is the time to live of the tokens
+	TTL 	optional.Option[time.Duration]
+}
+
+// payload is the signed content of a token
+type payload struct {
+	// Purpose is the purpose of the token
+	Purpose 	string 	`json:"p"`
+	// UserId is the id of the user
+	UserId 		int 	`json:"u"`
+	// ExpireDate is the expire date of the token in unix seconds
+	ExpireDate 	int64 	`json:"e"`
+	// Nonce is the random and unique id of the token
+	Nonce 		string 	`json:"n"`
+}
+
+// LinkTokenHMAC is the implementation of the LinkToken interface with tokens signed with HMAC-SHA256
+// - token format: base64url(payload).base64url(signature)
+// - used tokens are tracked by nonce in memory until they expire
+type LinkTokenHMAC struct {
+	// config is the configuration of the tokens
+	config *ConfigHMAC
+	// used is the set of consumed tokens
+	// - key: nonce
+	// - value: expire date of the token
+	used map[string]time.Time
+	// mu is the mutex of the used set
+	mu *sync.Mutex
+	// now returns the current date
+	now func() time.Time
+}
+
+// Issue issues a new token for a user
+func (l *LinkTokenHMAC) Issue(userId int) (token string, err error) {
+	// nonce
+	b := make([]byte, 16)
+	_, err = rand.Read(b)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrLinkTokenInternal, err.Error())
+		return
+	}
+
+	// payload
+	ttl, _ := l.config.TTL.Unwrap()
+	p := payload{
+		Purpose: l.config.Purpose,
+		UserId: userId,
+		ExpireDate: l.now().Add(ttl).Unix(),
+		Nonce: hex.EncodeToString(b),
+	}
+	bytes, err := json.Marshal(p)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrLinkTokenInternal, err.Error())
+		return
+	}
+
+	// sign
+	encoded := base64.RawURLEncoding.EncodeToString(bytes)
+	token = encoded + "." + base64.RawURLEncoding.EncodeToString(l.sign(encoded))
+	return
+}
+
+// Consume validates a token and marks it as used, returning its user
+func (l *LinkTokenHMAC) Consume(token string) (userId int, err error) {
+	// verify signature
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		err = fmt.Errorf("%w. malformed", ErrLinkTokenInvalid)
+		return
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, l.sign(encoded)) {
+		err = fmt.Errorf("%w. signature mismatch", ErrLinkTokenInvalid)
+		return
+	}
+
+	// decode payload
+	bytes, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrLinkTokenInvalid, err.Error())
+		return
+	}
+	var p payload
+	err = json.Unmarshal(bytes, &p)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrLinkTokenInvalid, err.Error())
+		return
+	}
+	if p.Purpose != l.config.Purpose {
+		err = fmt.Errorf("%w. purpose mismatch", ErrLinkTokenInvalid)
+		return
+	}
+
+	// check expiration
+	now := l.now()
+	expireDate := time.Unix(p.ExpireDate, 0)
+	if !now.Before(expireDate) {
+		err = ErrLinkTokenExpired
+		return
+	}
+
+	// mark as used
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for nonce, exp := range l.used {
+		if !now.Before(exp) {
+			delete(l.used, nonce)
+		}
+	}
+	if _, ok := l.used[p.Nonce]; ok {
+		err = ErrLinkTokenUsed
+		return
+	}
+	l.used[p.Nonce] = expireDate
+
+	userId = p.UserId
+	return
+}
+
+// sign returns the HMAC-SHA256 signature of an encoded payload
+func (l *LinkTokenHMAC) sign(encoded string) (signature []byte) {
+	mac := hmac.New(sha256.New, l.config.Secret)
+	mac.Write([]byte(encoded))
+	signature = mac.Sum(nil)
+	return
+}
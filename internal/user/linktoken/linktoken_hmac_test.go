@@ -0,0 +1,149 @@
+package linktoken
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for NewLinkTokenHMAC
+func TestNewLinkTokenHMAC(t *testing.T) {
+	t.Run("failure - empty secret", func(t *testing.T) {
+		// act
+		lt, err := NewLinkTokenHMAC(&ConfigHMAC{Purpose: "magic-link"})
+
+		// assert
+		require.ErrorIs(t, err, ErrLinkTokenConfig)
+		require.EqualError(t, err, "linktoken: invalid config. secret should be at least 32 bytes")
+		require.Nil(t, lt)
+	})
+
+	t.Run("failure - short secret", func(t *testing.T) {
+		// act
+		_, err := NewLinkTokenHMAC(&ConfigHMAC{Secret: []byte("secret"), Purpose: "magic-link"})
+
+		// assert
+		require.ErrorIs(t, err, ErrLinkTokenConfig)
+	})
+
+	t.Run("failure - empty purpose", func(t *testing.T) {
+		// act
+		_, err := NewLinkTokenHMAC(&ConfigHMAC{Secret: []byte("0123456789abcdef0123456789abcdef")})
+
+		// assert
+		require.ErrorIs(t, err, ErrLinkTokenConfig)
+		require.EqualError(t, err, "linktoken: invalid config. purpose is empty")
+	})
+}
+
+// Tests for LinkTokenHMAC
+func TestLinkTokenHMAC_Consume(t *testing.T) {
+	newLinkToken := func(t *testing.T, purpose string, now *time.Time) *LinkTokenHMAC {
+		lt, err := NewLinkTokenHMAC(&ConfigHMAC{
+			Secret: []byte("0123456789abcdef0123456789abcdef"),
+			Purpose: purpose,
+			TTL: optional.Some(time.Hour),
+		})
+		require.NoError(t, err)
+		lt.now = func() time.Time { return *now }
+		return lt
+	}
+
+	t.Run("success - consume an issued token", func(t *testing.T) {
+		// arrange
+		now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		lt := newLinkToken(t, "email-verification", &now)
+		token, err := lt.Issue(1)
+		require.NoError(t, err)
+
+		// act
+		userId, err := lt.Consume(token)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 1, userId)
+	})
+
+	t.Run("failure - token already used", func(t *testing.T) {
+		// arrange
+		now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		lt := newLinkToken(t, "email-verification", &now)
+		token, err := lt.Issue(1)
+		require.NoError(t, err)
+		_, err = lt.Consume(token)
+		require.NoError(t, err)
+
+		// act
+		userId, err := lt.Consume(token)
+
+		// assert
+		require.ErrorIs(t, err, ErrLinkTokenUsed)
+		require.Zero(t, userId)
+	})
+
+	t.Run("failure - token expired", func(t *testing.T) {
+		// arrange
+		now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		lt := newLinkToken(t, "email-verification", &now)
+		token, err := lt.Issue(1)
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+
+		// act
+		userId, err := lt.Consume(token)
+
+		// assert
+		require.ErrorIs(t, err, ErrLinkTokenExpired)
+		require.Zero(t, userId)
+	})
+
+	t.Run("failure - token tampered", func(t *testing.T) {
+		// arrange
+		now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		lt := newLinkToken(t, "email-verification", &now)
+		token, err := lt.Issue(1)
+		require.NoError(t, err)
+		encoded, signature, _ := strings.Cut(token, ".")
+		tampered := "X" + encoded[1:] + "." + signature
+
+		// act
+		userId, err := lt.Consume(tampered)
+
+		// assert
+		require.ErrorIs(t, err, ErrLinkTokenInvalid)
+		require.Zero(t, userId)
+	})
+
+	t.Run("failure - token malformed", func(t *testing.T) {
+		// arrange
+		now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		lt := newLinkToken(t, "email-verification", &now)
+
+		// act
+		userId, err := lt.Consume("malformed")
+
+		// assert
+		require.ErrorIs(t, err, ErrLinkTokenInvalid)
+		require.EqualError(t, err, "linktoken: invalid token. malformed")
+		require.Zero(t, userId)
+	})
+
+	t.Run("failure - token issued for another purpose", func(t *testing.T) {
+		// arrange
+		now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		token, err := newLinkToken(t, "magic-link", &now).Issue(1)
+		require.NoError(t, err)
+		lt := newLinkToken(t, "email-verification", &now)
+
+		// act
+		userId, err := lt.Consume(token)
+
+		// assert
+		require.ErrorIs(t, err, ErrLinkTokenInvalid)
+		require.EqualError(t, err, "linktoken: invalid token. purpose mismatch")
+		require.Zero(t, userId)
+	})
+}
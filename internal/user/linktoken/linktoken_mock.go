@@ -0,0 +1,30 @@
+package linktoken
+
+import "github.com/stretchr/testify/mock"
+
+// NewLinkTokenMock returns a new mock link token
+func NewLinkTokenMock() *LinkTokenMock {
+	return &LinkTokenMock{}
+}
+
+// LinkTokenMock is a mock implementation of the LinkToken interface
+type LinkTokenMock struct {
+	// mock.Mock is a struct that implements the Mock struct for testing purposes
+	mock.Mock
+}
+
+// Issue issues a new token for a user
+func (m *LinkTokenMock) Issue(userId int) (token string, err error) {
+	args := m.Called(userId)
+	token = args.String(0)
+	err = args.Error(1)
+	return
+}
+
+// Consume validates a token and marks it as used, returning its user
+func (m *LinkTokenMock) Consume(token string) (userId int, err error) {
+	args := m.Called(token)
+	userId = args.Int(0)
+	err = args.Error(1)
+	return
+}
@@ -0,0 +1,26 @@
+package magiclink
+
+import (
+	"errors"
+
+	"github.com/LNMMusic/msauth/internal/user"
+)
+
+var (
+	// ErrMagicLinkInternal is returned when an internal error occurs
+	ErrMagicLinkInternal = errors.New("magiclink: internal error")
+	// ErrMagicLinkTokenInvalid is returned when the login token is invalid, expired or already used
+	ErrMagicLinkTokenInvalid = errors.New("magiclink: invalid token")
+	// ErrMagicLinkUserInactive is returned when the user of a login token is not active
+	ErrMagicLinkUserInactive = errors.New("magiclink: user is not active")
+)
+
+// MagicLink interface to handle the passwordless login by email
+type MagicLink interface {
+	// Send sends a login link to an email
+	// - it does not fail when the email is not registered, so emails can not be enumerated
+	Send(email string) (err error)
+
+	// Consume consumes a login token and returns its user
+	Consume(token string) (u user.User, err error)
+}
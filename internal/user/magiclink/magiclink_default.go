@@ -0,0 +1,145 @@
+package magiclink
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/linktoken"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/pkg/mailer"
+)
+
+// TemplateDefault is the default template of the login mails
+// - data: Link is the login link
+var TemplateDefault = mailer.MustTemplate(
+	"Your sign in link",
+	"Sign in by following this link: {{.Link}}\nIf you did not request it, ignore this email.",
+	`<p>Sign in by following this <a href="{{.Link}}">link</a>.</p><p>If you did not request it, ignore this email.</p>`,
+)
+
+// NewMagicLinkDefault returns a new MagicLinkDefault
+// - lt should be scoped to its own purpose (e.g. "magic-link") and short-lived (e.g. 15 minutes)
+// - ml should send in the background (e.g. mailer.MailerAsync), so registered emails do not take longer to respond
+func NewMagicLinkDefault(lt linktoken.LinkToken, ml mailer.Mailer, stRead storage.StorageRead, config *Config) *MagicLinkDefault {
+	// default values
+	if config.Template == nil {
+		config.Template = TemplateDefault
+	}
+
+	return &MagicLinkDefault{
+		lt: lt,
+		ml: ml,
+		stRead: stRead,
+		config: config,
+	}
+}
+
+// Config is the configuration of MagicLinkDefault
+type Config struct {
+	// CallbackURL is the url of the callback endpoint, the token is appended as the "token" query param
+	CallbackURL string
+	// Template is the template of the login mails (default TemplateDefault)
+	Template *mailer.Template
+}
+
+// MagicLinkDefault is the default implementation of the MagicLink interface
+// - tokens are issued by a LinkToken and sent by a Mailer
+type MagicLinkDefault struct {
+	// lt is the LinkToken interface to issue and consume login tokens
+	lt linktoken.LinkToken
+	// ml is the Mailer interface to send login mails
+	ml mailer.Mailer
+	// stRead is the read interface for the user store
+	stRead storage.StorageRead
+	// config is the configuration of the magic link
+	config *Config
+}
+
+// Send sends a login link to an email
+// - nothing is sent to unregistered emails nor inactive users
+func (m *MagicLinkDefault) Send(email string) (err error) {
+	// get user
+	u, err := m.stRead.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, storage.ErrStorageNotFound) {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("%w. %s", ErrMagicLinkInternal, err.Error())
+		return
+	}
+	if isActive, _ := u.IsActive.Unwrap(); !isActive {
+		return
+	}
+
+	// issue token
+	token, err := m.lt.Issue(u.Id)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMagicLinkInternal, err.Error())
+		return
+	}
+
+	// link
+	link, err := url.Parse(m.config.CallbackURL)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMagicLinkInternal, err.Error())
+		return
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	// send mail
+	// - to the stored email, the typed one may be another spelling of it (e.g. case or provider rules)
+	to, _ := u.Email.Unwrap()
+	mail, err := m.config.Template.Render([]string{to}, map[string]any{"Link": link.String()})
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMagicLinkInternal, err.Error())
+		return
+	}
+	err = m.ml.Send(mail)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMagicLinkInternal, err.Error())
+		return
+	}
+
+	return
+}
+
+// Consume consumes a login token and returns its user
+func (m *MagicLinkDefault) Consume(token string) (u user.User, err error) {
+	// consume token
+	userId, err := m.lt.Consume(token)
+	if err != nil {
+		switch {
+		case errors.Is(err, linktoken.ErrLinkTokenInvalid),
+			errors.Is(err, linktoken.ErrLinkTokenExpired),
+			errors.Is(err, linktoken.ErrLinkTokenUsed):
+			err = fmt.Errorf("%w. %s", ErrMagicLinkTokenInvalid, err.Error())
+		default:
+			err = fmt.Errorf("%w. %s", ErrMagicLinkInternal, err.Error())
+		}
+		return
+	}
+
+	// get user
+	u, err = m.stRead.Get(userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrStorageNotFound):
+			err = fmt.Errorf("%w. %s", ErrMagicLinkTokenInvalid, err.Error())
+		default:
+			err = fmt.Errorf("%w. %s", ErrMagicLinkInternal, err.Error())
+		}
+		return
+	}
+	if isActive, _ := u.IsActive.Unwrap(); !isActive {
+		u = user.User{}
+		err = ErrMagicLinkUserInactive
+		return
+	}
+
+	return
+}
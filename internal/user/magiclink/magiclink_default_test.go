@@ -0,0 +1,147 @@
+package magiclink_test
+
+import (
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/linktoken"
+	"github.com/LNMMusic/msauth/internal/user/magiclink"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/pkg/mailer"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for MagicLinkDefault.Send
+func TestMagicLinkDefault_Send(t *testing.T) {
+	t.Run("success - login mail sent", func(t *testing.T) {
+		// arrange
+		rd := storage.NewStorageReadMock()
+		rd.On("GetByEmail", "johndoe@gmail.com").Return(user.User{Id: 1, Email: optional.Some("johndoe@gmail.com"), IsActive: optional.Some(true)}, nil)
+		lt := linktoken.NewLinkTokenMock()
+		lt.On("Issue", 1).Return("token", nil)
+		ml := mailer.NewMailerMock()
+		ml.On("Send", &mailer.Mail{
+			To: []string{"johndoe@gmail.com"},
+			Subject: "Your sign in link",
+			Text: "Sign in by following this link: https://auth.example.com/magic-link/callback?token=token\nIf you did not request it, ignore this email.",
+			HTML: `<p>Sign in by following this <a href="https://auth.example.com/magic-link/callback?token=token">link</a>.</p><p>If you did not request it, ignore this email.</p>`,
+		}).Return(nil)
+		mk := magiclink.NewMagicLinkDefault(lt, ml, rd, &magiclink.Config{CallbackURL: "https://auth.example.com/magic-link/callback"})
+
+		// act
+		err := mk.Send("johndoe@gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		rd.AssertExpectations(t)
+		lt.AssertExpectations(t)
+		ml.AssertExpectations(t)
+	})
+
+	t.Run("success - login mail sent to the stored email", func(t *testing.T) {
+		// arrange
+		rd := storage.NewStorageReadMock()
+		rd.On("GetByEmail", "John.Doe@Gmail.com").Return(user.User{Id: 1, Email: optional.Some("johndoe@gmail.com"), IsActive: optional.Some(true)}, nil)
+		lt := linktoken.NewLinkTokenMock()
+		lt.On("Issue", 1).Return("token", nil)
+		ml := mailer.NewMailerMemory()
+		mk := magiclink.NewMagicLinkDefault(lt, ml, rd, &magiclink.Config{CallbackURL: "https://auth.example.com/magic-link/callback"})
+
+		// act
+		err := mk.Send("John.Doe@Gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		require.Len(t, ml.Mails(), 1)
+		require.Equal(t, []string{"johndoe@gmail.com"}, ml.Mails()[0].To)
+		rd.AssertExpectations(t)
+		lt.AssertExpectations(t)
+	})
+
+	t.Run("success - email not registered, nothing sent", func(t *testing.T) {
+		// arrange
+		rd := storage.NewStorageReadMock()
+		rd.On("GetByEmail", "unknown@gmail.com").Return(user.User{}, storage.ErrStorageNotFound)
+		ml := mailer.NewMailerMemory()
+		mk := magiclink.NewMagicLinkDefault(nil, ml, rd, &magiclink.Config{})
+
+		// act
+		err := mk.Send("unknown@gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		require.Empty(t, ml.Mails())
+		rd.AssertExpectations(t)
+	})
+
+	t.Run("success - user inactive, nothing sent", func(t *testing.T) {
+		// arrange
+		rd := storage.NewStorageReadMock()
+		rd.On("GetByEmail", "johndoe@gmail.com").Return(user.User{Id: 1, Email: optional.Some("johndoe@gmail.com"), IsActive: optional.Some(false)}, nil)
+		ml := mailer.NewMailerMemory()
+		mk := magiclink.NewMagicLinkDefault(nil, ml, rd, &magiclink.Config{})
+
+		// act
+		err := mk.Send("johndoe@gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		require.Empty(t, ml.Mails())
+		rd.AssertExpectations(t)
+	})
+}
+
+// Tests for MagicLinkDefault.Consume
+func TestMagicLinkDefault_Consume(t *testing.T) {
+	t.Run("success - user returned", func(t *testing.T) {
+		// arrange
+		lt := linktoken.NewLinkTokenMock()
+		lt.On("Consume", "token").Return(1, nil)
+		rd := storage.NewStorageReadMock()
+		rd.On("Get", 1).Return(user.User{Id: 1, IsActive: optional.Some(true)}, nil)
+		mk := magiclink.NewMagicLinkDefault(lt, nil, rd, &magiclink.Config{})
+
+		// act
+		u, err := mk.Consume("token")
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 1, u.Id)
+		lt.AssertExpectations(t)
+		rd.AssertExpectations(t)
+	})
+
+	t.Run("failure - token already used", func(t *testing.T) {
+		// arrange
+		lt := linktoken.NewLinkTokenMock()
+		lt.On("Consume", "token").Return(0, linktoken.ErrLinkTokenUsed)
+		mk := magiclink.NewMagicLinkDefault(lt, nil, nil, &magiclink.Config{})
+
+		// act
+		_, err := mk.Consume("token")
+
+		// assert
+		require.ErrorIs(t, err, magiclink.ErrMagicLinkTokenInvalid)
+		require.EqualError(t, err, "magiclink: invalid token. linktoken: token already used")
+		lt.AssertExpectations(t)
+	})
+
+	t.Run("failure - user deactivated after the link was sent", func(t *testing.T) {
+		// arrange
+		lt := linktoken.NewLinkTokenMock()
+		lt.On("Consume", "token").Return(1, nil)
+		rd := storage.NewStorageReadMock()
+		rd.On("Get", 1).Return(user.User{Id: 1, IsActive: optional.Some(false)}, nil)
+		mk := magiclink.NewMagicLinkDefault(lt, nil, rd, &magiclink.Config{})
+
+		// act
+		u, err := mk.Consume("token")
+
+		// assert
+		require.ErrorIs(t, err, magiclink.ErrMagicLinkUserInactive)
+		require.Equal(t, user.User{}, u)
+		lt.AssertExpectations(t)
+		rd.AssertExpectations(t)
+	})
+}
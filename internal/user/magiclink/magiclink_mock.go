@@ -0,0 +1,32 @@
+package magiclink
+
+import (
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/stretchr/testify/mock"
+)
+
+// NewMagicLinkMock returns a new mock magic link
+func NewMagicLinkMock() *MagicLinkMock {
+	return &MagicLinkMock{}
+}
+
+// MagicLinkMock is a mock implementation of the MagicLink interface
+type MagicLinkMock struct {
+	// mock.Mock is a struct that implements the Mock struct for testing purposes
+	mock.Mock
+}
+
+// Send sends a login link to an email
+func (m *MagicLinkMock) Send(email string) (err error) {
+	args := m.Called(email)
+	err = args.Error(0)
+	return
+}
+
+// Consume consumes a login token and returns its user
+func (m *MagicLinkMock) Consume(token string) (u user.User, err error) {
+	args := m.Called(token)
+	u = args.Get(0).(user.User)
+	err = args.Error(1)
+	return
+}
@@ -0,0 +1,138 @@
+package passkey
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var (
+	// errCBOR is returned when a cbor item is malformed or not supported
+	errCBOR = errors.New("malformed cbor")
+)
+
+// cborMaxDepth is the maximum nesting of the decoded items
+const cborMaxDepth = 16
+
+// decodeCBOR decodes the first cbor item of b (RFC 8949), returning the remaining bytes
+// - it supports the subset used by WebAuthn: integers, byte and text strings, arrays, maps, tags and simple values
+// - integers are decoded as int64, maps as map[any]any with int64 or string keys
+func decodeCBOR(b []byte) (v any, rest []byte, err error) {
+	v, rest, err = decodeCBORItem(b, 0)
+	return
+}
+
+// decodeCBORItem decodes a cbor item at a nesting depth
+func decodeCBORItem(b []byte, depth int) (v any, rest []byte, err error) {
+	if depth > cborMaxDepth || len(b) == 0 {
+		err = errCBOR
+		return
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	b = b[1:]
+
+	// simple values
+	if major == 7 {
+		switch info {
+		case 20:
+			v = false
+		case 21:
+			v = true
+		case 22:
+			v = nil
+		default:
+			err = errCBOR
+			return
+		}
+		rest = b
+		return
+	}
+
+	// argument
+	var n uint64
+	switch {
+	case info < 24:
+		n = uint64(info)
+	case info == 24 && len(b) >= 1:
+		n, b = uint64(b[0]), b[1:]
+	case info == 25 && len(b) >= 2:
+		n, b = uint64(binary.BigEndian.Uint16(b)), b[2:]
+	case info == 26 && len(b) >= 4:
+		n, b = uint64(binary.BigEndian.Uint32(b)), b[4:]
+	case info == 27 && len(b) >= 8:
+		n, b = binary.BigEndian.Uint64(b), b[8:]
+	default:
+		err = errCBOR
+		return
+	}
+
+	switch major {
+	case 0:
+		if n > math.MaxInt64 {
+			err = errCBOR
+			return
+		}
+		v, rest = int64(n), b
+	case 1:
+		if n > math.MaxInt64 {
+			err = errCBOR
+			return
+		}
+		v, rest = -1 - int64(n), b
+	case 2, 3:
+		if n > uint64(len(b)) {
+			err = errCBOR
+			return
+		}
+		if major == 2 {
+			v = append([]byte(nil), b[:n]...)
+		} else {
+			v = string(b[:n])
+		}
+		rest = b[n:]
+	case 4:
+		if n > uint64(len(b)) {
+			err = errCBOR
+			return
+		}
+		items := make([]any, 0, n)
+		for i := uint64(0); i < n; i++ {
+			var item any
+			item, b, err = decodeCBORItem(b, depth+1)
+			if err != nil {
+				return
+			}
+			items = append(items, item)
+		}
+		v, rest = items, b
+	case 5:
+		if n > uint64(len(b)) {
+			err = errCBOR
+			return
+		}
+		m := make(map[any]any, n)
+		for i := uint64(0); i < n; i++ {
+			var key, value any
+			key, b, err = decodeCBORItem(b, depth+1)
+			if err != nil {
+				return
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				err = errCBOR
+				return
+			}
+			value, b, err = decodeCBORItem(b, depth+1)
+			if err != nil {
+				return
+			}
+			m[key] = value
+		}
+		v, rest = m, b
+	case 6:
+		// tags are ignored
+		v, rest, err = decodeCBORItem(b, depth+1)
+	}
+	return
+}
@@ -0,0 +1,58 @@
+package passkey
+
+import "errors"
+
+var (
+	// ErrPasskeyInternal is returned when an internal error occurs
+	ErrPasskeyInternal = errors.New("passkey: internal error")
+	// ErrPasskeyInvalid is returned when a ceremony response is malformed or does not verify
+	ErrPasskeyInvalid = errors.New("passkey: invalid response")
+	// ErrPasskeyChallenge is returned when the challenge of a response is unknown, expired or already used
+	ErrPasskeyChallenge = errors.New("passkey: invalid challenge")
+	// ErrPasskeyCredentialNotFound is returned when the credential of an assertion is not registered
+	ErrPasskeyCredentialNotFound = errors.New("passkey: credential not found")
+	// ErrPasskeyCredentialExists is returned when a credential is already registered
+	ErrPasskeyCredentialExists = errors.New("passkey: credential already registered")
+	// ErrPasskeySignCount is returned when the sign counter did not increase, the authenticator may be cloned
+	ErrPasskeySignCount = errors.New("passkey: sign counter did not increase")
+)
+
+// RegistrationResponse is the response of the authenticator to a registration ceremony
+// - navigator.credentials.create() result
+type RegistrationResponse struct {
+	// ClientDataJSON is the client data
+	ClientDataJSON 		[]byte
+	// AttestationObject is the cbor encoded attestation object
+	AttestationObject 	[]byte
+}
+
+// AssertionResponse is the response of the authenticator to a login ceremony
+// - navigator.credentials.get() result
+type AssertionResponse struct {
+	// CredentialID is the raw id of the credential
+	CredentialID 		[]byte
+	// ClientDataJSON is the client data
+	ClientDataJSON 		[]byte
+	// AuthenticatorData is the authenticator data
+	AuthenticatorData 	[]byte
+	// Signature is the ASN.1 signature of the authenticator data and the client data hash
+	Signature 			[]byte
+	// UserHandle is the user handle of the credential
+	UserHandle 			[]byte
+}
+
+// Passkey interface to handle the WebAuthn relying party ceremonies
+// - options are the json PublicKeyCredentialCreationOptions / PublicKeyCredentialRequestOptions for the browser
+type Passkey interface {
+	// BeginRegistration starts the registration of a new credential for a user
+	BeginRegistration(userId int, name string) (options map[string]any, err error)
+
+	// FinishRegistration verifies the response of a registration and stores the credential
+	FinishRegistration(userId int, r RegistrationResponse) (err error)
+
+	// BeginLogin starts a login with a discoverable credential (no username required)
+	BeginLogin() (options map[string]any, err error)
+
+	// FinishLogin verifies the response of a login and returns the user of the credential
+	FinishLogin(a AssertionResponse) (userId int, err error)
+}
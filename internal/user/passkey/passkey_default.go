@@ -0,0 +1,462 @@
+package passkey
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/LNMMusic/optional"
+)
+
+const (
+	// flagUserPresent is the user present flag of the authenticator data
+	flagUserPresent = 0x01
+	// flagUserVerified is the user verified flag of the authenticator data
+	flagUserVerified = 0x04
+	// flagAttestedCredentialData is the attested credential data flag of the authenticator data
+	flagAttestedCredentialData = 0x40
+	// algES256 is the cose identifier of ECDSA with P-256 and SHA-256
+	algES256 = -7
+)
+
+// NewPasskeyDefault returns a new PasskeyDefault
+func NewPasskeyDefault(st Storage, config *Config) *PasskeyDefault {
+	// default values
+	if !config.Timeout.IsSome() {
+		config.Timeout = optional.Some(5 * time.Minute)
+	}
+
+	return &PasskeyDefault{
+		st: st,
+		config: config,
+		challenges: make(map[string]challenge),
+		mu: &sync.Mutex{},
+		pruneAt: 1024,
+		now: time.Now,
+	}
+}
+
+// Config is the configuration of PasskeyDefault
+type Config struct {
+	// RPID is the relying party id, the effective domain of the origin (e.g. "example.com")
+	RPID 					string
+	// RPName is the relying party name shown by the authenticators
+	RPName 					string
+	// Origin is the expected origin of the ceremonies (e.g. "https://example.com")
+	Origin 					string
+	// Timeout is the time to complete a ceremony (default 5 minutes)
+	Timeout 				optional.Option[time.Duration]
+	// RequireUserVerification requires the authenticator to verify the user (pin, biometrics)
+	RequireUserVerification bool
+}
+
+// challenge is a pending ceremony
+type challenge struct {
+	// ceremony is the type of the client data expected ("webauthn.create" or "webauthn.get")
+	ceremony string
+	// userId is the user of a registration, 0 for logins
+	userId int
+	// expireDate is the expire date of the ceremony
+	expireDate time.Time
+}
+
+// clientData is the client data of a ceremony
+type clientData struct {
+	// Type is the type of the ceremony
+	Type 		string `json:"type"`
+	// Challenge is the base64url encoded challenge
+	Challenge 	string `json:"challenge"`
+	// Origin is the origin of the page
+	Origin 		string `json:"origin"`
+}
+
+// authenticatorData is the parsed authenticator data
+type authenticatorData struct {
+	// rpIdHash is the sha256 hash of the relying party id
+	rpIdHash []byte
+	// flags are the flags of the authenticator
+	flags byte
+	// signCount is the sign counter
+	signCount uint32
+	// credentialID is the id of the attested credential
+	credentialID []byte
+	// publicKey is the cose encoded public key of the attested credential
+	publicKey []byte
+}
+
+// PasskeyDefault is the default implementation of the Passkey interface, a WebAuthn relying party
+// - only "none" attestation and ES256 credentials are supported, the choice of passkey providers
+// - challenges are single-use and kept in memory until they expire
+type PasskeyDefault struct {
+	// st is the storage of the credentials
+	st Storage
+	// config is the configuration of the relying party
+	config *Config
+	// challenges are the pending ceremonies
+	// - key: base64url encoded challenge
+	// - value: ceremony
+	challenges map[string]challenge
+	// mu is the mutex of the challenges and the sign counters
+	mu *sync.Mutex
+	// pruneAt is the size of the challenges that triggers the next prune
+	pruneAt int
+	// now returns the current date
+	now func() time.Time
+}
+
+// BeginRegistration starts the registration of a new credential for a user
+func (p *PasskeyDefault) BeginRegistration(userId int, name string) (options map[string]any, err error) {
+	// exclude the credentials already registered
+	credentials, err := p.st.GetByUser(userId)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrPasskeyInternal, err.Error())
+		return
+	}
+	exclude := make([]map[string]any, 0, len(credentials))
+	for _, c := range credentials {
+		exclude = append(exclude, map[string]any{"type": "public-key", "id": base64.RawURLEncoding.EncodeToString(c.ID)})
+	}
+
+	ch, err := p.challenge("webauthn.create", userId)
+	if err != nil {
+		return
+	}
+
+	timeout, _ := p.config.Timeout.Unwrap()
+	options = map[string]any{
+		"challenge": ch,
+		"rp": map[string]any{"id": p.config.RPID, "name": p.config.RPName},
+		"user": map[string]any{
+			"id": base64.RawURLEncoding.EncodeToString(userHandle(userId)),
+			"name": name,
+			"displayName": name,
+		},
+		"pubKeyCredParams": []map[string]any{{"type": "public-key", "alg": algES256}},
+		"timeout": timeout.Milliseconds(),
+		"attestation": "none",
+		"excludeCredentials": exclude,
+		"authenticatorSelection": map[string]any{
+			"residentKey": "required",
+			"userVerification": p.userVerification(),
+		},
+	}
+	return
+}
+
+// FinishRegistration verifies the response of a registration and stores the credential
+func (p *PasskeyDefault) FinishRegistration(userId int, r RegistrationResponse) (err error) {
+	// client data
+	ch, err := p.verifyClientData(r.ClientDataJSON, "webauthn.create")
+	if err != nil {
+		return
+	}
+	if ch.userId != userId {
+		err = fmt.Errorf("%w. user mismatch", ErrPasskeyChallenge)
+		return
+	}
+
+	// attestation object
+	v, _, err := decodeCBOR(r.AttestationObject)
+	if err != nil {
+		err = fmt.Errorf("%w. attestation object: %s", ErrPasskeyInvalid, err.Error())
+		return
+	}
+	attestation, _ := v.(map[any]any)
+	format, _ := attestation["fmt"].(string)
+	if format != "none" {
+		err = fmt.Errorf("%w. unsupported attestation format %q", ErrPasskeyInvalid, format)
+		return
+	}
+	raw, _ := attestation["authData"].([]byte)
+	ad, err := p.verifyAuthenticatorData(raw)
+	if err != nil {
+		return
+	}
+	if ad.flags & flagAttestedCredentialData == 0 {
+		err = fmt.Errorf("%w. missing attested credential data", ErrPasskeyInvalid)
+		return
+	}
+	_, err = publicKey(ad.publicKey)
+	if err != nil {
+		return
+	}
+
+	// store credential
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err = p.st.Get(ad.credentialID)
+	if err == nil {
+		err = ErrPasskeyCredentialExists
+		return
+	}
+	if !errors.Is(err, ErrStorageCredentialNotFound) {
+		err = fmt.Errorf("%w. %s", ErrPasskeyInternal, err.Error())
+		return
+	}
+	err = p.st.Set(Credential{
+		ID: ad.credentialID,
+		UserId: userId,
+		PublicKey: ad.publicKey,
+		SignCount: ad.signCount,
+	})
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrPasskeyInternal, err.Error())
+		return
+	}
+
+	return
+}
+
+// BeginLogin starts a login with a discoverable credential (no username required)
+func (p *PasskeyDefault) BeginLogin() (options map[string]any, err error) {
+	ch, err := p.challenge("webauthn.get", 0)
+	if err != nil {
+		return
+	}
+
+	timeout, _ := p.config.Timeout.Unwrap()
+	options = map[string]any{
+		"challenge": ch,
+		"rpId": p.config.RPID,
+		"timeout": timeout.Milliseconds(),
+		"userVerification": p.userVerification(),
+	}
+	return
+}
+
+// FinishLogin verifies the response of a login and returns the user of the credential
+func (p *PasskeyDefault) FinishLogin(a AssertionResponse) (userId int, err error) {
+	// client data
+	_, err = p.verifyClientData(a.ClientDataJSON, "webauthn.get")
+	if err != nil {
+		return
+	}
+
+	// credential
+	c, err := p.st.Get(a.CredentialID)
+	if err != nil {
+		if errors.Is(err, ErrStorageCredentialNotFound) {
+			err = fmt.Errorf("%w. %s", ErrPasskeyCredentialNotFound, err.Error())
+			return
+		}
+		err = fmt.Errorf("%w. %s", ErrPasskeyInternal, err.Error())
+		return
+	}
+	if len(a.UserHandle) > 0 && !bytes.Equal(a.UserHandle, userHandle(c.UserId)) {
+		err = fmt.Errorf("%w. user handle mismatch", ErrPasskeyInvalid)
+		return
+	}
+
+	// authenticator data and signature
+	ad, err := p.verifyAuthenticatorData(a.AuthenticatorData)
+	if err != nil {
+		return
+	}
+	key, err := publicKey(c.PublicKey)
+	if err != nil {
+		return
+	}
+	clientDataHash := sha256.Sum256(a.ClientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), a.AuthenticatorData...), clientDataHash[:]...))
+	if !ecdsa.VerifyASN1(key, digest[:], a.Signature) {
+		err = fmt.Errorf("%w. signature mismatch", ErrPasskeyInvalid)
+		return
+	}
+
+	// sign counter
+	// - authenticators that do not implement it always report 0
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, err = p.st.Get(a.CredentialID)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrPasskeyInternal, err.Error())
+		return
+	}
+	if ad.signCount != 0 || c.SignCount != 0 {
+		if ad.signCount <= c.SignCount {
+			err = ErrPasskeySignCount
+			return
+		}
+		c.SignCount = ad.signCount
+		err = p.st.Set(c)
+		if err != nil {
+			err = fmt.Errorf("%w. %s", ErrPasskeyInternal, err.Error())
+			return
+		}
+	}
+
+	userId = c.UserId
+	return
+}
+
+// challenge issues a new challenge for a ceremony
+func (p *PasskeyDefault) challenge(ceremony string, userId int) (ch string, err error) {
+	b := make([]byte, 32)
+	_, err = rand.Read(b)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrPasskeyInternal, err.Error())
+		return
+	}
+	ch = base64.RawURLEncoding.EncodeToString(b)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	// prune expired challenges (amortized, only when the record doubles its size)
+	if len(p.challenges) >= p.pruneAt {
+		for k, v := range p.challenges {
+			if !now.Before(v.expireDate) {
+				delete(p.challenges, k)
+			}
+		}
+		p.pruneAt = max(1024, 2 * len(p.challenges))
+	}
+	timeout, _ := p.config.Timeout.Unwrap()
+	p.challenges[ch] = challenge{ceremony: ceremony, userId: userId, expireDate: now.Add(timeout)}
+	return
+}
+
+// verifyClientData verifies the client data of a ceremony and consumes its challenge
+func (p *PasskeyDefault) verifyClientData(raw []byte, ceremony string) (ch challenge, err error) {
+	var cd clientData
+	err = json.Unmarshal(raw, &cd)
+	if err != nil {
+		err = fmt.Errorf("%w. client data: %s", ErrPasskeyInvalid, err.Error())
+		return
+	}
+	if cd.Type != ceremony {
+		err = fmt.Errorf("%w. client data type %q", ErrPasskeyInvalid, cd.Type)
+		return
+	}
+	if cd.Origin != p.config.Origin {
+		err = fmt.Errorf("%w. origin %q", ErrPasskeyInvalid, cd.Origin)
+		return
+	}
+
+	// consume challenge
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ch, ok := p.challenges[cd.Challenge]
+	if !ok || ch.ceremony != ceremony {
+		err = ErrPasskeyChallenge
+		return
+	}
+	delete(p.challenges, cd.Challenge)
+	if !p.now().Before(ch.expireDate) {
+		err = fmt.Errorf("%w. expired", ErrPasskeyChallenge)
+		return
+	}
+
+	return
+}
+
+// verifyAuthenticatorData parses the authenticator data and verifies the relying party and the flags
+func (p *PasskeyDefault) verifyAuthenticatorData(raw []byte) (ad authenticatorData, err error) {
+	// rpIdHash (32) | flags (1) | signCount (4) | [aaguid (16) | credentialIdLength (2) | credentialId | publicKey]
+	if len(raw) < 37 {
+		err = fmt.Errorf("%w. authenticator data too short", ErrPasskeyInvalid)
+		return
+	}
+	ad.rpIdHash, ad.flags, ad.signCount = raw[:32], raw[32], binary.BigEndian.Uint32(raw[33:37])
+
+	rpIdHash := sha256.Sum256([]byte(p.config.RPID))
+	if !bytes.Equal(ad.rpIdHash, rpIdHash[:]) {
+		err = fmt.Errorf("%w. relying party mismatch", ErrPasskeyInvalid)
+		return
+	}
+	if ad.flags & flagUserPresent == 0 {
+		err = fmt.Errorf("%w. user not present", ErrPasskeyInvalid)
+		return
+	}
+	if p.config.RequireUserVerification && ad.flags & flagUserVerified == 0 {
+		err = fmt.Errorf("%w. user not verified", ErrPasskeyInvalid)
+		return
+	}
+
+	// attested credential data
+	if ad.flags & flagAttestedCredentialData != 0 {
+		rest := raw[37:]
+		if len(rest) < 18 {
+			err = fmt.Errorf("%w. attested credential data too short", ErrPasskeyInvalid)
+			return
+		}
+		n := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < n {
+			err = fmt.Errorf("%w. attested credential data too short", ErrPasskeyInvalid)
+			return
+		}
+		ad.credentialID, rest = rest[:n], rest[n:]
+
+		var after []byte
+		_, after, err = decodeCBOR(rest)
+		if err != nil {
+			err = fmt.Errorf("%w. public key: %s", ErrPasskeyInvalid, err.Error())
+			return
+		}
+		ad.publicKey = rest[:len(rest)-len(after)]
+	}
+
+	return
+}
+
+// userVerification returns the user verification requirement of the options
+func (p *PasskeyDefault) userVerification() (uv string) {
+	uv = "preferred"
+	if p.config.RequireUserVerification {
+		uv = "required"
+	}
+	return
+}
+
+// userHandle returns the user handle of a user
+func userHandle(userId int) (h []byte) {
+	h = []byte(strconv.Itoa(userId))
+	return
+}
+
+// publicKey decodes a cose encoded ES256 public key
+func publicKey(cose []byte) (key *ecdsa.PublicKey, err error) {
+	v, _, err := decodeCBOR(cose)
+	if err != nil {
+		err = fmt.Errorf("%w. public key: %s", ErrPasskeyInvalid, err.Error())
+		return
+	}
+	m, _ := v.(map[any]any)
+	// - kty: EC2 (2), alg: ES256 (-7), crv: P-256 (1)
+	if m[int64(1)] != int64(2) || m[int64(3)] != int64(algES256) || m[int64(-1)] != int64(1) {
+		err = fmt.Errorf("%w. unsupported public key", ErrPasskeyInvalid)
+		return
+	}
+	x, _ := m[int64(-2)].([]byte)
+	y, _ := m[int64(-3)].([]byte)
+	if len(x) != 32 || len(y) != 32 {
+		err = fmt.Errorf("%w. invalid public key coordinates", ErrPasskeyInvalid)
+		return
+	}
+	// - validates the point is on the curve
+	_, err = ecdh.P256().NewPublicKey(append(append([]byte{0x04}, x...), y...))
+	if err != nil {
+		err = fmt.Errorf("%w. public key: %s", ErrPasskeyInvalid, err.Error())
+		return
+	}
+
+	key = &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X: new(big.Int).SetBytes(x),
+		Y: new(big.Int).SetBytes(y),
+	}
+	return
+}
@@ -0,0 +1,447 @@
+package passkey
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeCBOR encodes the subset of cbor used by the authenticator
+func encodeCBOR(v any) (b []byte) {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 1<<8:
+			return []byte{major<<5 | 24, byte(n)}
+		default:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		}
+	}
+
+	switch v := v.(type) {
+	case int:
+		if v >= 0 {
+			return head(0, uint64(v))
+		}
+		return head(1, uint64(-1-v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case map[any]any:
+		b = head(5, uint64(len(v)))
+		for k, e := range v {
+			b = append(b, encodeCBOR(k)...)
+			b = append(b, encodeCBOR(e)...)
+		}
+		return b
+	}
+	panic("unsupported type")
+}
+
+// authenticator is a software WebAuthn authenticator
+type authenticator struct {
+	key *ecdsa.PrivateKey
+	id []byte
+	rpID string
+	origin string
+	signCount uint32
+	userHandle []byte
+}
+
+// newAuthenticator returns a new authenticator with a fresh ES256 credential
+func newAuthenticator(t *testing.T, rpID string, origin string) (a *authenticator) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	require.NoError(t, err)
+	a = &authenticator{key: key, id: id, rpID: rpID, origin: origin}
+	return
+}
+
+// clientData returns the client data json of a ceremony
+func (a *authenticator) clientData(t *testing.T, ceremony string, options map[string]any) []byte {
+	b, err := json.Marshal(map[string]any{
+		"type": ceremony,
+		"challenge": options["challenge"],
+		"origin": a.origin,
+		"crossOrigin": false,
+	})
+	require.NoError(t, err)
+	return b
+}
+
+// authData returns the authenticator data with the flags and, if attested, the credential
+func (a *authenticator) authData(flags byte, attested bool) []byte {
+	rpIdHash := sha256.Sum256([]byte(a.rpID))
+	b := append([]byte(nil), rpIdHash[:]...)
+	b = append(b, flags)
+	b = binary.BigEndian.AppendUint32(b, a.signCount)
+	if attested {
+		b = append(b, make([]byte, 16)...)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(a.id)))
+		b = append(b, a.id...)
+		b = append(b, encodeCBOR(map[any]any{
+			1: 2,
+			3: -7,
+			-1: 1,
+			-2: a.key.X.FillBytes(make([]byte, 32)),
+			-3: a.key.Y.FillBytes(make([]byte, 32)),
+		})...)
+	}
+	return b
+}
+
+// create responds a registration ceremony
+func (a *authenticator) create(t *testing.T, options map[string]any) RegistrationResponse {
+	user, _ := options["user"].(map[string]any)
+	handle, err := base64.RawURLEncoding.DecodeString(user["id"].(string))
+	require.NoError(t, err)
+	a.userHandle = handle
+
+	return RegistrationResponse{
+		ClientDataJSON: a.clientData(t, "webauthn.create", options),
+		AttestationObject: encodeCBOR(map[any]any{
+			"fmt": "none",
+			"attStmt": map[any]any{},
+			"authData": a.authData(flagUserPresent | flagUserVerified | flagAttestedCredentialData, true),
+		}),
+	}
+}
+
+// get responds a login ceremony, incrementing the sign counter
+func (a *authenticator) get(t *testing.T, options map[string]any) AssertionResponse {
+	a.signCount++
+	cd := a.clientData(t, "webauthn.get", options)
+	ad := a.authData(flagUserPresent | flagUserVerified, false)
+	clientDataHash := sha256.Sum256(cd)
+	digest := sha256.Sum256(append(append([]byte(nil), ad...), clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	return AssertionResponse{
+		CredentialID: a.id,
+		ClientDataJSON: cd,
+		AuthenticatorData: ad,
+		Signature: sig,
+		UserHandle: a.userHandle,
+	}
+}
+
+// newPasskey returns a PasskeyDefault with a controlled clock
+func newPasskey(now *time.Time) *PasskeyDefault {
+	p := NewPasskeyDefault(NewStorageLocal(), &Config{
+		RPID: "example.com",
+		RPName: "Example",
+		Origin: "https://example.com",
+		Timeout: optional.Some(time.Minute),
+	})
+	p.now = func() time.Time { return *now }
+	return p
+}
+
+// register registers the credential of an authenticator for a user
+func register(t *testing.T, p *PasskeyDefault, a *authenticator, userId int) {
+	options, err := p.BeginRegistration(userId, "johndoe")
+	require.NoError(t, err)
+	require.NoError(t, p.FinishRegistration(userId, a.create(t, options)))
+}
+
+// Tests for PasskeyDefault registration
+func TestPasskeyDefault_Registration(t *testing.T) {
+	t.Run("success - credential registered", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://example.com")
+
+		// act
+		options, err := p.BeginRegistration(1, "johndoe")
+		require.NoError(t, err)
+		err = p.FinishRegistration(1, a.create(t, options))
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "1", string(a.userHandle))
+		c, err := p.st.GetByUser(1)
+		require.NoError(t, err)
+		require.Len(t, c, 1)
+		require.Equal(t, a.id, c[0].ID)
+	})
+
+	t.Run("success - registered credentials are excluded", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://example.com")
+		register(t, p, a, 1)
+
+		// act
+		options, err := p.BeginRegistration(1, "johndoe")
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, []map[string]any{{"type": "public-key", "id": base64.RawURLEncoding.EncodeToString(a.id)}}, options["excludeCredentials"])
+	})
+
+	t.Run("failure - challenge replayed", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://example.com")
+		options, err := p.BeginRegistration(1, "johndoe")
+		require.NoError(t, err)
+		r := a.create(t, options)
+		require.NoError(t, p.FinishRegistration(1, r))
+
+		// act
+		err = p.FinishRegistration(1, r)
+
+		// assert
+		require.ErrorIs(t, err, ErrPasskeyChallenge)
+	})
+
+	t.Run("failure - challenge of another user", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://example.com")
+		options, err := p.BeginRegistration(1, "johndoe")
+		require.NoError(t, err)
+
+		// act
+		err = p.FinishRegistration(2, a.create(t, options))
+
+		// assert
+		require.ErrorIs(t, err, ErrPasskeyChallenge)
+	})
+
+	t.Run("failure - challenge expired", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://example.com")
+		options, err := p.BeginRegistration(1, "johndoe")
+		require.NoError(t, err)
+		now = now.Add(time.Minute)
+
+		// act
+		err = p.FinishRegistration(1, a.create(t, options))
+
+		// assert
+		require.ErrorIs(t, err, ErrPasskeyChallenge)
+	})
+
+	t.Run("failure - wrong origin", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://evil.example.net")
+		options, err := p.BeginRegistration(1, "johndoe")
+		require.NoError(t, err)
+
+		// act
+		err = p.FinishRegistration(1, a.create(t, options))
+
+		// assert
+		require.ErrorIs(t, err, ErrPasskeyInvalid)
+		require.EqualError(t, err, `passkey: invalid response. origin "https://evil.example.net"`)
+	})
+
+	t.Run("failure - wrong relying party", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "evil.example.net", "https://example.com")
+		options, err := p.BeginRegistration(1, "johndoe")
+		require.NoError(t, err)
+
+		// act
+		err = p.FinishRegistration(1, a.create(t, options))
+
+		// assert
+		require.ErrorIs(t, err, ErrPasskeyInvalid)
+		require.EqualError(t, err, "passkey: invalid response. relying party mismatch")
+	})
+
+	t.Run("failure - credential already registered", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://example.com")
+		register(t, p, a, 1)
+		options, err := p.BeginRegistration(2, "janedoe")
+		require.NoError(t, err)
+
+		// act
+		err = p.FinishRegistration(2, a.create(t, options))
+
+		// assert
+		require.ErrorIs(t, err, ErrPasskeyCredentialExists)
+	})
+}
+
+// Tests for PasskeyDefault login
+func TestPasskeyDefault_Login(t *testing.T) {
+	t.Run("success - user logged in", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://example.com")
+		register(t, p, a, 1)
+
+		// act
+		options, err := p.BeginLogin()
+		require.NoError(t, err)
+		userId, err := p.FinishLogin(a.get(t, options))
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 1, userId)
+		c, err := p.st.Get(a.id)
+		require.NoError(t, err)
+		require.Equal(t, uint32(1), c.SignCount)
+	})
+
+	t.Run("failure - sign counter did not increase", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://example.com")
+		register(t, p, a, 1)
+		options, err := p.BeginLogin()
+		require.NoError(t, err)
+		_, err = p.FinishLogin(a.get(t, options))
+		require.NoError(t, err)
+		// - a clone of the authenticator reports the same counter
+		a.signCount--
+
+		// act
+		options, err = p.BeginLogin()
+		require.NoError(t, err)
+		_, err = p.FinishLogin(a.get(t, options))
+
+		// assert
+		require.ErrorIs(t, err, ErrPasskeySignCount)
+	})
+
+	t.Run("failure - signature mismatch", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://example.com")
+		register(t, p, a, 1)
+		options, err := p.BeginLogin()
+		require.NoError(t, err)
+		r := a.get(t, options)
+		r.Signature[len(r.Signature)-1] ^= 0xff
+
+		// act
+		_, err = p.FinishLogin(r)
+
+		// assert
+		require.ErrorIs(t, err, ErrPasskeyInvalid)
+	})
+
+	t.Run("failure - credential not registered", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://example.com")
+		options, err := p.BeginLogin()
+		require.NoError(t, err)
+
+		// act
+		_, err = p.FinishLogin(a.get(t, options))
+
+		// assert
+		require.ErrorIs(t, err, ErrPasskeyCredentialNotFound)
+	})
+
+	t.Run("failure - registration challenge used to login", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		a := newAuthenticator(t, "example.com", "https://example.com")
+		register(t, p, a, 1)
+		options, err := p.BeginRegistration(1, "johndoe")
+		require.NoError(t, err)
+
+		// act
+		_, err = p.FinishLogin(a.get(t, options))
+
+		// assert
+		require.ErrorIs(t, err, ErrPasskeyChallenge)
+	})
+
+	t.Run("failure - user verification required", func(t *testing.T) {
+		// arrange
+		now := time.Now()
+		p := newPasskey(&now)
+		p.config.RequireUserVerification = true
+		a := newAuthenticator(t, "example.com", "https://example.com")
+		register(t, p, a, 1)
+		options, err := p.BeginLogin()
+		require.NoError(t, err)
+		r := a.get(t, options)
+		// - clear the user verified flag and sign again
+		r.AuthenticatorData[32] &^= flagUserVerified
+		clientDataHash := sha256.Sum256(r.ClientDataJSON)
+		digest := sha256.Sum256(append(append([]byte(nil), r.AuthenticatorData...), clientDataHash[:]...))
+		r.Signature, err = ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+		require.NoError(t, err)
+
+		// act
+		_, err = p.FinishLogin(r)
+
+		// assert
+		require.ErrorIs(t, err, ErrPasskeyInvalid)
+		require.EqualError(t, err, "passkey: invalid response. user not verified")
+	})
+}
+
+// Tests for decodeCBOR
+func TestDecodeCBOR(t *testing.T) {
+	t.Run("success - nested map", func(t *testing.T) {
+		// act
+		v, rest, err := decodeCBOR(append(encodeCBOR(map[any]any{"a": -7, 1: []byte{1, 2}, "s": "text"}), 0xff))
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, []byte{0xff}, rest)
+		require.Equal(t, map[any]any{"a": int64(-7), int64(1): []byte{1, 2}, "s": "text"}, v)
+	})
+
+	t.Run("failure - truncated", func(t *testing.T) {
+		// act
+		_, _, err := decodeCBOR([]byte{0x45, 1, 2})
+
+		// assert
+		require.Error(t, err)
+	})
+
+	t.Run("failure - too deep", func(t *testing.T) {
+		// arrange
+		b := make([]byte, 64)
+		for i := range b {
+			b[i] = 0x81
+		}
+
+		// act
+		_, _, err := decodeCBOR(b)
+
+		// assert
+		require.Error(t, err)
+	})
+}
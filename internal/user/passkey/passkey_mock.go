@@ -0,0 +1,45 @@
+package passkey
+
+import "github.com/stretchr/testify/mock"
+
+// NewPasskeyMock returns a new mock passkey
+func NewPasskeyMock() *PasskeyMock {
+	return &PasskeyMock{}
+}
+
+// PasskeyMock is a mock implementation of the Passkey interface
+type PasskeyMock struct {
+	// mock.Mock is a struct that implements the Mock struct for testing purposes
+	mock.Mock
+}
+
+// BeginRegistration starts the registration of a new credential for a user
+func (m *PasskeyMock) BeginRegistration(userId int, name string) (options map[string]any, err error) {
+	args := m.Called(userId, name)
+	options, _ = args.Get(0).(map[string]any)
+	err = args.Error(1)
+	return
+}
+
+// FinishRegistration verifies the response of a registration and stores the credential
+func (m *PasskeyMock) FinishRegistration(userId int, r RegistrationResponse) (err error) {
+	args := m.Called(userId, r)
+	err = args.Error(0)
+	return
+}
+
+// BeginLogin starts a login with a discoverable credential (no username required)
+func (m *PasskeyMock) BeginLogin() (options map[string]any, err error) {
+	args := m.Called()
+	options, _ = args.Get(0).(map[string]any)
+	err = args.Error(1)
+	return
+}
+
+// FinishLogin verifies the response of a login and returns the user of the credential
+func (m *PasskeyMock) FinishLogin(a AssertionResponse) (userId int, err error) {
+	args := m.Called(a)
+	userId = args.Int(0)
+	err = args.Error(1)
+	return
+}
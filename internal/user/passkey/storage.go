@@ -0,0 +1,32 @@
+package passkey
+
+import "errors"
+
+var (
+	// ErrStorageCredentialNotFound is returned when a credential is not found
+	ErrStorageCredentialNotFound = errors.New("passkey: storage credential not found")
+)
+
+// Credential is a WebAuthn credential of a user
+type Credential struct {
+	// ID is the raw id of the credential
+	ID 			[]byte
+	// UserId is the id of the user
+	UserId 		int
+	// PublicKey is the cose encoded public key
+	PublicKey 	[]byte
+	// SignCount is the last sign counter reported by the authenticator
+	SignCount 	uint32
+}
+
+// Storage interface to handle WebAuthn credentials in the database
+type Storage interface {
+	// Get returns a credential by id
+	Get(id []byte) (c Credential, err error)
+
+	// GetByUser returns the credentials of a user
+	GetByUser(userId int) (c []Credential, err error)
+
+	// Set sets a credential
+	Set(c Credential) (err error)
+}
@@ -0,0 +1,61 @@
+package passkey
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// NewStorageLocal returns a new StorageLocal
+func NewStorageLocal() *StorageLocal {
+	return &StorageLocal{
+		db: make(map[string]Credential),
+		mu: &sync.RWMutex{},
+	}
+}
+
+// StorageLocal is an in-memory implementation of the Storage interface
+type StorageLocal struct {
+	// db is the map of credentials
+	// - key: hex encoded credential id
+	// - value: credential
+	db map[string]Credential
+	// mu is the mutex of the db
+	mu *sync.RWMutex
+}
+
+// Get returns a credential by id
+func (s *StorageLocal) Get(id []byte) (c Credential, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.db[hex.EncodeToString(id)]
+	if !ok {
+		err = fmt.Errorf("%w - %x", ErrStorageCredentialNotFound, id)
+		return
+	}
+
+	return
+}
+
+// GetByUser returns the credentials of a user
+func (s *StorageLocal) GetByUser(userId int) (c []Credential, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, v := range s.db {
+		if v.UserId == userId {
+			c = append(c, v)
+		}
+	}
+	return
+}
+
+// Set sets a credential
+func (s *StorageLocal) Set(c Credential) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.db[hex.EncodeToString(c.ID)] = c
+	return
+}
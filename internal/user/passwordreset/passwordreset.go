@@ -0,0 +1,23 @@
+package passwordreset
+
+import "errors"
+
+var (
+	// ErrPasswordResetInternal is returned when an internal error occurs
+	ErrPasswordResetInternal = errors.New("passwordreset: internal error")
+	// ErrPasswordResetTokenInvalid is returned when the reset token is invalid, expired or already used
+	ErrPasswordResetTokenInvalid = errors.New("passwordreset: invalid token")
+	// ErrPasswordResetPasswordInvalid is returned when the new password does not satisfy the validation rules
+	ErrPasswordResetPasswordInvalid = errors.New("passwordreset: invalid password")
+)
+
+// PasswordReset interface to handle the password recovery flow of users
+type PasswordReset interface {
+	// Request issues a reset token for the user of an email and sends it by email
+	// - in case of the email not being registered, it does nothing (it does not reveal which accounts exist)
+	Request(email string) (err error)
+
+	// Confirm consumes a reset token and sets the new password of its user
+	// - all the sessions of the user are revoked
+	Confirm(token string, password string) (err error)
+}
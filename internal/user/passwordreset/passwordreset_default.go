@@ -0,0 +1,195 @@
+package passwordreset
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/session/sessionauth"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/pkg/mailer"
+	"github.com/LNMMusic/optional"
+)
+
+// TemplateDefault is the default template of the password reset mails
+// - data: Link is the reset link
+var TemplateDefault = mailer.MustTemplate(
+	"Reset your password",
+	"Reset your password by following this link: {{.Link}}\nIf you did not request a password reset, ignore this email.",
+	`<p>Reset your password by following this <a href="{{.Link}}">link</a>.</p><p>If you did not request a password reset, ignore this email.</p>`,
+)
+
+// NewPasswordResetDefault returns a new PasswordResetDefault
+// - stWrite is expected to validate and encrypt the password (e.g. storage.StorageWriteValidation)
+// - ml should send in the background (e.g. mailer.MailerAsync), so registered emails do not take longer to respond
+func NewPasswordResetDefault(st Storage, stRead storage.StorageRead, stWrite storage.StorageWrite, ml mailer.Mailer, ss sessionauth.SessionAuthManager, config *Config) *PasswordResetDefault {
+	// default values
+	if !config.TTL.IsSome() {
+		config.TTL = optional.Some(time.Hour)
+	}
+	if config.Template == nil {
+		config.Template = TemplateDefault
+	}
+
+	return &PasswordResetDefault{
+		st: st,
+		stRead: stRead,
+		stWrite: stWrite,
+		ml: ml,
+		ss: ss,
+		config: config,
+		mu: &sync.Mutex{},
+	}
+}
+
+// Config is the configuration of PasswordResetDefault
+type Config struct {
+	// ConfirmURL is the url of the reset page, the token is appended as the "token" query param
+	ConfirmURL 	string
+	// TTL is the time to live of the reset tokens
+	TTL 		optional.Option[time.Duration]
+	// Template is the template of the reset mails (default TemplateDefault)
+	Template 	*mailer.Template
+}
+
+// PasswordResetDefault is the default implementation of the PasswordReset interface
+// - tokens are random, only their sha256 hash is stored
+type PasswordResetDefault struct {
+	// st is the storage of the reset tokens
+	st Storage
+	// stRead is the read interface for the user store
+	stRead storage.StorageRead
+	// stWrite is the write interface for the user store
+	stWrite storage.StorageWrite
+	// ml is the Mailer interface to send the reset mails
+	ml mailer.Mailer
+	// ss is the SessionAuthManager interface to revoke the sessions after a reset
+	ss sessionauth.SessionAuthManager
+	// config is the configuration of the password reset
+	config *Config
+	// mu serializes confirmations, so a token can only be used once
+	mu *sync.Mutex
+}
+
+// Request issues a reset token for the user of an email and sends it by email
+func (p *PasswordResetDefault) Request(email string) (err error) {
+	// get user
+	u, err := p.stRead.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, storage.ErrStorageNotFound) {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("%w. %s", ErrPasswordResetInternal, err.Error())
+		return
+	}
+
+	// token
+	b := make([]byte, 32)
+	_, err = rand.Read(b)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrPasswordResetInternal, err.Error())
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	ttl, _ := p.config.TTL.Unwrap()
+	err = p.st.Set(Token{
+		UserId: u.Id,
+		Hash: hash(token),
+		ExpireDate: time.Now().Add(ttl),
+	})
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrPasswordResetInternal, err.Error())
+		return
+	}
+
+	// link
+	link, err := url.Parse(p.config.ConfirmURL)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrPasswordResetInternal, err.Error())
+		return
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	// send mail
+	// - to the stored email, the typed one may be another spelling of it (e.g. case or provider rules)
+	to, _ := u.Email.Unwrap()
+	m, err := p.config.Template.Render([]string{to}, map[string]any{"Link": link.String()})
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrPasswordResetInternal, err.Error())
+		return
+	}
+	err = p.ml.Send(m)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrPasswordResetInternal, err.Error())
+		return
+	}
+
+	return
+}
+
+// Confirm consumes a reset token and sets the new password of its user
+func (p *PasswordResetDefault) Confirm(token string, password string) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// get token
+	t, err := p.st.GetByHash(hash(token))
+	if err != nil {
+		if errors.Is(err, ErrStorageTokenNotFound) {
+			err = fmt.Errorf("%w. %s", ErrPasswordResetTokenInvalid, err.Error())
+			return
+		}
+		err = fmt.Errorf("%w. %s", ErrPasswordResetInternal, err.Error())
+		return
+	}
+	if !time.Now().Before(t.ExpireDate) {
+		_ = p.st.Delete(t.UserId)
+		err = fmt.Errorf("%w. token expired", ErrPasswordResetTokenInvalid)
+		return
+	}
+
+	// update password
+	// - the plain password is validated and encrypted by the write storage
+	err = p.stWrite.UpdatePassword(t.UserId, password)
+	if err != nil {
+		if errors.Is(err, storage.ErrStorageInvalid) {
+			err = fmt.Errorf("%w. %s", ErrPasswordResetPasswordInvalid, err.Error())
+			return
+		}
+		err = fmt.Errorf("%w. %s", ErrPasswordResetInternal, err.Error())
+		return
+	}
+
+	// consume token
+	err = p.st.Delete(t.UserId)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrPasswordResetInternal, err.Error())
+		return
+	}
+
+	// revoke sessions
+	err = p.ss.RevokeSessions(strconv.Itoa(t.UserId))
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrPasswordResetInternal, err.Error())
+		return
+	}
+
+	return
+}
+
+// hash returns the sha256 hash of a token
+func hash(token string) (h string) {
+	sum := sha256.Sum256([]byte(token))
+	h = hex.EncodeToString(sum[:])
+	return
+}
@@ -0,0 +1,182 @@
+package passwordreset_test
+
+import (
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/session/sessionauth"
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/passwordreset"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/pkg/mailer"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenFromMail extracts the reset token from the link of a mail
+func tokenFromMail(t *testing.T, m *mailer.Mail) (token string) {
+	link := regexp.MustCompile(`https://\S+`).FindString(m.Text)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	token = u.Query().Get("token")
+	require.NotEmpty(t, token)
+	return
+}
+
+// Tests for PasswordResetDefault.Request
+func TestPasswordResetDefault_Request(t *testing.T) {
+	t.Run("success - reset mail sent", func(t *testing.T) {
+		// arrange
+		rd := storage.NewStorageReadMock()
+		rd.On("GetByEmail", "johndoe@gmail.com").Return(user.User{Id: 1, Email: optional.Some("johndoe@gmail.com")}, nil)
+		ml := mailer.NewMailerMemory()
+		st := passwordreset.NewStorageLocal()
+		pr := passwordreset.NewPasswordResetDefault(st, rd, nil, ml, nil, &passwordreset.Config{ConfirmURL: "https://app.example.com/reset"})
+
+		// act
+		err := pr.Request("johndoe@gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		require.Len(t, ml.Mails(), 1)
+		m := ml.Mails()[0]
+		require.Equal(t, []string{"johndoe@gmail.com"}, m.To)
+		require.Equal(t, "Reset your password", m.Subject)
+		token := tokenFromMail(t, m)
+		_, err = st.GetByHash(token)
+		require.ErrorIs(t, err, passwordreset.ErrStorageTokenNotFound, "the plain token must not be stored")
+		rd.AssertExpectations(t)
+	})
+
+	t.Run("success - reset mail sent to the stored email", func(t *testing.T) {
+		// arrange
+		rd := storage.NewStorageReadMock()
+		rd.On("GetByEmail", "John.Doe@Gmail.com").Return(user.User{Id: 1, Email: optional.Some("johndoe@gmail.com")}, nil)
+		ml := mailer.NewMailerMemory()
+		pr := passwordreset.NewPasswordResetDefault(passwordreset.NewStorageLocal(), rd, nil, ml, nil, &passwordreset.Config{ConfirmURL: "https://app.example.com/reset"})
+
+		// act
+		err := pr.Request("John.Doe@Gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		require.Len(t, ml.Mails(), 1)
+		require.Equal(t, []string{"johndoe@gmail.com"}, ml.Mails()[0].To)
+		rd.AssertExpectations(t)
+	})
+
+	t.Run("success - email not registered, nothing sent", func(t *testing.T) {
+		// arrange
+		rd := storage.NewStorageReadMock()
+		rd.On("GetByEmail", "unknown@gmail.com").Return(user.User{}, storage.ErrStorageNotFound)
+		ml := mailer.NewMailerMemory()
+		pr := passwordreset.NewPasswordResetDefault(passwordreset.NewStorageLocal(), rd, nil, ml, nil, &passwordreset.Config{})
+
+		// act
+		err := pr.Request("unknown@gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		require.Empty(t, ml.Mails())
+		rd.AssertExpectations(t)
+	})
+}
+
+// Tests for PasswordResetDefault.Confirm
+func TestPasswordResetDefault_Confirm(t *testing.T) {
+	// arrange: issues a token through Request and returns it
+	type deps struct {
+		rd *storage.StorageReadMock
+		wr *storage.StorageWriteMock
+		ss *sessionauth.SessionAuthMock
+		pr *passwordreset.PasswordResetDefault
+	}
+	setUp := func(t *testing.T, ttl time.Duration) (d deps, token string) {
+		d.rd = storage.NewStorageReadMock()
+		d.rd.On("GetByEmail", "johndoe@gmail.com").Return(user.User{Id: 1, Email: optional.Some("johndoe@gmail.com")}, nil)
+		d.wr = storage.NewStorageWriteMock()
+		d.ss = sessionauth.NewSessionAuthMock()
+		ml := mailer.NewMailerMemory()
+		d.pr = passwordreset.NewPasswordResetDefault(passwordreset.NewStorageLocal(), d.rd, d.wr, ml, d.ss, &passwordreset.Config{
+			ConfirmURL: "https://app.example.com/reset",
+			TTL: optional.Some(ttl),
+		})
+		require.NoError(t, d.pr.Request("johndoe@gmail.com"))
+		token = tokenFromMail(t, ml.Mails()[0])
+		return
+	}
+
+	t.Run("success - password updated and sessions revoked", func(t *testing.T) {
+		// arrange
+		d, token := setUp(t, time.Hour)
+		d.wr.On("UpdatePassword", 1, "new-password").Return(nil)
+		d.ss.On("RevokeSessions", "1").Return(nil)
+
+		// act
+		err := d.pr.Confirm(token, "new-password")
+
+		// assert
+		require.NoError(t, err)
+		d.rd.AssertExpectations(t)
+		d.wr.AssertExpectations(t)
+		d.ss.AssertExpectations(t)
+	})
+
+	t.Run("failure - token already used", func(t *testing.T) {
+		// arrange
+		d, token := setUp(t, time.Hour)
+		d.wr.On("UpdatePassword", 1, "new-password").Return(nil).Once()
+		d.ss.On("RevokeSessions", "1").Return(nil).Once()
+		require.NoError(t, d.pr.Confirm(token, "new-password"))
+
+		// act
+		err := d.pr.Confirm(token, "other-password")
+
+		// assert
+		require.ErrorIs(t, err, passwordreset.ErrPasswordResetTokenInvalid)
+		d.wr.AssertExpectations(t)
+	})
+
+	t.Run("failure - token expired", func(t *testing.T) {
+		// arrange
+		d, token := setUp(t, -time.Second)
+
+		// act
+		err := d.pr.Confirm(token, "new-password")
+
+		// assert
+		require.ErrorIs(t, err, passwordreset.ErrPasswordResetTokenInvalid)
+		require.EqualError(t, err, "passwordreset: invalid token. token expired")
+	})
+
+	t.Run("failure - unknown token", func(t *testing.T) {
+		// arrange
+		d, _ := setUp(t, time.Hour)
+
+		// act
+		err := d.pr.Confirm("unknown", "new-password")
+
+		// assert
+		require.ErrorIs(t, err, passwordreset.ErrPasswordResetTokenInvalid)
+	})
+
+	t.Run("failure - invalid password keeps the token", func(t *testing.T) {
+		// arrange
+		d, token := setUp(t, time.Hour)
+		d.wr.On("UpdatePassword", 1, "short").Return(storage.ErrStorageInvalid).Once()
+		d.wr.On("UpdatePassword", 1, "new-password").Return(nil).Once()
+		d.ss.On("RevokeSessions", "1").Return(nil).Once()
+
+		// act
+		err := d.pr.Confirm(token, "short")
+		errRetry := d.pr.Confirm(token, "new-password")
+
+		// assert
+		require.ErrorIs(t, err, passwordreset.ErrPasswordResetPasswordInvalid)
+		require.NoError(t, errRetry)
+		d.wr.AssertExpectations(t)
+		d.ss.AssertExpectations(t)
+	})
+}
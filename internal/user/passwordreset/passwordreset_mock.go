@@ -0,0 +1,28 @@
+package passwordreset
+
+import "github.com/stretchr/testify/mock"
+
+// NewPasswordResetMock returns a new mock password reset
+func NewPasswordResetMock() *PasswordResetMock {
+	return &PasswordResetMock{}
+}
+
+// PasswordResetMock is a mock implementation of the PasswordReset interface
+type PasswordResetMock struct {
+	// mock.Mock is a struct that implements the Mock struct for testing purposes
+	mock.Mock
+}
+
+// Request issues a reset token for the user of an email and sends it by email
+func (m *PasswordResetMock) Request(email string) (err error) {
+	args := m.Called(email)
+	err = args.Error(0)
+	return
+}
+
+// Confirm consumes a reset token and sets the new password of its user
+func (m *PasswordResetMock) Confirm(token string, password string) (err error) {
+	args := m.Called(token, password)
+	err = args.Error(0)
+	return
+}
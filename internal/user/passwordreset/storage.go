@@ -0,0 +1,35 @@
+package passwordreset
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrStorageTokenNotFound is returned when a reset token is not found
+	ErrStorageTokenNotFound = errors.New("passwordreset: storage token not found")
+)
+
+// Token is a reset token of a user
+// - only the hash of the token is stored
+type Token struct {
+	// UserId is the id of the user
+	UserId 		int
+	// Hash is the sha256 hash of the token
+	Hash 		string
+	// ExpireDate is the expire date of the token
+	ExpireDate 	time.Time
+}
+
+// Storage interface to handle reset tokens in the database
+// - there is at most one token per user
+type Storage interface {
+	// Set sets the token of a user, replacing the previous one
+	Set(t Token) (err error)
+
+	// GetByHash returns the token with the given hash
+	GetByHash(hash string) (t Token, err error)
+
+	// Delete deletes the token of a user
+	Delete(userId int) (err error)
+}
@@ -0,0 +1,68 @@
+package passwordreset
+
+import (
+	"fmt"
+	"sync"
+)
+
+// NewStorageLocal returns a new StorageLocal
+func NewStorageLocal() *StorageLocal {
+	return &StorageLocal{
+		db: make(map[string]Token),
+		users: make(map[int]string),
+		mu: &sync.RWMutex{},
+	}
+}
+
+// StorageLocal is an in-memory implementation of the Storage interface
+type StorageLocal struct {
+	// db is the map of tokens
+	// - key: token hash
+	// - value: token
+	db map[string]Token
+	// users is the index of tokens by user
+	// - key: user id
+	// - value: token hash
+	users map[int]string
+	// mu is the mutex of the db
+	mu *sync.RWMutex
+}
+
+// Set sets the token of a user, replacing the previous one
+func (s *StorageLocal) Set(t Token) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if hash, ok := s.users[t.UserId]; ok {
+		delete(s.db, hash)
+	}
+	s.db[t.Hash] = t
+	s.users[t.UserId] = t.Hash
+	return
+}
+
+// GetByHash returns the token with the given hash
+func (s *StorageLocal) GetByHash(hash string) (t Token, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.db[hash]
+	if !ok {
+		err = fmt.Errorf("%w - %s", ErrStorageTokenNotFound, hash)
+		return
+	}
+
+	return
+}
+
+// Delete deletes the token of a user
+func (s *StorageLocal) Delete(userId int) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if hash, ok := s.users[userId]; ok {
+		delete(s.db, hash)
+		delete(s.users, userId)
+	}
+	return
+}
@@ -0,0 +1,25 @@
+package recovery
+
+import "errors"
+
+var (
+	// ErrRecoveryInternal is returned when an internal error occurs
+	ErrRecoveryInternal = errors.New("recovery: internal error")
+	// ErrRecoveryCodeInvalid is returned when a code is wrong or already used
+	ErrRecoveryCodeInvalid = errors.New("recovery: invalid code")
+	// ErrRecoveryConfig is returned when the configuration is invalid
+	ErrRecoveryConfig = errors.New("recovery: invalid config")
+)
+
+// RecoveryCodes interface to handle the one-time recovery codes of the two factor accounts
+// - they are the fallback of users who lost access to their authenticator app
+type RecoveryCodes interface {
+	// Generate generates a new batch of codes for a user, invalidating the previous one
+	Generate(userId int) (codes []string, err error)
+
+	// Use consumes a code of a user
+	Use(userId int, code string) (err error)
+
+	// Remaining returns the number of unused codes of a user
+	Remaining(userId int) (n int, err error)
+}
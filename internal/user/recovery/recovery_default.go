@@ -0,0 +1,216 @@
+package recovery
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/LNMMusic/msauth/pkg/crypter"
+	"github.com/LNMMusic/optional"
+)
+
+// encoding is the encoding of the codes
+var encoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// MinSecretLength is the minimum length in bytes of the secret key of the lookup prefixes
+const MinSecretLength = 32
+
+// prefixLength is the length in bytes of the lookup prefixes
+// - short enough to not help an offline attack in case both the secret and the hashes leak
+const prefixLength = 2
+
+// NewRecoveryCodesDefault returns a new RecoveryCodesDefault
+// - the secret must be at least MinSecretLength bytes long
+func NewRecoveryCodesDefault(st Storage, cr crypter.Crypter, config *Config) (r *RecoveryCodesDefault, err error) {
+	// validate config
+	if len(config.Secret) < MinSecretLength {
+		err = fmt.Errorf("%w. secret should be at least %d bytes", ErrRecoveryConfig, MinSecretLength)
+		return
+	}
+
+	// default values
+	if !config.Count.IsSome() {
+		config.Count = optional.Some(10)
+	}
+
+	r = &RecoveryCodesDefault{
+		st: st,
+		cr: cr,
+		config: config,
+		mu: &sync.Mutex{},
+		users: make(map[int]*userLock),
+	}
+	return
+}
+
+// Config is the configuration of RecoveryCodesDefault
+type Config struct {
+	// Count is the number of codes of a batch (default 10)
+	Count 	optional.Option[int]
+	// Secret is the secret key of the lookup prefixes of the codes
+	// - it must not change, otherwise the stored codes can not be used
+	Secret 	[]byte
+}
+
+// RecoveryCodesDefault is the default implementation of the RecoveryCodes interface
+// - codes have 50 random bits, formatted as "xxxxx-xxxxx"
+// - codes are hashed with the crypter, input is normalized (case, dashes and spaces are ignored)
+// - hashes are stored with a keyed prefix of their code, so a code is only compared against the hashes of its prefix
+// and an attempt costs a single comparison instead of one per code
+type RecoveryCodesDefault struct {
+	// st is the storage of the codes
+	st Storage
+	// cr is the crypter to hash the codes
+	cr crypter.Crypter
+	// config is the configuration of the codes
+	config *Config
+	// mu is the mutex of users
+	mu *sync.Mutex
+	// users are the locks of the users with operations in progress
+	// - operations of a user are serialized, so a code can only be used once
+	// - operations of different users run in parallel, comparing the codes is costly
+	users map[int]*userLock
+}
+
+// userLock is the lock of a user
+type userLock struct {
+	// mu serializes the operations of the user
+	mu sync.Mutex
+	// refs is the number of operations holding or waiting for the lock
+	refs int
+}
+
+// lock locks the operations of a user and returns the function to unlock them
+func (r *RecoveryCodesDefault) lock(userId int) (unlock func()) {
+	r.mu.Lock()
+	l, ok := r.users[userId]
+	if !ok {
+		l = &userLock{}
+		r.users[userId] = l
+	}
+	l.refs++
+	r.mu.Unlock()
+
+	l.mu.Lock()
+	unlock = func() {
+		l.mu.Unlock()
+
+		r.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(r.users, userId)
+		}
+		r.mu.Unlock()
+	}
+	return
+}
+
+// Generate generates a new batch of codes for a user, invalidating the previous one
+func (r *RecoveryCodesDefault) Generate(userId int) (codes []string, err error) {
+	count, _ := r.config.Count.Unwrap()
+	generated := make([]string, 0, count)
+	hashes := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		b := make([]byte, 7)
+		_, err = rand.Read(b)
+		if err != nil {
+			err = fmt.Errorf("%w. %s", ErrRecoveryInternal, err.Error())
+			return
+		}
+		code := encoding.EncodeToString(b)[:10]
+
+		var hash string
+		hash, err = r.cr.Encrypt(code)
+		if err != nil {
+			err = fmt.Errorf("%w. %s", ErrRecoveryInternal, err.Error())
+			return
+		}
+		generated = append(generated, code[:5]+"-"+code[5:])
+		hashes = append(hashes, r.prefix(code)+":"+hash)
+	}
+
+	unlock := r.lock(userId)
+	defer unlock()
+	err = r.st.Set(userId, hashes)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrRecoveryInternal, err.Error())
+		return
+	}
+
+	codes = generated
+	return
+}
+
+// Use consumes a code of a user
+func (r *RecoveryCodesDefault) Use(userId int, code string) (err error) {
+	unlock := r.lock(userId)
+	defer unlock()
+
+	hashes, err := r.st.Get(userId)
+	if err != nil {
+		if errors.Is(err, ErrStorageCodesNotFound) {
+			err = fmt.Errorf("%w. %s", ErrRecoveryCodeInvalid, err.Error())
+			return
+		}
+		err = fmt.Errorf("%w. %s", ErrRecoveryInternal, err.Error())
+		return
+	}
+
+	code = normalize(code)
+	prefix := r.prefix(code)
+	for i, entry := range hashes {
+		p, hash, _ := strings.Cut(entry, ":")
+		if p != prefix || r.cr.Compare(hash, code) != nil {
+			continue
+		}
+
+		// consume
+		hashes = append(hashes[:i], hashes[i+1:]...)
+		err = r.st.Set(userId, hashes)
+		if err != nil {
+			err = fmt.Errorf("%w. %s", ErrRecoveryInternal, err.Error())
+			return
+		}
+		return
+	}
+
+	err = ErrRecoveryCodeInvalid
+	return
+}
+
+// Remaining returns the number of unused codes of a user
+func (r *RecoveryCodesDefault) Remaining(userId int) (n int, err error) {
+	hashes, err := r.st.Get(userId)
+	if err != nil {
+		if errors.Is(err, ErrStorageCodesNotFound) {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("%w. %s", ErrRecoveryInternal, err.Error())
+		return
+	}
+
+	n = len(hashes)
+	return
+}
+
+// prefix returns the lookup prefix of a normalized code
+func (r *RecoveryCodesDefault) prefix(code string) (prefix string) {
+	mac := hmac.New(sha256.New, r.config.Secret)
+	mac.Write([]byte(code))
+	prefix = hex.EncodeToString(mac.Sum(nil)[:prefixLength])
+	return
+}
+
+// normalize returns a code as generated, without the dash
+func normalize(code string) (normalized string) {
+	normalized = strings.ToLower(code)
+	normalized = strings.NewReplacer("-", "", " ", "").Replace(normalized)
+	return
+}
@@ -0,0 +1,237 @@
+package recovery_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/internal/user/recovery"
+	"github.com/LNMMusic/msauth/pkg/crypter"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// secret is the secret key of the lookup prefixes of the tests
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+// newRecoveryCodes returns a RecoveryCodesDefault with a cheap crypter
+func newRecoveryCodes(t *testing.T, count int) (rc *recovery.RecoveryCodesDefault, st *recovery.StorageLocal) {
+	st = recovery.NewStorageLocal()
+	rc, err := recovery.NewRecoveryCodesDefault(st, crypter.NewCrypterDefault(4), &recovery.Config{Count: optional.Some(count), Secret: secret})
+	require.NoError(t, err)
+	return
+}
+
+// Tests for NewRecoveryCodesDefault
+func TestNewRecoveryCodesDefault(t *testing.T) {
+	t.Run("failure - short secret", func(t *testing.T) {
+		// act
+		rc, err := recovery.NewRecoveryCodesDefault(recovery.NewStorageLocal(), crypter.NewCrypterDefault(4), &recovery.Config{Secret: []byte("short")})
+
+		// assert
+		require.Nil(t, rc)
+		require.ErrorIs(t, err, recovery.ErrRecoveryConfig)
+		require.EqualError(t, err, "recovery: invalid config. secret should be at least 32 bytes")
+	})
+
+	t.Run("failure - missing secret", func(t *testing.T) {
+		// act
+		rc, err := recovery.NewRecoveryCodesDefault(recovery.NewStorageLocal(), crypter.NewCrypterDefault(4), &recovery.Config{})
+
+		// assert
+		require.Nil(t, rc)
+		require.ErrorIs(t, err, recovery.ErrRecoveryConfig)
+	})
+}
+
+// Tests for RecoveryCodesDefault
+func TestRecoveryCodesDefault(t *testing.T) {
+	t.Run("success - generate a batch stored hashed", func(t *testing.T) {
+		// arrange
+		rc, st := newRecoveryCodes(t, 3)
+
+		// act
+		codes, err := rc.Generate(1)
+		n, errRemaining := rc.Remaining(1)
+
+		// assert
+		require.NoError(t, err)
+		require.Len(t, codes, 3)
+		for _, c := range codes {
+			require.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, c)
+		}
+		require.NoError(t, errRemaining)
+		require.Equal(t, 3, n)
+		hashes, err := st.Get(1)
+		require.NoError(t, err)
+		for _, h := range hashes {
+			for _, c := range codes {
+				require.NotContains(t, h, strings.ReplaceAll(c, "-", ""))
+			}
+		}
+	})
+
+	t.Run("success - use a code once", func(t *testing.T) {
+		// arrange
+		rc, _ := newRecoveryCodes(t, 3)
+		codes, err := rc.Generate(1)
+		require.NoError(t, err)
+
+		// act
+		err = rc.Use(1, codes[1])
+		errReplay := rc.Use(1, codes[1])
+		n, errRemaining := rc.Remaining(1)
+
+		// assert
+		require.NoError(t, err)
+		require.ErrorIs(t, errReplay, recovery.ErrRecoveryCodeInvalid)
+		require.NoError(t, errRemaining)
+		require.Equal(t, 2, n)
+	})
+
+	t.Run("success - input is normalized", func(t *testing.T) {
+		// arrange
+		rc, _ := newRecoveryCodes(t, 1)
+		codes, err := rc.Generate(1)
+		require.NoError(t, err)
+
+		// act
+		err = rc.Use(1, " "+strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))+" ")
+
+		// assert
+		require.NoError(t, err)
+	})
+
+	t.Run("success - regenerate invalidates the previous batch", func(t *testing.T) {
+		// arrange
+		rc, _ := newRecoveryCodes(t, 2)
+		old, err := rc.Generate(1)
+		require.NoError(t, err)
+		codes, err := rc.Generate(1)
+		require.NoError(t, err)
+
+		// act
+		errOld := rc.Use(1, old[0])
+		errNew := rc.Use(1, codes[0])
+
+		// assert
+		require.ErrorIs(t, errOld, recovery.ErrRecoveryCodeInvalid)
+		require.NoError(t, errNew)
+	})
+
+	t.Run("success - a code is compared only against the hashes of its prefix", func(t *testing.T) {
+		// arrange
+		st := recovery.NewStorageLocal()
+		cr := crypter.NewCrypterMock()
+		for i := 0; i < 10; i++ {
+			cr.On("Encrypt", mock.Anything).Return(fmt.Sprintf("hash-%d", i), nil).Once()
+		}
+		rc, err := recovery.NewRecoveryCodesDefault(st, cr, &recovery.Config{Secret: secret})
+		require.NoError(t, err)
+		codes, err := rc.Generate(1)
+		require.NoError(t, err)
+		cr.On("Compare", "hash-7", strings.ReplaceAll(codes[7], "-", "")).Return(nil).Once()
+		// - another code may share the prefix, with a chance of 1 in 2^16 per code
+		cr.On("Compare", mock.Anything, mock.Anything).Return(crypter.ErrCrypterComparison).Maybe()
+
+		// act
+		err = rc.Use(1, codes[7])
+
+		// assert
+		require.NoError(t, err)
+		cr.AssertExpectations(t)
+		compares := 0
+		for _, c := range cr.Calls {
+			if c.Method == "Compare" {
+				compares++
+			}
+		}
+		require.Less(t, compares, len(codes))
+	})
+
+	t.Run("failure - user without codes", func(t *testing.T) {
+		// arrange
+		rc, _ := newRecoveryCodes(t, 2)
+
+		// act
+		err := rc.Use(1, "abcde-fghij")
+		n, errRemaining := rc.Remaining(1)
+
+		// assert
+		require.ErrorIs(t, err, recovery.ErrRecoveryCodeInvalid)
+		require.NoError(t, errRemaining)
+		require.Zero(t, n)
+	})
+}
+
+// Tests for RecoveryCodesDefault concurrency
+func TestRecoveryCodesDefault_Concurrent(t *testing.T) {
+	t.Run("success - a code used concurrently is consumed once", func(t *testing.T) {
+		// arrange
+		rc, _ := newRecoveryCodes(t, 1)
+		codes, err := rc.Generate(1)
+		require.NoError(t, err)
+
+		// act
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- rc.Use(1, codes[0])
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		// assert
+		var ok int
+		for err := range errs {
+			if err == nil {
+				ok++
+				continue
+			}
+			require.ErrorIs(t, err, recovery.ErrRecoveryCodeInvalid)
+		}
+		require.Equal(t, 1, ok)
+	})
+
+	t.Run("success - users do not wait for each other", func(t *testing.T) {
+		// arrange
+		st := recovery.NewStorageLocal()
+		cr := crypter.NewCrypterMock()
+		cr.On("Encrypt", mock.Anything).Return("hash-1", nil).Once()
+		cr.On("Encrypt", mock.Anything).Return("hash-2", nil).Once()
+		rc, err := recovery.NewRecoveryCodesDefault(st, cr, &recovery.Config{Count: optional.Some(1), Secret: secret})
+		require.NoError(t, err)
+		codes1, err := rc.Generate(1)
+		require.NoError(t, err)
+		codes2, err := rc.Generate(2)
+		require.NoError(t, err)
+		entered, release := make(chan struct{}), make(chan struct{})
+		cr.On("Compare", "hash-1", strings.ReplaceAll(codes1[0], "-", "")).Run(func(mock.Arguments) { close(entered); <-release }).Return(nil)
+		cr.On("Compare", "hash-2", strings.ReplaceAll(codes2[0], "-", "")).Return(nil)
+		errSlow := make(chan error, 1)
+		go func() { errSlow <- rc.Use(1, codes1[0]) }()
+		<-entered
+
+		// act
+		errFast := make(chan error, 1)
+		go func() { errFast <- rc.Use(2, codes2[0]) }()
+
+		// assert
+		select {
+		case err := <-errFast:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("the user waited for the operation of another user")
+		}
+		close(release)
+		require.NoError(t, <-errSlow)
+		cr.AssertExpectations(t)
+	})
+}
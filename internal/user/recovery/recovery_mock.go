@@ -0,0 +1,37 @@
+package recovery
+
+import "github.com/stretchr/testify/mock"
+
+// NewRecoveryCodesMock returns a new mock recovery codes
+func NewRecoveryCodesMock() *RecoveryCodesMock {
+	return &RecoveryCodesMock{}
+}
+
+// RecoveryCodesMock is a mock implementation of the RecoveryCodes interface
+type RecoveryCodesMock struct {
+	// mock.Mock is a struct that implements the Mock struct for testing purposes
+	mock.Mock
+}
+
+// Generate generates a new batch of codes for a user, invalidating the previous one
+func (m *RecoveryCodesMock) Generate(userId int) (codes []string, err error) {
+	args := m.Called(userId)
+	codes, _ = args.Get(0).([]string)
+	err = args.Error(1)
+	return
+}
+
+// Use consumes a code of a user
+func (m *RecoveryCodesMock) Use(userId int, code string) (err error) {
+	args := m.Called(userId, code)
+	err = args.Error(0)
+	return
+}
+
+// Remaining returns the number of unused codes of a user
+func (m *RecoveryCodesMock) Remaining(userId int) (n int, err error) {
+	args := m.Called(userId)
+	n = args.Int(0)
+	err = args.Error(1)
+	return
+}
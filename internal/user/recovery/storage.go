@@ -0,0 +1,18 @@
+package recovery
+
+import "errors"
+
+var (
+	// ErrStorageCodesNotFound is returned when a user has no codes
+	ErrStorageCodesNotFound = errors.New("recovery: storage codes not found")
+)
+
+// Storage interface to handle recovery codes in the database
+// - only the hashes of the codes are stored, each with the lookup prefix of its code ("prefix:hash")
+type Storage interface {
+	// Get returns the hashes of the unused codes of a user
+	Get(userId int) (hashes []string, err error)
+
+	// Set sets the hashes of the unused codes of a user, replacing the previous ones
+	Set(userId int, hashes []string) (err error)
+}
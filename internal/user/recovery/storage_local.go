@@ -0,0 +1,48 @@
+package recovery
+
+import (
+	"fmt"
+	"sync"
+)
+
+// NewStorageLocal returns a new StorageLocal
+func NewStorageLocal() *StorageLocal {
+	return &StorageLocal{
+		db: make(map[int][]string),
+		mu: &sync.RWMutex{},
+	}
+}
+
+// StorageLocal is an in-memory implementation of the Storage interface
+type StorageLocal struct {
+	// db is the map of codes
+	// - key: user id
+	// - value: hashes of the unused codes
+	db map[int][]string
+	// mu is the mutex of the db
+	mu *sync.RWMutex
+}
+
+// Get returns the hashes of the unused codes of a user
+func (s *StorageLocal) Get(userId int) (hashes []string, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.db[userId]
+	if !ok {
+		err = fmt.Errorf("%w - %d", ErrStorageCodesNotFound, userId)
+		return
+	}
+
+	hashes = append([]string(nil), stored...)
+	return
+}
+
+// Set sets the hashes of the unused codes of a user, replacing the previous ones
+func (s *StorageLocal) Set(userId int, hashes []string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.db[userId] = append([]string(nil), hashes...)
+	return
+}
@@ -0,0 +1,23 @@
+package storage
+
+// NewRepositoryMap returns a new RepositoryMap
+// - in case of a nil store, a new empty one is used
+func NewRepositoryMap(st *StoreMap) *RepositoryMap {
+	// default config
+	defaultSt := st
+	if defaultSt == nil {
+		defaultSt = NewStoreMap()
+	}
+
+	return &RepositoryMap{
+		StorageReadMap: NewStorageReadMap(defaultSt),
+		StorageWriteMap: NewStorageWriteMap(defaultSt),
+	}
+}
+
+// RepositoryMap is the implementation of the Repository interface in map format
+// - the read and write sides share one StoreMap, so they can not disagree on how users are stored
+type RepositoryMap struct {
+	*StorageReadMap
+	*StorageWriteMap
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"errors"
 
 	"github.com/LNMMusic/msauth/internal/user"
@@ -15,6 +16,10 @@ var (
 	ErrStorageInvalid  = errors.New("storage: invalid user")
 	// ErrStorageEncrypt is returned when a user password cannot be encrypted
 	ErrStorageEncrypt  = errors.New("storage: cannot encrypt password")
+	// ErrStorageConflict is returned when a user is updated with a version other than the stored one
+	ErrStorageConflict = errors.New("storage: user version conflict")
+	// ErrStorageInternal is returned when an internal error occurs (e.g. the context is done)
+	ErrStorageInternal = errors.New("storage: internal error")
 )
 
 // StorageRead interface for users to handle user read operations in the database
@@ -32,9 +37,61 @@ type StorageWrite interface {
 	// Create a new user
 	Create(u *user.User) (err error)
 	// Update an existing user
+	// - the version of the user must be the stored one, it is incremented on success
 	Update(u *user.User) (err error)
+	// Patch applies the fields that are some of a partial user to an existing user
+	// - the version of the user must be the stored one, it is incremented on success
+	// - on success, u is set to the patched user
+	Patch(u *user.User) (err error)
 	// Delete an existing user
 	Delete(id int) (err error)
 	// Activate an existing user
 	Activate(id int) (err error)
-}
\ No newline at end of file
+	// UpdatePassword updates the password of an existing user
+	UpdatePassword(id int, password string) (err error)
+}
+
+// Repository interface for users to handle both read and write operations over the same data
+// - users written are readable right after, with the same values
+type Repository interface {
+	StorageRead
+	StorageWrite
+}
+
+// StorageReadContext interface for user read operations aware of a context
+// - operations are not started, or are abandoned, once the context is done
+type StorageReadContext interface {
+	StorageRead
+
+	// Get a user by id
+	GetContext(ctx context.Context, id int) (u user.User, err error)
+	// Get a user by email
+	GetByEmailContext(ctx context.Context, email string) (u user.User, err error)
+	// Get a user by username
+	GetByUsernameContext(ctx context.Context, username string) (u user.User, err error)
+}
+
+// StorageWriteContext interface for user write operations aware of a context
+// - operations are not started, or are abandoned, once the context is done
+type StorageWriteContext interface {
+	StorageWrite
+
+	// Create a new user
+	CreateContext(ctx context.Context, u *user.User) (err error)
+	// Update an existing user
+	UpdateContext(ctx context.Context, u *user.User) (err error)
+	// Patch applies the fields that are some of a partial user to an existing user
+	PatchContext(ctx context.Context, u *user.User) (err error)
+	// Delete an existing user
+	DeleteContext(ctx context.Context, id int) (err error)
+	// Activate an existing user
+	ActivateContext(ctx context.Context, id int) (err error)
+	// UpdatePassword updates the password of an existing user
+	UpdatePasswordContext(ctx context.Context, id int, password string) (err error)
+}
+
+// RepositoryContext interface for users to handle both read and write operations aware of a context
+type RepositoryContext interface {
+	StorageReadContext
+	StorageWriteContext
+}
@@ -0,0 +1,39 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/storage/storagetest"
+	"github.com/LNMMusic/msauth/internal/user/validator"
+)
+
+// Conformance tests for the map storages
+func TestStorageMap_Conformance(t *testing.T) {
+	t.Run("StorageReadMap", func(t *testing.T) {
+		storagetest.RunStorageRead(t, func(t *testing.T, users ...user.User) storage.StorageRead {
+			return storage.NewStorageReadMap(storage.NewStoreMap(users...))
+		})
+	})
+
+	t.Run("StorageReadNormalized", func(t *testing.T) {
+		storagetest.RunStorageRead(t, func(t *testing.T, users ...user.User) storage.StorageRead {
+			return storage.NewStorageReadNormalized(storage.NewStorageReadMap(storage.NewStoreMap(users...)), validator.NewNormalizerDefault(nil))
+		})
+	})
+
+	t.Run("StorageWriteMap", func(t *testing.T) {
+		storagetest.RunStorageWrite(t, func(t *testing.T) (storage.StorageWrite, storage.StorageRead) {
+			db := storage.NewStoreMap()
+			return storage.NewStorageWriteMap(db), storage.NewStorageReadMap(db)
+		})
+	})
+
+	t.Run("RepositoryMap", func(t *testing.T) {
+		storagetest.RunStorageWrite(t, func(t *testing.T) (storage.StorageWrite, storage.StorageRead) {
+			rp := storage.NewRepositoryMap(nil)
+			return rp, rp
+		})
+	})
+}
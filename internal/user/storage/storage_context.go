@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LNMMusic/msauth/internal/user"
+)
+
+// StorageReadWithContext returns a StorageReadContext of a StorageRead
+// - storages that are already aware of a context are returned as they are
+// - otherwise, the context is only checked before each operation, once done the error wraps ErrStorageInternal and the error of the context
+func StorageReadWithContext(st StorageRead) (s StorageReadContext) {
+	s, ok := st.(StorageReadContext)
+	if !ok {
+		s = &storageReadContext{st}
+	}
+	return
+}
+
+// StorageWriteWithContext returns a StorageWriteContext of a StorageWrite
+// - storages that are already aware of a context are returned as they are
+// - otherwise, the context is only checked before each operation, once done the error wraps ErrStorageInternal and the error of the context
+func StorageWriteWithContext(st StorageWrite) (s StorageWriteContext) {
+	s, ok := st.(StorageWriteContext)
+	if !ok {
+		s = &storageWriteContext{st}
+	}
+	return
+}
+
+// storageReadContext is the adapter of a StorageRead to the StorageReadContext interface
+type storageReadContext struct {
+	StorageRead
+}
+
+// Get a user by id
+func (s *storageReadContext) GetContext(ctx context.Context, id int) (u user.User, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	u, err = s.Get(id)
+	return
+}
+
+// Get a user by email
+func (s *storageReadContext) GetByEmailContext(ctx context.Context, email string) (u user.User, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	u, err = s.GetByEmail(email)
+	return
+}
+
+// Get a user by username
+func (s *storageReadContext) GetByUsernameContext(ctx context.Context, username string) (u user.User, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	u, err = s.GetByUsername(username)
+	return
+}
+
+// storageWriteContext is the adapter of a StorageWrite to the StorageWriteContext interface
+type storageWriteContext struct {
+	StorageWrite
+}
+
+// Create a new user
+func (s *storageWriteContext) CreateContext(ctx context.Context, u *user.User) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = s.Create(u)
+	return
+}
+
+// Update an existing user
+func (s *storageWriteContext) UpdateContext(ctx context.Context, u *user.User) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = s.Update(u)
+	return
+}
+
+// Patch applies the fields that are some of a partial user to an existing user
+func (s *storageWriteContext) PatchContext(ctx context.Context, u *user.User) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = s.Patch(u)
+	return
+}
+
+// Delete an existing user
+func (s *storageWriteContext) DeleteContext(ctx context.Context, id int) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = s.Delete(id)
+	return
+}
+
+// Activate an existing user
+func (s *storageWriteContext) ActivateContext(ctx context.Context, id int) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = s.Activate(id)
+	return
+}
+
+// UpdatePassword updates the password of an existing user
+func (s *storageWriteContext) UpdatePasswordContext(ctx context.Context, id int, password string) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = s.UpdatePassword(id, password)
+	return
+}
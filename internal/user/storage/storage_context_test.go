@@ -0,0 +1,36 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for StorageReadWithContext and StorageWriteWithContext
+func TestStorageWithContext(t *testing.T) {
+	t.Run("failure - done context is wrapped as an internal error", func(t *testing.T) {
+		// arrange
+		rd := storage.NewStorageReadMock()
+		wr := storage.NewStorageWriteMock()
+		// - the mocks are context aware, so they are hidden behind the plain interfaces
+		stRead := storage.StorageReadWithContext(struct{ storage.StorageRead }{rd})
+		stWrite := storage.StorageWriteWithContext(struct{ storage.StorageWrite }{wr})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// act
+		_, errGet := stRead.GetContext(ctx, 1)
+		errCreate := stWrite.CreateContext(ctx, &user.User{})
+
+		// assert
+		require.ErrorIs(t, errGet, storage.ErrStorageInternal)
+		require.ErrorIs(t, errGet, context.Canceled)
+		require.ErrorIs(t, errCreate, storage.ErrStorageInternal)
+		require.ErrorIs(t, errCreate, context.Canceled)
+		rd.AssertExpectations(t)
+		wr.AssertExpectations(t)
+	})
+}
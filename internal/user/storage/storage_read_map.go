@@ -1,112 +1,76 @@
 package storage
 
 import (
+	"context"
 	"fmt"
-	"sync"
 
 	"github.com/LNMMusic/msauth/internal/user"
 )
 
 // NewStorageReadMap returns a new map storage
-func NewStorageReadMap(db *sync.Map) *StorageReadMap {
+// - in case of a nil store, a new empty one is used
+func NewStorageReadMap(st *StoreMap) *StorageReadMap {
 	// default config
-	defaultDb := &sync.Map{}
-	if db != nil {
-		defaultDb = db
+	defaultSt := st
+	if defaultSt == nil {
+		defaultSt = NewStoreMap()
 	}
 
-	return &StorageReadMap{defaultDb}
+	return &StorageReadMap{defaultSt}
 }
 
 // StorageReadMap is the implementation of the Storage interface
 type StorageReadMap struct {
-	// db is a map of users
-	// - key: user id
-	// - value: user
-	db *sync.Map
+	// st is the store of the users, shared with the write side
+	st *StoreMap
 }
 
 // Get a user by id
 func (m *StorageReadMap) Get(id int) (u user.User, err error) {
-	// get user from db
-	value, ok := m.db.Load(id)
-	if !ok {
-		err = fmt.Errorf("%w - %d", ErrStorageNotFound, id)
-		return
-	}
-
-	// type assertion
-	u = value.(user.User)
-
+	u, err = m.st.get(id)
 	return
 }
 
 // Get a user by email
+// - the email is compared with the canonical email of the users, if set
 func (m *StorageReadMap) GetByEmail(email string) (u user.User, err error) {
-	// loop over users
-	var exists bool; var key int
-	m.db.Range(func(k, v any) bool {
-		// check if email matches
-		userMap := v.(user.User)
-
-		if userMap.Email.IsSome() {
-			userMapEmail, _ := userMap.Email.Unwrap()
-			if userMapEmail == email {
-				exists = true
-				key = k.(int)
-				return false
-			}
-		}
+	u, err = m.st.getByEmail(email)
+	return
+}
 
-		return true
-	})
+// Get a user by username
+// - the username is compared with the canonical username of the users, if set
+func (m *StorageReadMap) GetByUsername(username string) (u user.User, err error) {
+	u, err = m.st.getByUsername(username)
+	return
+}
 
-	// check if user exists
-	if !exists {
-		err = fmt.Errorf("%w - %s", ErrStorageNotFound, email)
+// Get a user by id
+func (m *StorageReadMap) GetContext(ctx context.Context, id int) (u user.User, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
 		return
 	}
+	u, err = m.Get(id)
+	return
+}
 
-	// get user from db
-	value, _ := m.db.Load(key)
-
-	// type assertion
-	u = value.(user.User)
-
+// Get a user by email
+func (m *StorageReadMap) GetByEmailContext(ctx context.Context, email string) (u user.User, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	u, err = m.GetByEmail(email)
 	return
 }
 
 // Get a user by username
-func (m *StorageReadMap) GetByUsername(username string) (u user.User, err error) {
-	// loop over users
-	var exists bool; var key int
-	m.db.Range(func(k, v any) bool {
-		// check if username matches
-		userMap := v.(user.User)
-
-		if userMap.Username.IsSome() {
-			userMapUsername, _ := userMap.Username.Unwrap()
-			if userMapUsername == username {
-				exists = true
-				key = k.(int)
-				return false
-			}
-		}
-
-		return true
-	})
-
-	// check if user exists
-	if !exists {
-		err = fmt.Errorf("%w - %s", ErrStorageNotFound, username)
+func (m *StorageReadMap) GetByUsernameContext(ctx context.Context, username string) (u user.User, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
 		return
 	}
-
-	// get user from db
-	value, _ := m.db.Load(key)
-
-	// type assertion
-	u = value.(user.User)
-
+	u, err = m.GetByUsername(username)
 	return
-}
\ No newline at end of file
+}
@@ -1,7 +1,7 @@
 package storage_test
 
 import (
-	"sync"
+	"fmt"
 	"testing"
 
 	"github.com/LNMMusic/msauth/internal/user"
@@ -33,8 +33,8 @@ func TestStorageReadMap_Get(t *testing.T) {
 			name: "success to get user by id",
 			arrange: arrange{
 				setUpStorage: func() *storage.StorageReadMap {
-					db := &sync.Map{}
-					db.Store(1, user.User{
+					db := storage.NewStoreMap()
+					db.Put(user.User{
 						Id: 1,
 						Username: optional.Some[string]("johndoe"),
 						Password: optional.Some[string]("hashedPassword"),
@@ -118,8 +118,8 @@ func TestStorageReadMap_GetByEmail(t *testing.T) {
 			name: "success to get user by email",
 			arrange: arrange{
 				setUpStorage: func() *storage.StorageReadMap {
-					db := &sync.Map{}
-					db.Store(1, user.User{
+					db := storage.NewStoreMap()
+					db.Put(user.User{
 						Id: 1,
 						Username: optional.Some[string]("johndoe"),
 						Password: optional.Some[string]("hashedPassword"),
@@ -144,6 +144,36 @@ func TestStorageReadMap_GetByEmail(t *testing.T) {
 			},
 		},
 
+		{
+			name: "success to get user by email - canonical email",
+			arrange: arrange{
+				setUpStorage: func() *storage.StorageReadMap {
+					db := storage.NewStoreMap()
+					db.Put(user.User{
+						Id: 1,
+						Username: optional.Some[string]("JohnDoe"),
+						UsernameCanonical: optional.Some[string]("johndoe"),
+						Email: optional.Some[string]("JohnDoe@Gmail.com"),
+						EmailCanonical: optional.Some[string]("johndoe@gmail.com"),
+					})
+
+					return storage.NewStorageReadMap(db)
+				},
+			},
+			input: input{email: "johndoe@gmail.com"},
+			output: output{
+				u: user.User{
+					Id: 1,
+					Username: optional.Some[string]("JohnDoe"),
+					UsernameCanonical: optional.Some[string]("johndoe"),
+					Email: optional.Some[string]("JohnDoe@Gmail.com"),
+					EmailCanonical: optional.Some[string]("johndoe@gmail.com"),
+				},
+				err: nil,
+				errMsg: "",
+			},
+		},
+
 		// failure
 		{
 			name: "failure to get user by email - user not found",
@@ -163,8 +193,8 @@ func TestStorageReadMap_GetByEmail(t *testing.T) {
 			name: "failure to get user by email - user not found (db not empty)",
 			arrange: arrange{
 				setUpStorage: func() *storage.StorageReadMap {
-					db := &sync.Map{}
-					db.Store(1, user.User{
+					db := storage.NewStoreMap()
+					db.Put(user.User{
 						Id: 1,
 						Username: optional.Some[string]("johndoe"),
 						Password: optional.Some[string]("hashedPassword"),
@@ -226,8 +256,8 @@ func TestStorageReadMap_GetByUsername(t *testing.T) {
 			name: "success to get user by username",
 			arrange: arrange{
 				setUpStorage: func() *storage.StorageReadMap {
-					db := &sync.Map{}
-					db.Store(1, user.User{
+					db := storage.NewStoreMap()
+					db.Put(user.User{
 						Id: 1,
 						Username: optional.Some[string]("johndoe"),
 						Password: optional.Some[string]("hashedPassword"),
@@ -252,6 +282,36 @@ func TestStorageReadMap_GetByUsername(t *testing.T) {
 			},
 		},
 
+		{
+			name: "success to get user by username - canonical username",
+			arrange: arrange{
+				setUpStorage: func() *storage.StorageReadMap {
+					db := storage.NewStoreMap()
+					db.Put(user.User{
+						Id: 1,
+						Username: optional.Some[string]("JohnDoe"),
+						UsernameCanonical: optional.Some[string]("johndoe"),
+						Email: optional.Some[string]("JohnDoe@Gmail.com"),
+						EmailCanonical: optional.Some[string]("johndoe@gmail.com"),
+					})
+
+					return storage.NewStorageReadMap(db)
+				},
+			},
+			input: input{username: "johndoe"},
+			output: output{
+				u: user.User{
+					Id: 1,
+					Username: optional.Some[string]("JohnDoe"),
+					UsernameCanonical: optional.Some[string]("johndoe"),
+					Email: optional.Some[string]("JohnDoe@Gmail.com"),
+					EmailCanonical: optional.Some[string]("johndoe@gmail.com"),
+				},
+				err: nil,
+				errMsg: "",
+			},
+		},
+
 		// failure
 		{
 			name: "failure to get user by username - user not found",
@@ -271,8 +331,8 @@ func TestStorageReadMap_GetByUsername(t *testing.T) {
 			name: "failure to get user by username - user not found (db not empty)",
 			arrange: arrange{
 				setUpStorage: func() *storage.StorageReadMap {
-					db := &sync.Map{}
-					db.Store(1, user.User{
+					db := storage.NewStoreMap()
+					db.Put(user.User{
 						Id: 1,
 						Username: optional.Some[string](""),
 						Password: optional.Some[string]("hashedPassword"),
@@ -309,4 +369,39 @@ func TestStorageReadMap_GetByUsername(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+// Benchmarks for StorageReadMap lookups
+// - lookups go through the indexes, their cost does not grow with the number of users
+func BenchmarkStorageReadMap(b *testing.B) {
+	for _, n := range []int{1_000, 100_000, 1_000_000} {
+		// arrange
+		db := storage.NewStoreMap()
+		for i := 1; i <= n; i++ {
+			db.Put(user.User{
+				Id: i,
+				Username: optional.Some(fmt.Sprintf("user%d", i)),
+				Email: optional.Some(fmt.Sprintf("user%d@gmail.com", i)),
+			})
+		}
+		st := storage.NewStorageReadMap(db)
+		email := fmt.Sprintf("user%d@gmail.com", n/2)
+		username := fmt.Sprintf("user%d", n/2)
+
+		b.Run(fmt.Sprintf("GetByEmail/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := st.GetByEmail(email)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("GetByUsername/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := st.GetByUsername(username)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
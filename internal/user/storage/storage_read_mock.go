@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"context"
+
 	"github.com/LNMMusic/msauth/internal/user"
 
 	"github.com/stretchr/testify/mock"
@@ -18,7 +20,7 @@ type StorageReadMock struct {
 }
 
 // Get a user by id
-func (m *StorageReadMock) Get(id string) (u user.User, err error) {
+func (m *StorageReadMock) Get(id int) (u user.User, err error) {
 	args := m.Called(id)
 	u = args.Get(0).(user.User)
 	err = args.Error(1)
@@ -39,4 +41,31 @@ func (m *StorageReadMock) GetByUsername(username string) (u user.User, err error
 	u = args.Get(0).(user.User)
 	err = args.Error(1)
 	return
-}
\ No newline at end of file
+}
+
+// GetContext returns the error of a done context, otherwise the Get mock is called
+func (m *StorageReadMock) GetContext(ctx context.Context, id int) (u user.User, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	u, err = m.Get(id)
+	return
+}
+
+// GetByEmailContext returns the error of a done context, otherwise the GetByEmail mock is called
+func (m *StorageReadMock) GetByEmailContext(ctx context.Context, email string) (u user.User, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	u, err = m.GetByEmail(email)
+	return
+}
+
+// GetByUsernameContext returns the error of a done context, otherwise the GetByUsername mock is called
+func (m *StorageReadMock) GetByUsernameContext(ctx context.Context, username string) (u user.User, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	u, err = m.GetByUsername(username)
+	return
+}
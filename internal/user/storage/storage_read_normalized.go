@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/validator"
+)
+
+// NewStorageReadNormalized creates a new StorageReadNormalized
+func NewStorageReadNormalized(st StorageRead, nm validator.Normalizer) *StorageReadNormalized {
+	return &StorageReadNormalized{StorageReadWithContext(st), nm}
+}
+
+// StorageReadNormalized wraps a StorageRead interface and normalizes the identifiers of the lookups
+// - users are looked up by their canonical identifiers, so sign ins are case insensitive as sign ups
+type StorageReadNormalized struct {
+	// st is the StorageRead interface to be wrapped
+	st StorageReadContext
+	// nm is the Normalizer interface of the identifiers, it must be the same used by the validator
+	nm validator.Normalizer
+}
+
+// Get a user by id
+func (s *StorageReadNormalized) Get(id int) (u user.User, err error) {
+	u, err = s.GetContext(context.Background(), id)
+	return
+}
+
+// Get a user by id
+func (s *StorageReadNormalized) GetContext(ctx context.Context, id int) (u user.User, err error) {
+	u, err = s.st.GetContext(ctx, id)
+	return
+}
+
+// Get a user by email
+func (s *StorageReadNormalized) GetByEmail(email string) (u user.User, err error) {
+	u, err = s.GetByEmailContext(context.Background(), email)
+	return
+}
+
+// Get a user by email
+func (s *StorageReadNormalized) GetByEmailContext(ctx context.Context, email string) (u user.User, err error) {
+	u, err = s.st.GetByEmailContext(ctx, s.nm.Email(email))
+	return
+}
+
+// Get a user by username
+func (s *StorageReadNormalized) GetByUsername(username string) (u user.User, err error) {
+	u, err = s.GetByUsernameContext(context.Background(), username)
+	return
+}
+
+// Get a user by username
+func (s *StorageReadNormalized) GetByUsernameContext(ctx context.Context, username string) (u user.User, err error) {
+	u, err = s.st.GetByUsernameContext(ctx, s.nm.Username(username))
+	return
+}
@@ -0,0 +1,73 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/validator"
+	"github.com/LNMMusic/msauth/pkg/crypter"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for StorageReadNormalized
+func TestStorageReadNormalized(t *testing.T) {
+	t.Run("email normalized before the lookup", func(t *testing.T) {
+		// arrange
+		st := storage.NewStorageReadMock()
+		st.On("GetByEmail", "alice@example.com").Return(user.User{Id: 1, Email: optional.Some("Alice@Example.com")}, nil)
+		nm := validator.NewNormalizerMock()
+		nm.On("Email", " Alice@Example.com").Return("alice@example.com")
+		sn := storage.NewStorageReadNormalized(st, nm)
+
+		// act
+		u, err := sn.GetByEmail(" Alice@Example.com")
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 1, u.Id)
+		st.AssertExpectations(t)
+		nm.AssertExpectations(t)
+	})
+
+	t.Run("username normalized before the lookup", func(t *testing.T) {
+		// arrange
+		st := storage.NewStorageReadMock()
+		st.On("GetByUsername", "alice").Return(user.User{}, storage.ErrStorageNotFound)
+		nm := validator.NewNormalizerMock()
+		nm.On("Username", "ALICE").Return("alice")
+		sn := storage.NewStorageReadNormalized(st, nm)
+
+		// act
+		_, err := sn.GetByUsername("ALICE")
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		st.AssertExpectations(t)
+		nm.AssertExpectations(t)
+	})
+
+	t.Run("sign up and sign in with different cases", func(t *testing.T) {
+		// arrange
+		// - user prepared by the validator, as on sign up
+		cr := crypter.NewCrypterMock()
+		cr.On("Encrypt", "correct-horse").Return("hashedPassword", nil)
+		vl := validator.NewValidatorDefault("", cr)
+		u := user.User{Id: 1, Username: optional.Some("Alice"), Password: optional.Some("correct-horse"), Email: optional.Some("Alice@Example.com")}
+		require.NoError(t, vl.Prepare(&u))
+		db := storage.NewStoreMap()
+		db.Put(u)
+		sn := storage.NewStorageReadNormalized(storage.NewStorageReadMap(db), validator.NewNormalizerDefault(nil))
+
+		// act
+		byEmail, errEmail := sn.GetByEmail(" ALICE@example.COM")
+		byUsername, errUsername := sn.GetByUsername("aLiCe")
+
+		// assert
+		require.NoError(t, errEmail)
+		require.NoError(t, errUsername)
+		require.Equal(t, optional.Some("Alice@Example.com"), byEmail.Email)
+		require.Equal(t, optional.Some("Alice"), byUsername.Username)
+	})
+}
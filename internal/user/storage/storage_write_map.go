@@ -1,108 +1,141 @@
 package storage
 
 import (
+	"context"
 	"fmt"
-	"sync"
 
 	"github.com/LNMMusic/msauth/internal/user"
 	"github.com/LNMMusic/optional"
 )
 
 // NewStorageWriteMap creates a new instance of StorageWriteMap
-func NewStorageWriteMap(db *sync.Map, lastId int) *StorageWriteMap {
+// - in case of a nil store, a new empty one is used
+func NewStorageWriteMap(st *StoreMap) *StorageWriteMap {
 	// default config
-	defaultDb := &sync.Map{}
-	defaultLastId := 0
-	if db != nil {
-		defaultDb = db
-	}
-	if lastId > 0 {
-		defaultLastId = lastId
+	defaultSt := st
+	if defaultSt == nil {
+		defaultSt = NewStoreMap()
 	}
 
 	return &StorageWriteMap{
-		db: defaultDb,
-		lastId: defaultLastId,
-		mu: &sync.Mutex{},
+		st: defaultSt,
 	}
 }
 
 // StorageWriteMap is the storage in map format
 type StorageWriteMap struct {
-	// db is the database in map format
-	// - key: user id
-	// - value: user data
-	db *sync.Map
-
-	// lastId is the last id of the user
-	lastId int
-
-	// mu is the mutex
-	mu *sync.Mutex
+	// st is the store of the users, shared with the read side
+	st *StoreMap
 }
 
 // Create a new user
+// - the username and email must be unique, they are compared by their canonical form if set
 func (m *StorageWriteMap) Create(u *user.User) (err error) {
-	// lock the mutex
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	
-	// set the id
-	m.lastId++
-	(*u).Id = m.lastId
-
 	// save the user
-	m.db.Store(m.lastId, u)
-
+	// - the uniqueness check and the store are atomic
+	err = m.st.insert(u)
 	return
 }
 
 // Update an existing user
+// - the version of the user must be the stored one, it is incremented on success
+// - the username and email must remain unique
 func (m *StorageWriteMap) Update(u *user.User) (err error) {
 	// update the user
-	_, ok := m.db.Swap(u.Id, u)
-	if !ok {
-		err = fmt.Errorf("%w - %d", ErrStorageNotFound, u.Id)
-		return
-	}
+	err = m.st.replace(u)
+	return
+}
 
+// Patch applies the fields that are some of a partial user to an existing user
+// - the version of the user must be the stored one, it is incremented on success
+// - the username and email must remain unique
+func (m *StorageWriteMap) Patch(u *user.User) (err error) {
+	// patch the user
+	err = m.st.patch(u)
 	return
 }
 
 // Delete an existing user
 func (m *StorageWriteMap) Delete(id int) (err error) {
 	// delete the user
-	_, ok := m.db.LoadAndDelete(id)
-	if !ok {
-		err = fmt.Errorf("%w - %d", ErrStorageNotFound, id)
-		return
-	}
-
+	err = m.st.remove(id)
 	return
 }
 
 // Activate an existing user
 func (m *StorageWriteMap) Activate(id int) (err error) {
-	// lock the mutex
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// get the user
-	v, ok := m.db.Load(id)
-	if !ok {
-		err = fmt.Errorf("%w - %d", ErrStorageNotFound, id)
+	// activate the user
+	err = m.st.modify(id, func(u *user.User) {
+		u.IsActive = optional.Some[bool](true)
+	})
+	return
+}
+
+// UpdatePassword updates the password of an existing user
+func (m *StorageWriteMap) UpdatePassword(id int, password string) (err error) {
+	// update the password
+	err = m.st.modify(id, func(u *user.User) {
+		u.Password = optional.Some(password)
+	})
+	return
+}
+
+// Create a new user
+func (m *StorageWriteMap) CreateContext(ctx context.Context, u *user.User) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
 		return
 	}
+	err = m.Create(u)
+	return
+}
 
-	// type assertion
-	u := v.(user.User)
+// Update an existing user
+func (m *StorageWriteMap) UpdateContext(ctx context.Context, u *user.User) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = m.Update(u)
+	return
+}
 
-	// activate the user
-	u.IsActive = optional.Some[bool](true)
+// Patch applies the fields that are some of a partial user to an existing user
+func (m *StorageWriteMap) PatchContext(ctx context.Context, u *user.User) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = m.Patch(u)
+	return
+}
 
-	// update the user
-	m.db.Swap(u.Id, u)
+// Delete an existing user
+func (m *StorageWriteMap) DeleteContext(ctx context.Context, id int) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = m.Delete(id)
+	return
+}
 
+// Activate an existing user
+func (m *StorageWriteMap) ActivateContext(ctx context.Context, id int) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = m.Activate(id)
 	return
 }
 
+// UpdatePassword updates the password of an existing user
+func (m *StorageWriteMap) UpdatePasswordContext(ctx context.Context, id int, password string) (err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInternal, err)
+		return
+	}
+	err = m.UpdatePassword(id, password)
+	return
+}
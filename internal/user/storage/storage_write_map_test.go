@@ -31,7 +31,7 @@ func TestStorageWriteMap_Create(t *testing.T) {
 			name: "success - create a new user",
 			arrange: arrange{
 				storage: func() *storage.StorageWriteMap {
-					return storage.NewStorageWriteMap(nil, 0)
+					return storage.NewStorageWriteMap(nil)
 				},
 			},
 			input: input{
@@ -49,6 +49,68 @@ func TestStorageWriteMap_Create(t *testing.T) {
 				errMsg: "",
 			},
 		},
+
+		// failure cases
+		{
+			name: "failure - username already exists, compared by its canonical form",
+			arrange: arrange{
+				storage: func() *storage.StorageWriteMap {
+					db := storage.NewStoreMap()
+					db.Put(user.User{
+						Id: 1,
+						Username: optional.Some[string]("JohnDoe"),
+						UsernameCanonical: optional.Some[string]("johndoe"),
+						Email: optional.Some[string]("JohnDoe@Gmail.com"),
+						EmailCanonical: optional.Some[string]("johndoe@gmail.com"),
+					})
+
+					return storage.NewStorageWriteMap(db)
+				},
+			},
+			input: input{
+				u: &user.User{
+					Username: optional.Some[string]("JOHNDOE"),
+					UsernameCanonical: optional.Some[string]("johndoe"),
+					Email: optional.Some[string]("other@gmail.com"),
+					EmailCanonical: optional.Some[string]("other@gmail.com"),
+				},
+			},
+			output: output{
+				id: 0,
+				err: storage.ErrStorageExists,
+				errMsg: "storage: user already exists - username",
+			},
+		},
+		{
+			name: "failure - email already exists, compared by its canonical form",
+			arrange: arrange{
+				storage: func() *storage.StorageWriteMap {
+					db := storage.NewStoreMap()
+					db.Put(user.User{
+						Id: 1,
+						Username: optional.Some[string]("JohnDoe"),
+						UsernameCanonical: optional.Some[string]("johndoe"),
+						Email: optional.Some[string]("JohnDoe@Gmail.com"),
+						EmailCanonical: optional.Some[string]("johndoe@gmail.com"),
+					})
+
+					return storage.NewStorageWriteMap(db)
+				},
+			},
+			input: input{
+				u: &user.User{
+					Username: optional.Some[string]("other"),
+					UsernameCanonical: optional.Some[string]("other"),
+					Email: optional.Some[string]("johndoe@GMAIL.com"),
+					EmailCanonical: optional.Some[string]("johndoe@gmail.com"),
+				},
+			},
+			output: output{
+				id: 0,
+				err: storage.ErrStorageExists,
+				errMsg: "storage: user already exists - email",
+			},
+		},
 	}
 
 	// run test cases
@@ -70,6 +132,45 @@ func TestStorageWriteMap_Create(t *testing.T) {
 	}
 }
 
+// Tests for StorageWriteMap.Create with concurrent sign ups
+func TestStorageWriteMap_Create_Concurrent(t *testing.T) {
+	// arrange
+	st := storage.NewStorageWriteMap(nil)
+	n := 50
+
+	// act
+	// - n identical registrations at once
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- st.Create(&user.User{
+				Username: optional.Some[string]("johndoe"),
+				Email: optional.Some[string]("johndoe@gmail.com"),
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// assert
+	// - only one of them succeeds
+	var created, exists int
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		default:
+			require.ErrorIs(t, err, storage.ErrStorageExists)
+			exists++
+		}
+	}
+	require.Equal(t, 1, created)
+	require.Equal(t, n-1, exists)
+}
+
 // Tests for StorageWriteMap.Update
 func TestStorageWriteMap_Update(t *testing.T) {
 	type arrange struct {storage func() *storage.StorageWriteMap}
@@ -91,8 +192,8 @@ func TestStorageWriteMap_Update(t *testing.T) {
 			name: "success - update an existing user",
 			arrange: arrange{
 				storage: func() *storage.StorageWriteMap {
-					db := &sync.Map{}
-					db.Store(1, user.User{
+					db := storage.NewStoreMap()
+					db.Put(user.User{
 						Id: 1,
 						Username: optional.Some[string]("username"),
 						Password: optional.Some[string]("password"),
@@ -100,7 +201,7 @@ func TestStorageWriteMap_Update(t *testing.T) {
 						IsActive: optional.Some[bool](false),
 					})
 
-					return storage.NewStorageWriteMap(db, 1)
+					return storage.NewStorageWriteMap(db)
 				},
 			},
 			input: input{
@@ -123,7 +224,7 @@ func TestStorageWriteMap_Update(t *testing.T) {
 			name: "failure - update a non-existing user",
 			arrange: arrange{
 				storage: func() *storage.StorageWriteMap {
-					return storage.NewStorageWriteMap(nil, 0)
+					return storage.NewStorageWriteMap(nil)
 				},
 			},
 			input: input{
@@ -140,6 +241,52 @@ func TestStorageWriteMap_Update(t *testing.T) {
 				errMsg: "storage: user not found - 1",
 			},
 		},
+		{
+			name: "failure - update with a stale version",
+			arrange: arrange{
+				storage: func() *storage.StorageWriteMap {
+					db := storage.NewStoreMap()
+					db.Put(user.User{Id: 1, Username: optional.Some[string]("johndoe"), Email: optional.Some[string]("johndoe@gmail.com"), Version: 2})
+
+					return storage.NewStorageWriteMap(db)
+				},
+			},
+			input: input{
+				u: &user.User{
+					Id: 1,
+					Username: optional.Some[string]("johnny"),
+					Email: optional.Some[string]("johndoe@gmail.com"),
+					Version: 1,
+				},
+			},
+			output: output{
+				err: storage.ErrStorageConflict,
+				errMsg: "storage: user version conflict - 1, version 1, stored 2",
+			},
+		},
+		{
+			name: "failure - update to the email of another user",
+			arrange: arrange{
+				storage: func() *storage.StorageWriteMap {
+					db := storage.NewStoreMap()
+					db.Put(user.User{Id: 1, Username: optional.Some[string]("johndoe"), Email: optional.Some[string]("johndoe@gmail.com")})
+					db.Put(user.User{Id: 2, Username: optional.Some[string]("janedoe"), Email: optional.Some[string]("janedoe@gmail.com")})
+
+					return storage.NewStorageWriteMap(db)
+				},
+			},
+			input: input{
+				u: &user.User{
+					Id: 2,
+					Username: optional.Some[string]("janedoe"),
+					Email: optional.Some[string]("johndoe@gmail.com"),
+				},
+			},
+			output: output{
+				err: storage.ErrStorageExists,
+				errMsg: "storage: user already exists - email",
+			},
+		},
 	}
 
 	// run test cases
@@ -181,8 +328,8 @@ func TestStorageWriteMap_Delete(t *testing.T) {
 			name: "success - delete an existing user",
 			arrange: arrange{
 				storage: func() *storage.StorageWriteMap {
-					db := &sync.Map{}
-					db.Store(1, user.User{
+					db := storage.NewStoreMap()
+					db.Put(user.User{
 						Id: 1,
 						Username: optional.Some[string]("username"),
 						Password: optional.Some[string]("password"),
@@ -190,7 +337,7 @@ func TestStorageWriteMap_Delete(t *testing.T) {
 						IsActive: optional.Some[bool](false),
 					})
 
-					return storage.NewStorageWriteMap(db, 1)
+					return storage.NewStorageWriteMap(db)
 				},
 			},
 			input: input{
@@ -207,7 +354,7 @@ func TestStorageWriteMap_Delete(t *testing.T) {
 			name: "failure - delete a non-existing user",
 			arrange: arrange{
 				storage: func() *storage.StorageWriteMap {
-					return storage.NewStorageWriteMap(nil, 0)
+					return storage.NewStorageWriteMap(nil)
 				},
 			},
 			input: input{
@@ -259,8 +406,8 @@ func TestStorageWriteMap_Activate(t *testing.T) {
 			name: "success - activate an existing user",
 			arrange: arrange{
 				storage: func() *storage.StorageWriteMap {
-					db := &sync.Map{}
-					db.Store(1, user.User{
+					db := storage.NewStoreMap()
+					db.Put(user.User{
 						Id: 1,
 						Username: optional.Some[string]("username"),
 						Password: optional.Some[string]("password"),
@@ -268,7 +415,7 @@ func TestStorageWriteMap_Activate(t *testing.T) {
 						IsActive: optional.Some[bool](false),
 					})
 
-					return storage.NewStorageWriteMap(db, 1)
+					return storage.NewStorageWriteMap(db)
 				},
 			},
 			input: input{
@@ -285,7 +432,7 @@ func TestStorageWriteMap_Activate(t *testing.T) {
 			name: "failure - activate a non-existing user",
 			arrange: arrange{
 				storage: func() *storage.StorageWriteMap {
-					return storage.NewStorageWriteMap(nil, 0)
+					return storage.NewStorageWriteMap(nil)
 				},
 			},
 			input: input{
@@ -314,4 +461,46 @@ func TestStorageWriteMap_Activate(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+// Tests for StorageWriteMap.UpdatePassword
+func TestStorageWriteMap_UpdatePassword(t *testing.T) {
+	t.Run("success - update the password of an existing user", func(t *testing.T) {
+		// arrange
+		db := storage.NewStoreMap()
+		db.Put(user.User{
+			Id: 1,
+			Username: optional.Some[string]("username"),
+			Password: optional.Some[string]("password"),
+			Email: optional.Some[string]("email"),
+			IsActive: optional.Some[bool](true),
+		})
+		st := storage.NewStorageWriteMap(db)
+
+		// act
+		err := st.UpdatePassword(1, "password_updated")
+
+		// assert
+		require.NoError(t, err)
+		v, _ := storage.NewStorageReadMap(db).Get(1)
+		require.Equal(t, user.User{
+			Id: 1,
+			Username: optional.Some[string]("username"),
+			Password: optional.Some[string]("password_updated"),
+			Email: optional.Some[string]("email"),
+			IsActive: optional.Some[bool](true),
+			Version: 1,
+		}, v)
+	})
+
+	t.Run("failure - update the password of a non-existing user", func(t *testing.T) {
+		// arrange
+		st := storage.NewStorageWriteMap(nil)
+
+		// act
+		err := st.UpdatePassword(1, "password_updated")
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		require.EqualError(t, err, "storage: user not found - 1")
+	})
+}
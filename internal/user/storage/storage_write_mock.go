@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"context"
+
 	"github.com/LNMMusic/msauth/internal/user"
 	"github.com/stretchr/testify/mock"
 )
@@ -30,6 +32,13 @@ func (m *StorageWriteMock) Update(u *user.User) (err error) {
 	return
 }
 
+// Patch applies the fields that are some of a partial user to an existing user
+func (m *StorageWriteMock) Patch(u *user.User) (err error) {
+	args := m.Called(u)
+	err = args.Error(0)
+	return
+}
+
 // Delete an existing user
 func (m *StorageWriteMock) Delete(id int) (err error) {
 	args := m.Called(id)
@@ -42,4 +51,65 @@ func (m *StorageWriteMock) Activate(id int) (err error) {
 	args := m.Called(id)
 	err = args.Error(0)
 	return
-}
\ No newline at end of file
+}
+
+// UpdatePassword updates the password of an existing user
+func (m *StorageWriteMock) UpdatePassword(id int, password string) (err error) {
+	args := m.Called(id, password)
+	err = args.Error(0)
+	return
+}
+
+// CreateContext returns the error of a done context, otherwise the Create mock is called
+func (m *StorageWriteMock) CreateContext(ctx context.Context, u *user.User) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.Create(u)
+	return
+}
+
+// UpdateContext returns the error of a done context, otherwise the Update mock is called
+func (m *StorageWriteMock) UpdateContext(ctx context.Context, u *user.User) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.Update(u)
+	return
+}
+
+// PatchContext returns the error of a done context, otherwise the Patch mock is called
+func (m *StorageWriteMock) PatchContext(ctx context.Context, u *user.User) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.Patch(u)
+	return
+}
+
+// DeleteContext returns the error of a done context, otherwise the Delete mock is called
+func (m *StorageWriteMock) DeleteContext(ctx context.Context, id int) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.Delete(id)
+	return
+}
+
+// ActivateContext returns the error of a done context, otherwise the Activate mock is called
+func (m *StorageWriteMock) ActivateContext(ctx context.Context, id int) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.Activate(id)
+	return
+}
+
+// UpdatePasswordContext returns the error of a done context, otherwise the UpdatePassword mock is called
+func (m *StorageWriteMock) UpdatePasswordContext(ctx context.Context, id int, password string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.UpdatePassword(id, password)
+	return
+}
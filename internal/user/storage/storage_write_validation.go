@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/LNMMusic/msauth/internal/user"
@@ -8,21 +9,30 @@ import (
 )
 
 // NewStorageWriteValidation creates a new StorageWriteValidation
-func NewStorageWriteValidation(st StorageWrite, vl validator.Validator) *StorageWriteValidation {
-	return &StorageWriteValidation{st, vl}
+func NewStorageWriteValidation(st StorageWrite, stRead StorageRead, vl validator.Validator) *StorageWriteValidation {
+	return &StorageWriteValidation{StorageWriteWithContext(st), StorageReadWithContext(stRead), validator.WithContext(vl)}
 }
 
 // StorageWriteValidation wraps a StorageWrite interface and adds validation and encryption
 type StorageWriteValidation struct {
 	// st is the StorageWrite interface to be wrapped
-	st StorageWrite
+	st StorageWriteContext
+	// stRead is the StorageRead interface to get the stored user
+	// - the stored username and email must not be part of a new password that comes without them
+	stRead StorageReadContext
 	// vl is the Validator interface to add validation to the StorageWrite interface
-	vl validator.Validator
+	vl validator.ValidatorContext
 }
 
 
 // Create a new user
 func (s *StorageWriteValidation) Create(u *user.User) (err error) {
+	err = s.CreateContext(context.Background(), u)
+	return
+}
+
+// CreateContext creates a new user, the password is encrypted unless the context is done
+func (s *StorageWriteValidation) CreateContext(ctx context.Context, u *user.User) (err error) {
 	// validator
 	// - default values
 	err = s.vl.Default(u)
@@ -33,23 +43,29 @@ func (s *StorageWriteValidation) Create(u *user.User) (err error) {
 	// - validate user
 	err = s.vl.Validate(u)
 	if err != nil {
-		err = fmt.Errorf("%w. %s", ErrStorageInvalid, err.Error())
+		err = fmt.Errorf("%w. %w", ErrStorageInvalid, err)
 		return
 	}
 	// - prepare user
-	err = s.vl.Prepare(u)
+	err = s.vl.PrepareContext(ctx, u)
 	if err != nil {
-		err = fmt.Errorf("%w. %s", ErrStorageInvalid, err.Error())
+		err = fmt.Errorf("%w. %w", ErrStorageInvalid, err)
 		return
 	}
 
 	// create user
-	err = s.st.Create(u)
+	err = s.st.CreateContext(ctx, u)
 	return
 }
 
 // Update an existing user
 func (s *StorageWriteValidation) Update(u *user.User) (err error) {
+	err = s.UpdateContext(context.Background(), u)
+	return
+}
+
+// UpdateContext updates an existing user, the password is encrypted unless the context is done
+func (s *StorageWriteValidation) UpdateContext(ctx context.Context, u *user.User) (err error) {
 	// validator
 	// - default values
 	err = s.vl.Default(u)
@@ -60,31 +76,120 @@ func (s *StorageWriteValidation) Update(u *user.User) (err error) {
 	// - validate user
 	err = s.vl.Validate(u)
 	if err != nil {
-		err = fmt.Errorf("%w. %s", ErrStorageInvalid, err.Error())
+		err = fmt.Errorf("%w. %w", ErrStorageInvalid, err)
 		return
 	}
 	// - prepare user
-	err = s.vl.Prepare(u)
+	err = s.vl.PrepareContext(ctx, u)
 	if err != nil {
-		err = fmt.Errorf("%w. %s", ErrStorageInvalid, err.Error())
+		err = fmt.Errorf("%w. %w", ErrStorageInvalid, err)
 		return
 	}
 
 	// update user
-	err = s.st.Update(u)
+	err = s.st.UpdateContext(ctx, u)
+	return
+}
+
+// Patch validates and prepares the fields that are some of a partial user and applies them to an existing user
+// - defaults are not set and none fields are not required, the stored values are kept
+// - the password is validated against the stored username and email as well, and encrypted only if it is some
+func (s *StorageWriteValidation) Patch(u *user.User) (err error) {
+	err = s.PatchContext(context.Background(), u)
+	return
+}
+
+// PatchContext patches an existing user, the password is encrypted unless the context is done
+func (s *StorageWriteValidation) PatchContext(ctx context.Context, u *user.User) (err error) {
+	// validator
+	// - stored identifiers
+	var identifiers []string
+	if u.Password.IsSome() {
+		var stored user.User
+		stored, err = s.stRead.GetContext(ctx, u.Id)
+		if err != nil {
+			return
+		}
+		username, _ := stored.Username.Unwrap()
+		email, _ := stored.Email.Unwrap()
+		identifiers = []string{username, email}
+	}
+	// - validate fields
+	err = s.vl.ValidatePatch(u, identifiers...)
+	if err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInvalid, err)
+		return
+	}
+	// - prepare fields
+	err = s.vl.PrepareContext(ctx, u)
+	if err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInvalid, err)
+		return
+	}
+
+	// patch user
+	err = s.st.PatchContext(ctx, u)
 	return
 }
 
 // Delete an existing user
 func (s *StorageWriteValidation) Delete(id int) (err error) {
+	err = s.DeleteContext(context.Background(), id)
+	return
+}
+
+// Delete an existing user
+func (s *StorageWriteValidation) DeleteContext(ctx context.Context, id int) (err error) {
 	// delete user
-	err = s.st.Delete(id)
+	err = s.st.DeleteContext(ctx, id)
 	return
 }
 
 // Activate an existing user
 func (s *StorageWriteValidation) Activate(id int) (err error) {
+	err = s.ActivateContext(context.Background(), id)
+	return
+}
+
+// Activate an existing user
+func (s *StorageWriteValidation) ActivateContext(ctx context.Context, id int) (err error) {
 	// activate user
-	err = s.st.Activate(id)
+	err = s.st.ActivateContext(ctx, id)
+	return
+}
+
+// UpdatePassword validates and encrypts a plain password and updates it for an existing user
+// - only the password is validated, against the stored username and email, the rest of the user is left as it is
+func (s *StorageWriteValidation) UpdatePassword(id int, password string) (err error) {
+	err = s.UpdatePasswordContext(context.Background(), id, password)
+	return
+}
+
+// UpdatePasswordContext validates and encrypts a plain password and updates it for an existing user, unless the context is done
+func (s *StorageWriteValidation) UpdatePasswordContext(ctx context.Context, id int, password string) (err error) {
+	// stored identifiers
+	stored, err := s.stRead.GetContext(ctx, id)
+	if err != nil {
+		return
+	}
+	username, _ := stored.Username.Unwrap()
+	email, _ := stored.Email.Unwrap()
+
+	// validator
+	// - validate password
+	err = s.vl.ValidatePassword(password, username, email)
+	if err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInvalid, err)
+		return
+	}
+	// - prepare password
+	prepared, err := s.vl.PreparePasswordContext(ctx, password)
+	if err != nil {
+		err = fmt.Errorf("%w. %w", ErrStorageInvalid, err)
+		return
+	}
+
+	// update password
+	err = s.st.UpdatePasswordContext(ctx, id, prepared)
 	return
-}
\ No newline at end of file
+}
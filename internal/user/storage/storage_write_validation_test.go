@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/LNMMusic/msauth/internal/user"
 	"github.com/LNMMusic/msauth/internal/user/storage"
 	"github.com/LNMMusic/msauth/internal/user/validator"
+	"github.com/LNMMusic/msauth/pkg/crypter"
+	"github.com/LNMMusic/optional"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -25,7 +28,7 @@ func TestStorageWriteValidation_Create(t *testing.T) {
 		stMk.On("Create", mock.Anything).Return(nil)
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(stMk, vlMk)
+		st := storage.NewStorageWriteValidation(stMk, nil, vlMk)
 
 		// act
 		err := st.Create(nil)
@@ -46,7 +49,7 @@ func TestStorageWriteValidation_Create(t *testing.T) {
 		// ...
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(nil, vlMk)
+		st := storage.NewStorageWriteValidation(nil, nil, vlMk)
 
 		// act
 		err := st.Create(nil)
@@ -68,7 +71,7 @@ func TestStorageWriteValidation_Create(t *testing.T) {
 		// ...
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(nil, vlMk)
+		st := storage.NewStorageWriteValidation(nil, nil, vlMk)
 
 		// act
 		err := st.Create(nil)
@@ -91,7 +94,7 @@ func TestStorageWriteValidation_Create(t *testing.T) {
 		// ...
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(nil, vlMk)
+		st := storage.NewStorageWriteValidation(nil, nil, vlMk)
 
 		// act
 		err := st.Create(nil)
@@ -115,7 +118,7 @@ func TestStorageWriteValidation_Create(t *testing.T) {
 		stMk.On("Create", mock.Anything).Return(storage.ErrStorageExists)
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(stMk, vlMk)
+		st := storage.NewStorageWriteValidation(stMk, nil, vlMk)
 
 		// act
 		err := st.Create(nil)
@@ -142,7 +145,7 @@ func TestStorageWriteValidation_Update(t *testing.T) {
 		stMk.On("Update", mock.Anything).Return(nil)
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(stMk, vlMk)
+		st := storage.NewStorageWriteValidation(stMk, nil, vlMk)
 
 		// act
 		err := st.Update(nil)
@@ -163,7 +166,7 @@ func TestStorageWriteValidation_Update(t *testing.T) {
 		// ...
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(nil, vlMk)
+		st := storage.NewStorageWriteValidation(nil, nil, vlMk)
 
 		// act
 		err := st.Update(nil)
@@ -185,7 +188,7 @@ func TestStorageWriteValidation_Update(t *testing.T) {
 		// ...
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(nil, vlMk)
+		st := storage.NewStorageWriteValidation(nil, nil, vlMk)
 
 		// act
 		err := st.Update(nil)
@@ -208,7 +211,7 @@ func TestStorageWriteValidation_Update(t *testing.T) {
 		// ...
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(nil, vlMk)
+		st := storage.NewStorageWriteValidation(nil, nil, vlMk)
 
 		// act
 		err := st.Update(nil)
@@ -232,7 +235,7 @@ func TestStorageWriteValidation_Update(t *testing.T) {
 		stMk.On("Update", mock.Anything).Return(storage.ErrStorageNotFound)
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(stMk, vlMk)
+		st := storage.NewStorageWriteValidation(stMk, nil, vlMk)
 
 		// act
 		err := st.Update(nil)
@@ -253,7 +256,7 @@ func TestStorageWriteValidation_Delete(t *testing.T) {
 		stMk.On("Delete", mock.Anything).Return(nil)
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(stMk, nil)
+		st := storage.NewStorageWriteValidation(stMk, nil, nil)
 
 		// act
 		err := st.Delete(0)
@@ -270,7 +273,7 @@ func TestStorageWriteValidation_Delete(t *testing.T) {
 		stMk.On("Delete", mock.Anything).Return(storage.ErrStorageNotFound)
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(stMk, nil)
+		st := storage.NewStorageWriteValidation(stMk, nil, nil)
 
 		// act
 		err := st.Delete(0)
@@ -290,7 +293,7 @@ func TestStorageWriteValidation_Activate(t *testing.T) {
 		stMk.On("Activate", mock.Anything).Return(nil)
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(stMk, nil)
+		st := storage.NewStorageWriteValidation(stMk, nil, nil)
 
 		// act
 		err := st.Activate(0)
@@ -307,7 +310,7 @@ func TestStorageWriteValidation_Activate(t *testing.T) {
 		stMk.On("Activate", mock.Anything).Return(storage.ErrStorageNotFound)
 
 		// - storage: validation
-		st := storage.NewStorageWriteValidation(stMk, nil)
+		st := storage.NewStorageWriteValidation(stMk, nil, nil)
 
 		// act
 		err := st.Activate(0)
@@ -316,4 +319,245 @@ func TestStorageWriteValidation_Activate(t *testing.T) {
 		require.ErrorIs(t, err, storage.ErrStorageNotFound)
 		stMk.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+}
+// Tests for StorageWriteValidation.UpdatePassword
+func TestStorageWriteValidation_UpdatePassword(t *testing.T) {
+	// stored user
+	stored := func() *storage.StorageReadMock {
+		rdMk := storage.NewStorageReadMock()
+		rdMk.On("Get", 1).Return(user.User{Id: 1, Username: optional.Some("johndoe"), Email: optional.Some("johndoe@gmail.com")}, nil)
+		return rdMk
+	}
+
+	t.Run("success to update password", func(t *testing.T) {
+		// arrange
+		// - validator: mock
+		vlMk := validator.NewValidatorMock()
+		vlMk.On("ValidatePassword", "password", "johndoe", "johndoe@gmail.com").Return(nil)
+		vlMk.On("PreparePassword", "password").Return("encrypted_password", nil)
+
+		// - storage: mock
+		stMk := storage.NewStorageWriteMock()
+		stMk.On("UpdatePassword", 1, "encrypted_password").Return(nil)
+
+		// - storage: validation
+		st := storage.NewStorageWriteValidation(stMk, stored(), vlMk)
+
+		// act
+		err := st.UpdatePassword(1, "password")
+
+		// assert
+		require.NoError(t, err)
+		vlMk.AssertExpectations(t)
+		stMk.AssertExpectations(t)
+	})
+
+	t.Run("fail to update password - password contains the stored identifiers", func(t *testing.T) {
+		// arrange
+		// - storage: mock
+		stMk := storage.NewStorageWriteMock()
+
+		// - storage: validation
+		st := storage.NewStorageWriteValidation(stMk, stored(), validator.NewValidatorDefault("", nil))
+
+		// act
+		err := st.UpdatePassword(1, "johndoe2024!")
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageInvalid)
+		require.ErrorIs(t, err, validator.ErrValidatorFieldQuality)
+		require.Contains(t, validator.Fields(err)["password"], "should not contain the username or email")
+		stMk.AssertExpectations(t)
+	})
+
+	t.Run("fail to update password - user not found", func(t *testing.T) {
+		// arrange
+		// - storage: mock
+		rdMk := storage.NewStorageReadMock()
+		rdMk.On("Get", 1).Return(user.User{}, storage.ErrStorageNotFound)
+
+		// - storage: validation
+		st := storage.NewStorageWriteValidation(nil, rdMk, validator.NewValidatorMock())
+
+		// act
+		err := st.UpdatePassword(1, "password")
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		rdMk.AssertExpectations(t)
+	})
+
+	t.Run("fail to update password - validate error", func(t *testing.T) {
+		// arrange
+		// - validator: mock
+		vlMk := validator.NewValidatorMock()
+		vlMk.On("ValidatePassword", "pass", "johndoe", "johndoe@gmail.com").Return(validator.ErrValidatorFieldQuality)
+
+		// - storage: validation
+		st := storage.NewStorageWriteValidation(nil, stored(), vlMk)
+
+		// act
+		err := st.UpdatePassword(1, "pass")
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageInvalid)
+		require.EqualError(t, err, fmt.Sprintf("%v. %v", storage.ErrStorageInvalid, validator.ErrValidatorFieldQuality))
+		vlMk.AssertExpectations(t)
+	})
+
+	t.Run("fail to update password - prepare error", func(t *testing.T) {
+		// arrange
+		// - validator: mock
+		vlMk := validator.NewValidatorMock()
+		vlMk.On("ValidatePassword", "password", "johndoe", "johndoe@gmail.com").Return(nil)
+		vlMk.On("PreparePassword", "password").Return("", validator.ErrValidatorEncryption)
+
+		// - storage: validation
+		st := storage.NewStorageWriteValidation(nil, stored(), vlMk)
+
+		// act
+		err := st.UpdatePassword(1, "password")
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageInvalid)
+		require.EqualError(t, err, fmt.Sprintf("%v. %v", storage.ErrStorageInvalid, validator.ErrValidatorEncryption))
+		vlMk.AssertExpectations(t)
+	})
+
+	t.Run("fail to update password - storage error", func(t *testing.T) {
+		// arrange
+		// - validator: mock
+		vlMk := validator.NewValidatorMock()
+		vlMk.On("ValidatePassword", "password", "johndoe", "johndoe@gmail.com").Return(nil)
+		vlMk.On("PreparePassword", "password").Return("encrypted_password", nil)
+
+		// - storage: mock
+		stMk := storage.NewStorageWriteMock()
+		stMk.On("UpdatePassword", 1, "encrypted_password").Return(storage.ErrStorageNotFound)
+
+		// - storage: validation
+		st := storage.NewStorageWriteValidation(stMk, stored(), vlMk)
+
+		// act
+		err := st.UpdatePassword(1, "password")
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		vlMk.AssertExpectations(t)
+		stMk.AssertExpectations(t)
+	})
+}
+
+// Tests for StorageWriteValidation.Patch
+func TestStorageWriteValidation_Patch(t *testing.T) {
+	// stored user
+	stored := func() *storage.StoreMap {
+		return storage.NewStoreMap(user.User{
+			Id: 1,
+			Username: optional.Some("johndoe"),
+			Password: optional.Some("hashed_password"),
+			Email: optional.Some("johndoe@gmail.com"),
+			IsActive: optional.Some(true),
+			Version: 1,
+		})
+	}
+
+	t.Run("success to patch - only the fields that are some are applied", func(t *testing.T) {
+		// arrange
+		// - crypter: mock
+		cr := crypter.NewCrypterMock()
+
+		// - storage: validation
+		db := stored()
+		st := storage.NewStorageWriteValidation(storage.NewStorageWriteMap(db), storage.NewStorageReadMap(db), validator.NewValidatorDefault("", cr))
+
+		// act
+		u := &user.User{Id: 1, Email: optional.Some("Johnny@gmail.com"), Version: 1}
+		err := st.Patch(u)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, user.User{
+			Id: 1,
+			Username: optional.Some("johndoe"),
+			Password: optional.Some("hashed_password"),
+			Email: optional.Some("Johnny@gmail.com"),
+			EmailCanonical: optional.Some("johnny@gmail.com"),
+			IsActive: optional.Some(true),
+			Version: 2,
+		}, *u)
+		got, err := storage.NewStorageReadMap(db).GetByEmail("johnny@gmail.com")
+		require.NoError(t, err)
+		require.Equal(t, *u, got)
+		cr.AssertExpectations(t)
+	})
+
+	t.Run("success to patch - the password is encrypted if some", func(t *testing.T) {
+		// arrange
+		// - crypter: mock
+		cr := crypter.NewCrypterMock()
+		cr.On("Encrypt", "x9#Kq2!janedoe").Return("encrypted_password", nil)
+
+		// - storage: validation
+		db := stored()
+		st := storage.NewStorageWriteValidation(storage.NewStorageWriteMap(db), storage.NewStorageReadMap(db), validator.NewValidatorDefault("", cr))
+
+		// act
+		u := &user.User{Id: 1, Password: optional.Some("x9#Kq2!janedoe"), Version: 1}
+		err := st.Patch(u)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, optional.Some("encrypted_password"), u.Password)
+		require.Equal(t, optional.Some("johndoe"), u.Username)
+		cr.AssertExpectations(t)
+	})
+
+	t.Run("fail to patch - password contains the stored identifiers", func(t *testing.T) {
+		// arrange
+		// - storage: mock
+		stMk := storage.NewStorageWriteMock()
+
+		// - storage: validation
+		db := stored()
+		st := storage.NewStorageWriteValidation(stMk, storage.NewStorageReadMap(db), validator.NewValidatorDefault("", nil))
+
+		// act
+		err := st.Patch(&user.User{Id: 1, Password: optional.Some("johndoe2024!"), Version: 1})
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageInvalid)
+		require.ErrorIs(t, err, validator.ErrValidatorFieldQuality)
+		require.Contains(t, validator.Fields(err)["password"], "should not contain the username or email")
+		stMk.AssertExpectations(t)
+	})
+
+	t.Run("fail to patch - validate error", func(t *testing.T) {
+		// arrange
+		// - storage: mock
+		stMk := storage.NewStorageWriteMock()
+
+		// - storage: validation
+		st := storage.NewStorageWriteValidation(stMk, nil, validator.NewValidatorDefault("", nil))
+
+		// act
+		err := st.Patch(&user.User{Id: 1, Email: optional.Some("johnnygmail.com"), Version: 1})
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageInvalid)
+		require.EqualError(t, err, "storage: invalid user. validator: field quality - email, invalid format")
+		stMk.AssertExpectations(t)
+	})
+
+	t.Run("fail to patch - stale version", func(t *testing.T) {
+		// arrange
+		// - storage: validation
+		st := storage.NewStorageWriteValidation(storage.NewStorageWriteMap(stored()), nil, validator.NewValidatorDefault("", nil))
+
+		// act
+		err := st.Patch(&user.User{Id: 1, Username: optional.Some("johnny"), Version: 0})
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageConflict)
+	})
+}
@@ -0,0 +1,447 @@
+// Package storagetest implements conformance suites for the user storages
+// - every implementation of storage.StorageRead and storage.StorageWrite should pass them, e.g. a new SQL backend
+// - writes are expected to be versioned, see user.User.Version
+//
+//	func TestStorageSQL(t *testing.T) {
+//		storagetest.RunStorageRead(t, func(t *testing.T, users ...user.User) storage.StorageRead { ... })
+//		storagetest.RunStorageWrite(t, func(t *testing.T) (storage.StorageWrite, storage.StorageRead) { ... })
+//	}
+package storagetest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewStorageRead returns a new storage with some users, keeping their ids
+// - it is called once per case, each case must get an isolated storage
+type NewStorageRead func(t *testing.T, users ...user.User) storage.StorageRead
+
+// NewStorageWrite returns a new empty storage, and a read side over the same data to observe the writes
+// - it is called once per case, each case must get an isolated storage
+type NewStorageWrite func(t *testing.T) (wr storage.StorageWrite, rd storage.StorageRead)
+
+// johnDoe returns the user most cases are run with
+func johnDoe() user.User {
+	return user.User{
+		Username: optional.Some("johndoe"),
+		Password: optional.Some("hashedPassword"),
+		Email: optional.Some("johndoe@gmail.com"),
+		IsActive: optional.Some(false),
+	}
+}
+
+// RunStorageRead runs the conformance suite of storage.StorageRead
+func RunStorageRead(t *testing.T, newStorage NewStorageRead) {
+	seed := johnDoe()
+	seed.Id = 1
+
+	t.Run("get by id", func(t *testing.T) {
+		// arrange
+		st := newStorage(t, seed)
+
+		// act
+		u, err := st.Get(1)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, seed, u)
+	})
+
+	t.Run("get by email", func(t *testing.T) {
+		// arrange
+		st := newStorage(t, seed)
+
+		// act
+		u, err := st.GetByEmail("johndoe@gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, seed, u)
+	})
+
+	t.Run("get by username", func(t *testing.T) {
+		// arrange
+		st := newStorage(t, seed)
+
+		// act
+		u, err := st.GetByUsername("johndoe")
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, seed, u)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		// arrange
+		st := newStorage(t, seed)
+
+		// act & assert
+		_, err := st.Get(2)
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		_, err = st.GetByEmail("janedoe@gmail.com")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		_, err = st.GetByUsername("janedoe")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+	})
+
+	t.Run("not found in an empty storage", func(t *testing.T) {
+		// arrange
+		st := newStorage(t)
+
+		// act & assert
+		_, err := st.Get(1)
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		_, err = st.GetByEmail("johndoe@gmail.com")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		_, err = st.GetByUsername("johndoe")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+	})
+
+	t.Run("concurrent reads", func(t *testing.T) {
+		// arrange
+		st := newStorage(t, seed)
+
+		// act & assert
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				u, err := st.GetByEmail("johndoe@gmail.com")
+				assert.NoError(t, err)
+				assert.Equal(t, seed, u)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+// RunStorageWrite runs the conformance suite of storage.StorageWrite
+func RunStorageWrite(t *testing.T, newStorage NewStorageWrite) {
+	t.Run("created users are readable by id, email and username", func(t *testing.T) {
+		// arrange
+		wr, rd := newStorage(t)
+		u := johnDoe()
+
+		// act
+		err := wr.Create(&u)
+
+		// assert
+		require.NoError(t, err)
+		require.NotZero(t, u.Id)
+		byId, err := rd.Get(u.Id)
+		require.NoError(t, err)
+		require.Equal(t, u, byId)
+		byEmail, err := rd.GetByEmail("johndoe@gmail.com")
+		require.NoError(t, err)
+		require.Equal(t, u, byEmail)
+		byUsername, err := rd.GetByUsername("johndoe")
+		require.NoError(t, err)
+		require.Equal(t, u, byUsername)
+	})
+
+	t.Run("read users are copies", func(t *testing.T) {
+		// arrange
+		wr, rd := newStorage(t)
+		u := johnDoe()
+		require.NoError(t, wr.Create(&u))
+
+		// act
+		u.Password = optional.Some("changed")
+		got, err := rd.Get(u.Id)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, optional.Some("hashedPassword"), got.Password)
+	})
+
+	t.Run("duplicated username or email", func(t *testing.T) {
+		// arrange
+		wr, _ := newStorage(t)
+		u := johnDoe()
+		require.NoError(t, wr.Create(&u))
+
+		// act
+		errUsername := wr.Create(&user.User{Username: optional.Some("johndoe"), Email: optional.Some("other@gmail.com")})
+		errEmail := wr.Create(&user.User{Username: optional.Some("other"), Email: optional.Some("johndoe@gmail.com")})
+
+		// assert
+		require.ErrorIs(t, errUsername, storage.ErrStorageExists)
+		require.ErrorIs(t, errEmail, storage.ErrStorageExists)
+	})
+
+	t.Run("duplicated email on update", func(t *testing.T) {
+		// arrange
+		wr, _ := newStorage(t)
+		a := johnDoe()
+		b := user.User{Username: optional.Some("janedoe"), Email: optional.Some("janedoe@gmail.com")}
+		require.NoError(t, wr.Create(&a))
+		require.NoError(t, wr.Create(&b))
+
+		// act
+		b.Email = optional.Some("johndoe@gmail.com")
+		err := wr.Update(&b)
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageExists)
+	})
+
+	t.Run("updated users are readable with their new values", func(t *testing.T) {
+		// arrange
+		wr, rd := newStorage(t)
+		u := johnDoe()
+		require.NoError(t, wr.Create(&u))
+
+		// act
+		u.Email = optional.Some("johnny@gmail.com")
+		err := wr.Update(&u)
+
+		// assert
+		require.NoError(t, err)
+		got, err := rd.GetByEmail("johnny@gmail.com")
+		require.NoError(t, err)
+		require.Equal(t, u, got)
+		_, err = rd.GetByEmail("johndoe@gmail.com")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+	})
+
+	t.Run("updates are versioned", func(t *testing.T) {
+		// arrange
+		wr, rd := newStorage(t)
+		u := johnDoe()
+		require.NoError(t, wr.Create(&u))
+		version := u.Version
+
+		// act
+		u.Email = optional.Some("johnny@gmail.com")
+		err := wr.Update(&u)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, version + 1, u.Version)
+		got, err := rd.Get(u.Id)
+		require.NoError(t, err)
+		require.Equal(t, u.Version, got.Version)
+	})
+
+	t.Run("stale updates conflict and are not applied", func(t *testing.T) {
+		// arrange
+		wr, rd := newStorage(t)
+		u := johnDoe()
+		require.NoError(t, wr.Create(&u))
+		first, second := u, u
+		first.Email = optional.Some("first@gmail.com")
+		second.Email = optional.Some("second@gmail.com")
+		require.NoError(t, wr.Update(&first))
+
+		// act
+		err := wr.Update(&second)
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageConflict)
+		got, err := rd.Get(u.Id)
+		require.NoError(t, err)
+		require.Equal(t, optional.Some("first@gmail.com"), got.Email)
+	})
+
+	t.Run("partial writes conflict with stale updates", func(t *testing.T) {
+		// arrange
+		wr, _ := newStorage(t)
+		u := johnDoe()
+		require.NoError(t, wr.Create(&u))
+		require.NoError(t, wr.UpdatePassword(u.Id, "newHashedPassword"))
+
+		// act
+		u.Email = optional.Some("johnny@gmail.com")
+		err := wr.Update(&u)
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageConflict)
+	})
+
+	t.Run("patches apply only the fields that are some", func(t *testing.T) {
+		// arrange
+		wr, rd := newStorage(t)
+		u := johnDoe()
+		require.NoError(t, wr.Create(&u))
+
+		// act
+		p := user.User{Id: u.Id, Email: optional.Some("johnny@gmail.com"), Version: u.Version}
+		err := wr.Patch(&p)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, u.Version + 1, p.Version)
+		require.Equal(t, u.Username, p.Username)
+		require.Equal(t, u.Password, p.Password)
+		require.Equal(t, u.IsActive, p.IsActive)
+		got, err := rd.GetByEmail("johnny@gmail.com")
+		require.NoError(t, err)
+		require.Equal(t, p, got)
+		_, err = rd.GetByEmail("johndoe@gmail.com")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+	})
+
+	t.Run("stale patches conflict and are not applied", func(t *testing.T) {
+		// arrange
+		wr, rd := newStorage(t)
+		u := johnDoe()
+		require.NoError(t, wr.Create(&u))
+		require.NoError(t, wr.Activate(u.Id))
+
+		// act
+		p := user.User{Id: u.Id, Username: optional.Some("johnny"), Version: u.Version}
+		err := wr.Patch(&p)
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageConflict)
+		_, err = rd.GetByUsername("johnny")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+	})
+
+	t.Run("duplicated username on patch", func(t *testing.T) {
+		// arrange
+		wr, _ := newStorage(t)
+		a := johnDoe()
+		b := user.User{Username: optional.Some("janedoe"), Email: optional.Some("janedoe@gmail.com")}
+		require.NoError(t, wr.Create(&a))
+		require.NoError(t, wr.Create(&b))
+
+		// act
+		p := user.User{Id: b.Id, Username: optional.Some("johndoe"), Version: b.Version}
+		err := wr.Patch(&p)
+
+		// assert
+		require.ErrorIs(t, err, storage.ErrStorageExists)
+	})
+
+	t.Run("activated users are readable as active", func(t *testing.T) {
+		// arrange
+		wr, rd := newStorage(t)
+		u := johnDoe()
+		require.NoError(t, wr.Create(&u))
+
+		// act
+		err := wr.Activate(u.Id)
+
+		// assert
+		require.NoError(t, err)
+		got, err := rd.Get(u.Id)
+		require.NoError(t, err)
+		require.Equal(t, optional.Some(true), got.IsActive)
+	})
+
+	t.Run("updated passwords are readable", func(t *testing.T) {
+		// arrange
+		wr, rd := newStorage(t)
+		u := johnDoe()
+		require.NoError(t, wr.Create(&u))
+
+		// act
+		err := wr.UpdatePassword(u.Id, "newHashedPassword")
+
+		// assert
+		require.NoError(t, err)
+		got, err := rd.Get(u.Id)
+		require.NoError(t, err)
+		require.Equal(t, optional.Some("newHashedPassword"), got.Password)
+	})
+
+	t.Run("deleted users are not found", func(t *testing.T) {
+		// arrange
+		wr, rd := newStorage(t)
+		u := johnDoe()
+		require.NoError(t, wr.Create(&u))
+
+		// act
+		err := wr.Delete(u.Id)
+
+		// assert
+		require.NoError(t, err)
+		_, err = rd.Get(u.Id)
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		_, err = rd.GetByEmail("johndoe@gmail.com")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		_, err = rd.GetByUsername("johndoe")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		// arrange
+		wr, _ := newStorage(t)
+
+		// act & assert
+		require.ErrorIs(t, wr.Update(&user.User{Id: 1}), storage.ErrStorageNotFound)
+		require.ErrorIs(t, wr.Patch(&user.User{Id: 1}), storage.ErrStorageNotFound)
+		require.ErrorIs(t, wr.Delete(1), storage.ErrStorageNotFound)
+		require.ErrorIs(t, wr.Activate(1), storage.ErrStorageNotFound)
+		require.ErrorIs(t, wr.UpdatePassword(1, "hashedPassword"), storage.ErrStorageNotFound)
+	})
+
+	t.Run("concurrent creates get unique ids", func(t *testing.T) {
+		// arrange
+		wr, _ := newStorage(t)
+		n := 50
+
+		// act
+		var wg sync.WaitGroup
+		ids := make(chan int, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				u := user.User{Username: optional.Some(fmt.Sprintf("user%d", i)), Email: optional.Some(fmt.Sprintf("user%d@gmail.com", i))}
+				assert.NoError(t, wr.Create(&u))
+				ids <- u.Id
+			}(i)
+		}
+		wg.Wait()
+		close(ids)
+
+		// assert
+		unique := make(map[int]struct{})
+		for id := range ids {
+			unique[id] = struct{}{}
+		}
+		require.Len(t, unique, n)
+	})
+
+	t.Run("concurrent duplicated creates, only one succeeds", func(t *testing.T) {
+		// arrange
+		wr, _ := newStorage(t)
+		n := 50
+
+		// act
+		var wg sync.WaitGroup
+		errs := make(chan error, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				u := johnDoe()
+				errs <- wr.Create(&u)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		// assert
+		var created int
+		for err := range errs {
+			if err == nil {
+				created++
+				continue
+			}
+			require.ErrorIs(t, err, storage.ErrStorageExists)
+		}
+		require.Equal(t, 1, created)
+	})
+}
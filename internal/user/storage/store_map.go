@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/LNMMusic/msauth/internal/user"
+)
+
+// NewStoreMap returns a new StoreMap with some users
+// - users are stored as they are, see Put
+func NewStoreMap(users ...user.User) *StoreMap {
+	s := &StoreMap{
+		users: make(map[int]user.User),
+		byUsername: make(map[string]int),
+		byEmail: make(map[string]int),
+		mu: &sync.RWMutex{},
+	}
+	for _, u := range users {
+		s.Put(u)
+	}
+
+	return s
+}
+
+// StoreMap is the in-memory store of the users, shared by StorageReadMap and StorageWriteMap
+// - users are indexed by id, canonical username and canonical email, so lookups do not scan the users
+// - users are stored as values, they are copied in and out of the store
+type StoreMap struct {
+	// users are the users by id
+	users map[int]user.User
+	// byUsername is the index of the users by username
+	// - key: canonical username, or the username for users stored without it
+	// - value: user id
+	byUsername map[string]int
+	// byEmail is the index of the users by email
+	// - key: canonical email, or the email for users stored without it
+	// - value: user id
+	byEmail map[string]int
+
+	// lastId is the last id of the users
+	lastId int
+
+	// mu guards the users, the indexes and the last id
+	mu *sync.RWMutex
+}
+
+// Put stores a user as it is, replacing the user with the same id
+// - unlike insert and replace, the id is kept and uniqueness is not checked, e.g. to seed the store
+func (s *StoreMap) Put(u user.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.users[u.Id]; ok {
+		s.unindex(old)
+	}
+	s.users[u.Id] = u
+	s.index(u)
+	s.lastId = max(s.lastId, u.Id)
+}
+
+// get returns a user by id
+func (s *StoreMap) get(id int) (u user.User, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		err = fmt.Errorf("%w - %d", ErrStorageNotFound, id)
+		return
+	}
+
+	return
+}
+
+// getByUsername returns a user by its canonical username
+func (s *StoreMap) getByUsername(username string) (u user.User, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.byUsername[username]
+	if !ok {
+		err = fmt.Errorf("%w - %s", ErrStorageNotFound, username)
+		return
+	}
+	u = s.users[id]
+
+	return
+}
+
+// getByEmail returns a user by its canonical email
+func (s *StoreMap) getByEmail(email string) (u user.User, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.byEmail[email]
+	if !ok {
+		err = fmt.Errorf("%w - %s", ErrStorageNotFound, email)
+		return
+	}
+	u = s.users[id]
+
+	return
+}
+
+// insert stores a new user with the next id and the first version
+// - the username and email must be unique
+func (s *StoreMap) insert(u *user.User) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.unique(*u)
+	if err != nil {
+		return
+	}
+
+	s.lastId++
+	(*u).Id = s.lastId
+	(*u).Version = 1
+	s.users[u.Id] = *u
+	s.index(*u)
+
+	return
+}
+
+// replace replaces an existing user and increments its version
+// - the version of the user must be the stored one
+// - the username and email must remain unique
+func (s *StoreMap) replace(u *user.User) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.users[u.Id]
+	if !ok {
+		err = fmt.Errorf("%w - %d", ErrStorageNotFound, u.Id)
+		return
+	}
+	if old.Version != u.Version {
+		err = fmt.Errorf("%w - %d, version %d, stored %d", ErrStorageConflict, u.Id, u.Version, old.Version)
+		return
+	}
+	err = s.unique(*u)
+	if err != nil {
+		return
+	}
+
+	(*u).Version++
+	s.unindex(old)
+	s.users[u.Id] = *u
+	s.index(*u)
+
+	return
+}
+
+// patch applies the fields that are some of a partial user to an existing user and increments its version
+// - the version of the user must be the stored one
+// - the username and email must remain unique
+// - on success, u is set to the patched user
+func (s *StoreMap) patch(u *user.User) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.users[u.Id]
+	if !ok {
+		err = fmt.Errorf("%w - %d", ErrStorageNotFound, u.Id)
+		return
+	}
+	if old.Version != u.Version {
+		err = fmt.Errorf("%w - %d, version %d, stored %d", ErrStorageConflict, u.Id, u.Version, old.Version)
+		return
+	}
+
+	// merge
+	// - a new identifier without its canonical form drops the old one, so the user is looked up by the new one
+	patched := old
+	if u.Username.IsSome() {
+		patched.Username = u.Username
+		patched.UsernameCanonical = u.UsernameCanonical
+	}
+	if u.Email.IsSome() {
+		patched.Email = u.Email
+		patched.EmailCanonical = u.EmailCanonical
+	}
+	if u.Password.IsSome() {
+		patched.Password = u.Password
+	}
+	if u.IsActive.IsSome() {
+		patched.IsActive = u.IsActive
+	}
+	err = s.unique(patched)
+	if err != nil {
+		return
+	}
+
+	patched.Version++
+	s.unindex(old)
+	s.users[u.Id] = patched
+	s.index(patched)
+	*u = patched
+
+	return
+}
+
+// modify applies a change to an existing user, atomically, and increments its version
+// - the change must not modify the username nor the email
+func (s *StoreMap) modify(id int, change func(u *user.User)) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		err = fmt.Errorf("%w - %d", ErrStorageNotFound, id)
+		return
+	}
+	change(&u)
+	u.Version++
+	s.users[id] = u
+
+	return
+}
+
+// remove deletes an existing user
+func (s *StoreMap) remove(id int) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		err = fmt.Errorf("%w - %d", ErrStorageNotFound, id)
+		return
+	}
+	s.unindex(u)
+	delete(s.users, id)
+
+	return
+}
+
+// unique checks that no other user has the username or the email of a user
+// - it must be called with the mutex locked
+func (s *StoreMap) unique(u user.User) (err error) {
+	if username, ok := lookupUsername(u); ok {
+		if id, found := s.byUsername[username]; found && id != u.Id {
+			err = fmt.Errorf("%w - username", ErrStorageExists)
+			return
+		}
+	}
+	if email, ok := lookupEmail(u); ok {
+		if id, found := s.byEmail[email]; found && id != u.Id {
+			err = fmt.Errorf("%w - email", ErrStorageExists)
+			return
+		}
+	}
+
+	return
+}
+
+// index adds a user to the indexes
+// - it must be called with the mutex locked
+func (s *StoreMap) index(u user.User) {
+	if username, ok := lookupUsername(u); ok {
+		s.byUsername[username] = u.Id
+	}
+	if email, ok := lookupEmail(u); ok {
+		s.byEmail[email] = u.Id
+	}
+}
+
+// unindex removes a user from the indexes
+// - it must be called with the mutex locked
+func (s *StoreMap) unindex(u user.User) {
+	if username, ok := lookupUsername(u); ok && s.byUsername[username] == u.Id {
+		delete(s.byUsername, username)
+	}
+	if email, ok := lookupEmail(u); ok && s.byEmail[email] == u.Id {
+		delete(s.byEmail, email)
+	}
+}
+
+// lookupEmail returns the email a user is looked up by: the canonical one, or the email as it is for users stored without it
+func lookupEmail(u user.User) (email string, ok bool) {
+	if u.EmailCanonical.IsSome() {
+		email, _ = u.EmailCanonical.Unwrap()
+		ok = true
+		return
+	}
+	if u.Email.IsSome() {
+		email, _ = u.Email.Unwrap()
+		ok = true
+	}
+	return
+}
+
+// lookupUsername returns the username a user is looked up by: the canonical one, or the username as it is for users stored without it
+func lookupUsername(u user.User) (username string, ok bool) {
+	if u.UsernameCanonical.IsSome() {
+		username, _ = u.UsernameCanonical.Unwrap()
+		ok = true
+		return
+	}
+	if u.Username.IsSome() {
+		username, _ = u.Username.Unwrap()
+		ok = true
+	}
+	return
+}
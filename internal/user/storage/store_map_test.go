@@ -0,0 +1,90 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for the indexes of StoreMap, shared by StorageReadMap and StorageWriteMap
+func TestStoreMap_Indexes(t *testing.T) {
+	t.Run("writes are visible to the read side", func(t *testing.T) {
+		// arrange
+		db := storage.NewStoreMap()
+		wr := storage.NewStorageWriteMap(db)
+		rd := storage.NewStorageReadMap(db)
+
+		// act
+		u := &user.User{Username: optional.Some("johndoe"), Email: optional.Some("johndoe@gmail.com"), IsActive: optional.Some(false)}
+		err := wr.Create(u)
+		require.NoError(t, err)
+		err = wr.Activate(u.Id)
+		require.NoError(t, err)
+
+		// assert
+		byEmail, err := rd.GetByEmail("johndoe@gmail.com")
+		require.NoError(t, err)
+		require.Equal(t, optional.Some(true), byEmail.IsActive)
+		byUsername, err := rd.GetByUsername("johndoe")
+		require.NoError(t, err)
+		require.Equal(t, u.Id, byUsername.Id)
+	})
+
+	t.Run("updates move the user in the indexes", func(t *testing.T) {
+		// arrange
+		db := storage.NewStoreMap(user.User{Id: 1, Username: optional.Some("johndoe"), Email: optional.Some("johndoe@gmail.com")})
+		wr := storage.NewStorageWriteMap(db)
+		rd := storage.NewStorageReadMap(db)
+
+		// act
+		err := wr.Update(&user.User{Id: 1, Username: optional.Some("johnny"), Email: optional.Some("johnny@gmail.com")})
+
+		// assert
+		require.NoError(t, err)
+		_, err = rd.GetByEmail("johndoe@gmail.com")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		_, err = rd.GetByUsername("johndoe")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		u, err := rd.GetByEmail("johnny@gmail.com")
+		require.NoError(t, err)
+		require.Equal(t, 1, u.Id)
+
+		// - the old identifiers are free again
+		err = wr.Create(&user.User{Username: optional.Some("johndoe"), Email: optional.Some("johndoe@gmail.com")})
+		require.NoError(t, err)
+	})
+
+	t.Run("deletes remove the user from the indexes", func(t *testing.T) {
+		// arrange
+		db := storage.NewStoreMap(user.User{Id: 1, Username: optional.Some("johndoe"), Email: optional.Some("johndoe@gmail.com")})
+		wr := storage.NewStorageWriteMap(db)
+		rd := storage.NewStorageReadMap(db)
+
+		// act
+		err := wr.Delete(1)
+
+		// assert
+		require.NoError(t, err)
+		_, err = rd.GetByEmail("johndoe@gmail.com")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+		_, err = rd.GetByUsername("johndoe")
+		require.ErrorIs(t, err, storage.ErrStorageNotFound)
+	})
+
+	t.Run("ids continue after the seeded users", func(t *testing.T) {
+		// arrange
+		db := storage.NewStoreMap(user.User{Id: 7, Username: optional.Some("johndoe"), Email: optional.Some("johndoe@gmail.com")})
+		wr := storage.NewStorageWriteMap(db)
+
+		// act
+		u := &user.User{Username: optional.Some("janedoe"), Email: optional.Some("janedoe@gmail.com")}
+		err := wr.Create(u)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, 8, u.Id)
+	})
+}
@@ -0,0 +1,33 @@
+package twofactor
+
+import "errors"
+
+var (
+	// ErrStorageSecretNotFound is returned when a secret is not found
+	ErrStorageSecretNotFound = errors.New("twofactor: storage secret not found")
+)
+
+// Secret is the two factor secret of a user
+type Secret struct {
+	// UserId is the id of the user
+	UserId 		int
+	// Sealed is the encrypted secret
+	Sealed 		[]byte
+	// Enabled is true once the secret is confirmed
+	Enabled 	bool
+	// LastStep is the last time-step used, codes of previous steps are rejected
+	LastStep 	int64
+}
+
+// Storage interface to handle two factor secrets in the database
+// - there is at most one secret per user
+type Storage interface {
+	// Get returns the secret of a user
+	Get(userId int) (s Secret, err error)
+
+	// Set sets the secret of a user, replacing the previous one
+	Set(s Secret) (err error)
+
+	// Delete deletes the secret of a user
+	Delete(userId int) (err error)
+}
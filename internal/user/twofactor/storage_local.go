@@ -0,0 +1,56 @@
+package twofactor
+
+import (
+	"fmt"
+	"sync"
+)
+
+// NewStorageLocal returns a new StorageLocal
+func NewStorageLocal() *StorageLocal {
+	return &StorageLocal{
+		db: make(map[int]Secret),
+		mu: &sync.RWMutex{},
+	}
+}
+
+// StorageLocal is an in-memory implementation of the Storage interface
+type StorageLocal struct {
+	// db is the map of secrets
+	// - key: user id
+	// - value: secret
+	db map[int]Secret
+	// mu is the mutex of the db
+	mu *sync.RWMutex
+}
+
+// Get returns the secret of a user
+func (s *StorageLocal) Get(userId int) (sc Secret, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sc, ok := s.db[userId]
+	if !ok {
+		err = fmt.Errorf("%w - %d", ErrStorageSecretNotFound, userId)
+		return
+	}
+
+	return
+}
+
+// Set sets the secret of a user, replacing the previous one
+func (s *StorageLocal) Set(sc Secret) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.db[sc.UserId] = sc
+	return
+}
+
+// Delete deletes the secret of a user
+func (s *StorageLocal) Delete(userId int) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.db, userId)
+	return
+}
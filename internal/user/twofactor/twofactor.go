@@ -0,0 +1,39 @@
+package twofactor
+
+import "errors"
+
+var (
+	// ErrTwoFactorInternal is returned when an internal error occurs
+	ErrTwoFactorInternal = errors.New("twofactor: internal error")
+	// ErrTwoFactorNotEnrolled is returned when the user has no two factor secret, or it is not confirmed
+	ErrTwoFactorNotEnrolled = errors.New("twofactor: user not enrolled")
+	// ErrTwoFactorAlreadyEnabled is returned when the user already has two factor enabled
+	ErrTwoFactorAlreadyEnabled = errors.New("twofactor: already enabled")
+	// ErrTwoFactorCodeInvalid is returned when a code is wrong, expired or already used
+	ErrTwoFactorCodeInvalid = errors.New("twofactor: invalid code")
+)
+
+// Enrollment is the data the user needs to set up an authenticator app
+type Enrollment struct {
+	// Secret is the base32 encoded secret, for manual entry
+	Secret 	string
+	// URI is the otpauth:// key uri, the payload of the QR code
+	URI 	string
+}
+
+// TwoFactor interface to handle the two factor authentication of the users
+type TwoFactor interface {
+	// Enroll generates a new pending secret for a user
+	// - two factor is not enabled until the secret is confirmed with a valid code
+	Enroll(userId int, account string) (e Enrollment, err error)
+
+	// Confirm enables two factor for a user with a code of its pending secret
+	Confirm(userId int, code string) (err error)
+
+	// Verify verifies a code of a user with two factor enabled
+	// - each code can only be used once
+	Verify(userId int, code string) (err error)
+
+	// Enabled returns whether a user has two factor enabled
+	Enabled(userId int) (enabled bool, err error)
+}
@@ -0,0 +1,44 @@
+package twofactor
+
+import "github.com/stretchr/testify/mock"
+
+// NewTwoFactorMock returns a new mock two factor
+func NewTwoFactorMock() *TwoFactorMock {
+	return &TwoFactorMock{}
+}
+
+// TwoFactorMock is a mock implementation of the TwoFactor interface
+type TwoFactorMock struct {
+	// mock.Mock is a struct that implements the Mock struct for testing purposes
+	mock.Mock
+}
+
+// Enroll generates a new pending secret for a user
+func (m *TwoFactorMock) Enroll(userId int, account string) (e Enrollment, err error) {
+	args := m.Called(userId, account)
+	e = args.Get(0).(Enrollment)
+	err = args.Error(1)
+	return
+}
+
+// Confirm enables two factor for a user with a code of its pending secret
+func (m *TwoFactorMock) Confirm(userId int, code string) (err error) {
+	args := m.Called(userId, code)
+	err = args.Error(0)
+	return
+}
+
+// Verify verifies a code of a user with two factor enabled
+func (m *TwoFactorMock) Verify(userId int, code string) (err error) {
+	args := m.Called(userId, code)
+	err = args.Error(0)
+	return
+}
+
+// Enabled returns whether a user has two factor enabled
+func (m *TwoFactorMock) Enabled(userId int) (enabled bool, err error) {
+	args := m.Called(userId)
+	enabled = args.Bool(0)
+	err = args.Error(1)
+	return
+}
@@ -0,0 +1,205 @@
+package twofactor
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/LNMMusic/msauth/pkg/cipher"
+	"github.com/LNMMusic/msauth/pkg/totp"
+	"github.com/LNMMusic/optional"
+)
+
+// NewTwoFactorTOTP returns a new TwoFactorTOTP
+func NewTwoFactorTOTP(st Storage, cp cipher.Cipher, config *ConfigTOTP) *TwoFactorTOTP {
+	// default values
+	if !config.Skew.IsSome() {
+		config.Skew = optional.Some(1)
+	}
+
+	return &TwoFactorTOTP{
+		st: st,
+		cp: cp,
+		config: config,
+		mu: &sync.Mutex{},
+		now: time.Now,
+	}
+}
+
+// ConfigTOTP is the configuration of TwoFactorTOTP
+type ConfigTOTP struct {
+	// Issuer is the name of the service shown by the authenticator apps
+	Issuer 	string
+	// Skew is the number of time-steps accepted before and after the current one (default 1)
+	Skew 	optional.Option[int]
+}
+
+// TwoFactorTOTP is the implementation of the TwoFactor interface with time-based one-time passwords (RFC 6238)
+// - secrets are stored encrypted with the cipher
+// - the last used time-step is stored, so a code can not be replayed
+type TwoFactorTOTP struct {
+	// st is the storage of the secrets
+	st Storage
+	// cp is the cipher to encrypt the secrets at rest
+	cp cipher.Cipher
+	// config is the configuration of the codes
+	config *ConfigTOTP
+	// mu serializes the verifications, so a time-step can only be used once
+	mu *sync.Mutex
+	// now returns the current date
+	now func() time.Time
+}
+
+// Enroll generates a new pending secret for a user
+func (t *TwoFactorTOTP) Enroll(userId int, account string) (e Enrollment, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// check current secret
+	sc, err := t.st.Get(userId)
+	if err != nil && !errors.Is(err, ErrStorageSecretNotFound) {
+		err = fmt.Errorf("%w. %s", ErrTwoFactorInternal, err.Error())
+		return
+	}
+	if err == nil && sc.Enabled {
+		err = ErrTwoFactorAlreadyEnabled
+		return
+	}
+
+	// generate secret
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrTwoFactorInternal, err.Error())
+		return
+	}
+	sealed, err := t.cp.Encrypt([]byte(secret))
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrTwoFactorInternal, err.Error())
+		return
+	}
+	err = t.st.Set(Secret{UserId: userId, Sealed: sealed})
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrTwoFactorInternal, err.Error())
+		return
+	}
+
+	e = Enrollment{
+		Secret: secret,
+		URI: totp.URI(t.config.Issuer, account, secret),
+	}
+	return
+}
+
+// Confirm enables two factor for a user with a code of its pending secret
+func (t *TwoFactorTOTP) Confirm(userId int, code string) (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sc, err := t.get(userId)
+	if err != nil {
+		return
+	}
+	if sc.Enabled {
+		err = ErrTwoFactorAlreadyEnabled
+		return
+	}
+
+	err = t.use(&sc, code)
+	if err != nil {
+		return
+	}
+	sc.Enabled = true
+	err = t.st.Set(sc)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrTwoFactorInternal, err.Error())
+		return
+	}
+
+	return
+}
+
+// Verify verifies a code of a user with two factor enabled
+func (t *TwoFactorTOTP) Verify(userId int, code string) (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sc, err := t.get(userId)
+	if err != nil {
+		return
+	}
+	if !sc.Enabled {
+		err = fmt.Errorf("%w. secret not confirmed", ErrTwoFactorNotEnrolled)
+		return
+	}
+
+	err = t.use(&sc, code)
+	if err != nil {
+		return
+	}
+	err = t.st.Set(sc)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrTwoFactorInternal, err.Error())
+		return
+	}
+
+	return
+}
+
+// Enabled returns whether a user has two factor enabled
+func (t *TwoFactorTOTP) Enabled(userId int) (enabled bool, err error) {
+	sc, err := t.st.Get(userId)
+	if err != nil {
+		if errors.Is(err, ErrStorageSecretNotFound) {
+			err = nil
+			return
+		}
+		err = fmt.Errorf("%w. %s", ErrTwoFactorInternal, err.Error())
+		return
+	}
+
+	enabled = sc.Enabled
+	return
+}
+
+// get returns the secret of a user
+func (t *TwoFactorTOTP) get(userId int) (sc Secret, err error) {
+	sc, err = t.st.Get(userId)
+	if err != nil {
+		if errors.Is(err, ErrStorageSecretNotFound) {
+			err = fmt.Errorf("%w. %s", ErrTwoFactorNotEnrolled, err.Error())
+			return
+		}
+		err = fmt.Errorf("%w. %s", ErrTwoFactorInternal, err.Error())
+		return
+	}
+
+	return
+}
+
+// use validates a code of a secret and records its time-step as used
+func (t *TwoFactorTOTP) use(sc *Secret, code string) (err error) {
+	secret, err := t.cp.Decrypt(sc.Sealed)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrTwoFactorInternal, err.Error())
+		return
+	}
+
+	skew, _ := t.config.Skew.Unwrap()
+	step, ok, err := totp.Validate(string(secret), code, t.now(), skew)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrTwoFactorInternal, err.Error())
+		return
+	}
+	if !ok {
+		err = ErrTwoFactorCodeInvalid
+		return
+	}
+	if step <= sc.LastStep {
+		err = fmt.Errorf("%w. code already used", ErrTwoFactorCodeInvalid)
+		return
+	}
+
+	sc.LastStep = step
+	return
+}
@@ -0,0 +1,156 @@
+package twofactor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/pkg/cipher"
+	"github.com/LNMMusic/msauth/pkg/totp"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// newTwoFactorTOTP returns a TwoFactorTOTP with a controlled clock
+func newTwoFactorTOTP(t *testing.T, now *time.Time) (tf *TwoFactorTOTP, st *StorageLocal) {
+	cp, err := cipher.NewCipherAESGCM([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	st = NewStorageLocal()
+	tf = NewTwoFactorTOTP(st, cp, &ConfigTOTP{Issuer: "msauth", Skew: optional.Some(1)})
+	tf.now = func() time.Time { return *now }
+	return
+}
+
+// code returns the code of a secret at a date
+func code(t *testing.T, secret string, at time.Time) string {
+	c, err := totp.Code(secret, totp.Step(at))
+	require.NoError(t, err)
+	return c
+}
+
+// Tests for TwoFactorTOTP
+func TestTwoFactorTOTP(t *testing.T) {
+	t.Run("success - enroll, confirm and verify", func(t *testing.T) {
+		// arrange
+		now := time.Unix(1700000000, 0)
+		tf, st := newTwoFactorTOTP(t, &now)
+
+		// act
+		e, err := tf.Enroll(1, "johndoe@gmail.com")
+		require.NoError(t, err)
+		enabledPending, err := tf.Enabled(1)
+		require.NoError(t, err)
+		errConfirm := tf.Confirm(1, code(t, e.Secret, now))
+		enabled, err := tf.Enabled(1)
+		require.NoError(t, err)
+		now = now.Add(totp.Period)
+		errVerify := tf.Verify(1, code(t, e.Secret, now))
+
+		// assert
+		require.Contains(t, e.URI, "otpauth://totp/msauth:johndoe@gmail.com?")
+		require.False(t, enabledPending)
+		require.NoError(t, errConfirm)
+		require.True(t, enabled)
+		require.NoError(t, errVerify)
+		sc, err := st.Get(1)
+		require.NoError(t, err)
+		require.NotContains(t, string(sc.Sealed), e.Secret, "the secret must be stored encrypted")
+	})
+
+	t.Run("failure - verify before confirm", func(t *testing.T) {
+		// arrange
+		now := time.Unix(1700000000, 0)
+		tf, _ := newTwoFactorTOTP(t, &now)
+		e, err := tf.Enroll(1, "johndoe@gmail.com")
+		require.NoError(t, err)
+
+		// act
+		err = tf.Verify(1, code(t, e.Secret, now))
+
+		// assert
+		require.ErrorIs(t, err, ErrTwoFactorNotEnrolled)
+	})
+
+	t.Run("failure - user not enrolled", func(t *testing.T) {
+		// arrange
+		now := time.Unix(1700000000, 0)
+		tf, _ := newTwoFactorTOTP(t, &now)
+
+		// act
+		err := tf.Verify(1, "123456")
+		errConfirm := tf.Confirm(1, "123456")
+
+		// assert
+		require.ErrorIs(t, err, ErrTwoFactorNotEnrolled)
+		require.ErrorIs(t, errConfirm, ErrTwoFactorNotEnrolled)
+	})
+
+	t.Run("failure - wrong code", func(t *testing.T) {
+		// arrange
+		now := time.Unix(1700000000, 0)
+		tf, _ := newTwoFactorTOTP(t, &now)
+		e, err := tf.Enroll(1, "johndoe@gmail.com")
+		require.NoError(t, err)
+		wrong := "000000"
+		if wrong == code(t, e.Secret, now) {
+			wrong = "111111"
+		}
+
+		// act
+		err = tf.Confirm(1, wrong)
+
+		// assert
+		require.ErrorIs(t, err, ErrTwoFactorCodeInvalid)
+	})
+
+	t.Run("failure - code replayed in the same time-step", func(t *testing.T) {
+		// arrange
+		now := time.Unix(1700000000, 0)
+		tf, _ := newTwoFactorTOTP(t, &now)
+		e, err := tf.Enroll(1, "johndoe@gmail.com")
+		require.NoError(t, err)
+		require.NoError(t, tf.Confirm(1, code(t, e.Secret, now)))
+		now = now.Add(totp.Period)
+		c := code(t, e.Secret, now)
+		require.NoError(t, tf.Verify(1, c))
+
+		// act
+		err = tf.Verify(1, c)
+
+		// assert
+		require.ErrorIs(t, err, ErrTwoFactorCodeInvalid)
+		require.EqualError(t, err, "twofactor: invalid code. code already used")
+	})
+
+	t.Run("failure - code of a previous step after a newer one was used", func(t *testing.T) {
+		// arrange
+		now := time.Unix(1700000000, 0)
+		tf, _ := newTwoFactorTOTP(t, &now)
+		e, err := tf.Enroll(1, "johndoe@gmail.com")
+		require.NoError(t, err)
+		require.NoError(t, tf.Confirm(1, code(t, e.Secret, now)))
+		now = now.Add(2 * totp.Period)
+		previous := code(t, e.Secret, now.Add(-totp.Period))
+		require.NoError(t, tf.Verify(1, code(t, e.Secret, now)))
+
+		// act
+		err = tf.Verify(1, previous)
+
+		// assert
+		require.ErrorIs(t, err, ErrTwoFactorCodeInvalid)
+	})
+
+	t.Run("failure - enroll when already enabled", func(t *testing.T) {
+		// arrange
+		now := time.Unix(1700000000, 0)
+		tf, _ := newTwoFactorTOTP(t, &now)
+		e, err := tf.Enroll(1, "johndoe@gmail.com")
+		require.NoError(t, err)
+		require.NoError(t, tf.Confirm(1, code(t, e.Secret, now)))
+
+		// act
+		_, err = tf.Enroll(1, "johndoe@gmail.com")
+
+		// assert
+		require.ErrorIs(t, err, ErrTwoFactorAlreadyEnabled)
+	})
+}
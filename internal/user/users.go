@@ -15,6 +15,15 @@ type User struct {
 	Password 	optional.Option[string]
 	// Email is the email of the user
 	Email 		optional.Option[string]
+	// UsernameCanonical is the normalized username, used to look the user up
+	// - Username is kept as the user typed it, for display
+	UsernameCanonical 	optional.Option[string]
+	// EmailCanonical is the normalized email, used to look the user up
+	// - Email is kept as the user typed it, for display and delivery
+	EmailCanonical 		optional.Option[string]
 	// IsActive is the status of the user
 	IsActive 	optional.Option[bool]
+	// Version is the version of the user, incremented by the storage on each write
+	// - updates must carry the version the user was read with, so concurrent changes are not lost
+	Version 	int
 }
\ No newline at end of file
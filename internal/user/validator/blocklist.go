@@ -0,0 +1,52 @@
+package validator
+
+import (
+	_ "embed"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrValidatorBlocklist is returned when a blocklist cannot be loaded
+	ErrValidatorBlocklist = errors.New("validator: cannot load blocklist")
+	// ErrValidatorBlocklistConfig is returned when a blocklist is configured with invalid values
+	ErrValidatorBlocklistConfig = errors.New("validator: invalid blocklist config")
+)
+
+// Blocklist interface for sets of passwords that must not be used
+// - e.g. common passwords, passwords found in data breaches
+type Blocklist interface {
+	// Contains returns whether a normalized password is in the blocklist
+	Contains(password string) (found bool)
+}
+
+// commonPasswords is the list of the most common passwords, one per line, lowercase
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// NewBlocklistCommon returns a new BlocklistCommon
+func NewBlocklistCommon() *BlocklistCommon {
+	lines := strings.Fields(commonPasswords)
+	passwords := make(map[string]struct{}, len(lines))
+	for _, p := range lines {
+		passwords[p] = struct{}{}
+	}
+
+	return &BlocklistCommon{
+		passwords: passwords,
+	}
+}
+
+// BlocklistCommon is the implementation of the Blocklist interface with the most common passwords
+// - the list is compiled into the binary
+// - passwords are compared case insensitive, "Password123" is as weak as "password123"
+type BlocklistCommon struct {
+	// passwords is the set of common passwords
+	passwords map[string]struct{}
+}
+
+// Contains returns whether a password is a common password
+func (b *BlocklistCommon) Contains(password string) (found bool) {
+	_, found = b.passwords[strings.ToLower(password)]
+	return
+}
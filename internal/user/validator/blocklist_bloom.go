@@ -0,0 +1,165 @@
+package validator
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// NewBlocklistBloom returns a new empty BlocklistBloom sized for n passwords
+// - falsePositiveRate is the probability of a password not in the blocklist to be reported as found (e.g. 0.001)
+// - n must be positive and falsePositiveRate within (0, 1), otherwise the filter can not be sized
+func NewBlocklistBloom(n int, falsePositiveRate float64) (b *BlocklistBloom, err error) {
+	// validate
+	if n <= 0 {
+		err = fmt.Errorf("%w. n must be greater than 0", ErrValidatorBlocklistConfig)
+		return
+	}
+	if !(falsePositiveRate > 0 && falsePositiveRate < 1) {
+		err = fmt.Errorf("%w. false positive rate must be between 0 and 1", ErrValidatorBlocklistConfig)
+		return
+	}
+
+	// optimal size and number of hash functions
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := max(1, int(math.Round(float64(m) / float64(n) * math.Ln2)))
+
+	b = &BlocklistBloom{
+		bits: make([]uint64, (m + 63) / 64),
+		m: m,
+		k: k,
+	}
+	return
+}
+
+// LoadBlocklistBloom loads a breached password corpus from a file into a BlocklistBloom
+// - each line is the uppercase or lowercase hex SHA-1 of a password, optionally followed by ":count"
+//   (the format of the Have I Been Pwned offline downloads)
+// - the file is read twice, to count the lines and size the filter
+func LoadBlocklistBloom(path string, falsePositiveRate float64) (b *BlocklistBloom, err error) {
+	// count
+	n, err := readHashes(path, nil)
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = fmt.Errorf("%w. empty corpus", ErrValidatorBlocklist)
+		return
+	}
+
+	// load
+	bl, err := NewBlocklistBloom(n, falsePositiveRate)
+	if err != nil {
+		return
+	}
+	_, err = readHashes(path, bl.AddHash)
+	if err != nil {
+		return
+	}
+
+	b = bl
+	return
+}
+
+// BlocklistBloom is the implementation of the Blocklist interface with a bloom filter of SHA-1 hashes
+// - it takes ~1.2 bytes per password at a false positive rate of 0.1%, instead of the 20 of the hashes
+// - there are no false negatives, a password reported as not found is not in the corpus
+type BlocklistBloom struct {
+	// bits is the bit array of the filter
+	bits []uint64
+	// m is the number of bits of the filter
+	m uint64
+	// k is the number of hash functions
+	k int
+}
+
+// Add adds a password to the blocklist
+func (b *BlocklistBloom) Add(password string) {
+	b.AddHash(sha1.Sum([]byte(password)))
+}
+
+// AddHash adds the SHA-1 hash of a password to the blocklist
+func (b *BlocklistBloom) AddHash(h [sha1.Size]byte) {
+	for _, i := range b.indexes(h) {
+		b.bits[i / 64] |= 1 << (i % 64)
+	}
+}
+
+// Contains returns whether a password is in the blocklist
+func (b *BlocklistBloom) Contains(password string) (found bool) {
+	for _, i := range b.indexes(sha1.Sum([]byte(password))) {
+		if b.bits[i / 64] & (1 << (i % 64)) == 0 {
+			return
+		}
+	}
+
+	found = true
+	return
+}
+
+// indexes returns the bit indexes of a hash
+// - the SHA-1 hash is already uniform, so its halves are used for double hashing
+func (b *BlocklistBloom) indexes(h [sha1.Size]byte) (idx []uint64) {
+	h1 := binary.BigEndian.Uint64(h[0:8])
+	h2 := binary.BigEndian.Uint64(h[8:16]) | 1
+	idx = make([]uint64, b.k)
+	for i := range idx {
+		idx[i] = (h1 + uint64(i) * h2) % b.m
+	}
+	return
+}
+
+// readHashes reads the hashes of a corpus file, calling fn for each one if not nil
+func readHashes(path string, fn func(h [sha1.Size]byte)) (n int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrValidatorBlocklist, err.Error())
+		return
+	}
+	defer f.Close()
+
+	n, err = scanHashes(f, fn)
+	return
+}
+
+// scanHashes reads the hashes of a corpus, calling fn for each one if not nil
+func scanHashes(r io.Reader, fn func(h [sha1.Size]byte)) (n int, err error) {
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		hash, _, _ := strings.Cut(text, ":")
+
+		var h [sha1.Size]byte
+		if len(hash) != 2 * sha1.Size {
+			err = fmt.Errorf("%w. line %d, invalid SHA-1 hash", ErrValidatorBlocklist, line)
+			return
+		}
+		_, err = hex.Decode(h[:], []byte(hash))
+		if err != nil {
+			err = fmt.Errorf("%w. line %d, %s", ErrValidatorBlocklist, line, err.Error())
+			return
+		}
+
+		if fn != nil {
+			fn(h)
+		}
+		n++
+	}
+	err = sc.Err()
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrValidatorBlocklist, err.Error())
+		return
+	}
+
+	return
+}
@@ -0,0 +1,248 @@
+package validator_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user/validator"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCorpus writes a corpus file with the SHA-1 hashes of the passwords, in the HIBP format
+func writeCorpus(t *testing.T, passwords ...string) (path string) {
+	var sb strings.Builder
+	for i, p := range passwords {
+		sum := sha1.Sum([]byte(p))
+		fmt.Fprintf(&sb, "%s:%d\n", strings.ToUpper(hex.EncodeToString(sum[:])), i+1)
+	}
+	path = filepath.Join(t.TempDir(), "corpus.txt")
+	require.NoError(t, os.WriteFile(path, []byte(sb.String()), 0o600))
+	return
+}
+
+// Tests for BlocklistCommon
+func TestBlocklistCommon_Contains(t *testing.T) {
+	// arrange
+	b := validator.NewBlocklistCommon()
+
+	// act & assert
+	require.True(t, b.Contains("password123"))
+	require.True(t, b.Contains("Password123"))
+	require.True(t, b.Contains("qwerty"))
+	require.False(t, b.Contains("correct-horse-battery-staple"))
+}
+
+// Tests for BlocklistBloom
+func TestBlocklistBloom(t *testing.T) {
+	t.Run("success - corpus loaded", func(t *testing.T) {
+		// arrange
+		breached := make([]string, 0, 1000)
+		for i := 0; i < 1000; i++ {
+			breached = append(breached, fmt.Sprintf("breached-%d", i))
+		}
+		path := writeCorpus(t, breached...)
+
+		// act
+		b, err := validator.LoadBlocklistBloom(path, 0.001)
+
+		// assert
+		require.NoError(t, err)
+		// - no false negatives
+		for _, p := range breached {
+			require.True(t, b.Contains(p), p)
+		}
+		// - false positives close to the rate
+		fp := 0
+		for i := 0; i < 10000; i++ {
+			if b.Contains(fmt.Sprintf("safe-%d", i)) {
+				fp++
+			}
+		}
+		require.Less(t, fp, 50)
+	})
+
+	t.Run("failure - invalid hash", func(t *testing.T) {
+		// arrange
+		path := filepath.Join(t.TempDir(), "corpus.txt")
+		require.NoError(t, os.WriteFile(path, []byte("5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3\nnot-a-hash:1\n"), 0o600))
+
+		// act
+		b, err := validator.LoadBlocklistBloom(path, 0.001)
+
+		// assert
+		require.Nil(t, b)
+		require.ErrorIs(t, err, validator.ErrValidatorBlocklist)
+		require.EqualError(t, err, "validator: cannot load blocklist. line 2, invalid SHA-1 hash")
+	})
+
+	t.Run("failure - file not found", func(t *testing.T) {
+		// act
+		_, err := validator.LoadBlocklistBloom(filepath.Join(t.TempDir(), "missing.txt"), 0.001)
+
+		// assert
+		require.ErrorIs(t, err, validator.ErrValidatorBlocklist)
+	})
+
+	t.Run("failure - empty corpus", func(t *testing.T) {
+		// act
+		b, err := validator.LoadBlocklistBloom(writeCorpus(t), 0.001)
+
+		// assert
+		require.Nil(t, b)
+		require.ErrorIs(t, err, validator.ErrValidatorBlocklist)
+		require.EqualError(t, err, "validator: cannot load blocklist. empty corpus")
+	})
+
+	t.Run("failure - invalid false positive rate", func(t *testing.T) {
+		// act
+		b, err := validator.LoadBlocklistBloom(writeCorpus(t, "Tr0ub4dor&3"), 1)
+
+		// assert
+		require.Nil(t, b)
+		require.ErrorIs(t, err, validator.ErrValidatorBlocklistConfig)
+	})
+}
+
+// Tests for NewBlocklistBloom
+func TestNewBlocklistBloom(t *testing.T) {
+	type input struct { n int; falsePositiveRate float64 }
+	type output struct { err error; errMsg string }
+	type testCase struct {
+		title  string
+		input  input
+		output output
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - sized for the passwords",
+			input: input{n: 1000, falsePositiveRate: 0.001},
+			output: output{},
+		},
+
+		// invalid cases
+		{
+			title: "zero passwords",
+			input: input{n: 0, falsePositiveRate: 0.001},
+			output: output{err: validator.ErrValidatorBlocklistConfig, errMsg: "validator: invalid blocklist config. n must be greater than 0"},
+		},
+		{
+			title: "negative passwords",
+			input: input{n: -1, falsePositiveRate: 0.001},
+			output: output{err: validator.ErrValidatorBlocklistConfig, errMsg: "validator: invalid blocklist config. n must be greater than 0"},
+		},
+		{
+			title: "zero false positive rate",
+			input: input{n: 1000, falsePositiveRate: 0},
+			output: output{err: validator.ErrValidatorBlocklistConfig, errMsg: "validator: invalid blocklist config. false positive rate must be between 0 and 1"},
+		},
+		{
+			title: "negative false positive rate",
+			input: input{n: 1000, falsePositiveRate: -0.1},
+			output: output{err: validator.ErrValidatorBlocklistConfig, errMsg: "validator: invalid blocklist config. false positive rate must be between 0 and 1"},
+		},
+		{
+			title: "false positive rate of one",
+			input: input{n: 1000, falsePositiveRate: 1},
+			output: output{err: validator.ErrValidatorBlocklistConfig, errMsg: "validator: invalid blocklist config. false positive rate must be between 0 and 1"},
+		},
+		{
+			title: "false positive rate not a number",
+			input: input{n: 1000, falsePositiveRate: math.NaN()},
+			output: output{err: validator.ErrValidatorBlocklistConfig, errMsg: "validator: invalid blocklist config. false positive rate must be between 0 and 1"},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// act
+			b, err := validator.NewBlocklistBloom(c.input.n, c.input.falsePositiveRate)
+
+			// assert
+			if c.output.err != nil {
+				require.Nil(t, b)
+				require.ErrorIs(t, err, c.output.err)
+				require.EqualError(t, err, c.output.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			b.Add("Tr0ub4dor&3")
+			require.True(t, b.Contains("Tr0ub4dor&3"))
+			require.False(t, b.Contains("correct-horse-battery-staple"))
+		})
+	}
+}
+
+// Tests for ValidatorDefault.ValidatePassword with blocklists
+func TestValidator_ValidatePassword_Blocklists(t *testing.T) {
+	t.Run("failure - common password rejected by default", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorDefaultPolicy("", nil, &validator.Policy{
+			Password: validator.PasswordPolicy{MinScore: optional.Some(0)},
+		})
+
+		// act
+		err := vl.ValidatePassword("password123")
+
+		// assert
+		require.ErrorIs(t, err, validator.ErrValidatorFieldQuality)
+		require.EqualError(t, err, "validator: field quality - password, is too common or was found in a data breach")
+	})
+
+	t.Run("success - common passwords allowed when disabled", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorDefaultPolicy("", nil, &validator.Policy{
+			Password: validator.PasswordPolicy{RejectCommon: optional.Some(false), MinScore: optional.Some(0)},
+		})
+
+		// act
+		err := vl.ValidatePassword("password123")
+
+		// assert
+		require.NoError(t, err)
+	})
+
+	t.Run("failure - breached password rejected", func(t *testing.T) {
+		// arrange
+		b, err := validator.LoadBlocklistBloom(writeCorpus(t, "Tr0ub4dor&3"), 0.001)
+		require.NoError(t, err)
+		vl := validator.NewValidatorDefaultPolicy("", nil, &validator.Policy{
+			Password: validator.PasswordPolicy{Blocklists: []validator.Blocklist{b}},
+		})
+
+		// act
+		err = vl.ValidatePassword("Tr0ub4dor&3")
+		errOther := vl.ValidatePassword("correct-horse-battery-staple")
+
+		// assert
+		require.ErrorIs(t, err, validator.ErrValidatorFieldQuality)
+		require.NoError(t, errOther)
+	})
+
+	t.Run("success - policy shared by validators is not modified", func(t *testing.T) {
+		// arrange
+		b, err := validator.LoadBlocklistBloom(writeCorpus(t, "Tr0ub4dor&3"), 0.001)
+		require.NoError(t, err)
+		policy := &validator.Policy{Password: validator.PasswordPolicy{Blocklists: []validator.Blocklist{b}, MinScore: optional.Some(0)}}
+
+		// act
+		vl := validator.NewValidatorDefaultPolicy("", nil, policy)
+		other := validator.NewValidatorDefaultPolicy("", nil, policy)
+
+		// assert
+		require.Equal(t, []validator.Blocklist{b}, policy.Password.Blocklists)
+		for _, v := range []*validator.ValidatorDefault{vl, other} {
+			require.EqualError(t, v.ValidatePassword("password123"), "validator: field quality - password, is too common or was found in a data breach")
+			require.ErrorIs(t, v.ValidatePassword("Tr0ub4dor&3"), validator.ErrValidatorFieldQuality)
+		}
+	})
+}
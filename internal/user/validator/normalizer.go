@@ -0,0 +1,108 @@
+package validator
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalizer interface for the identifiers of the users
+// - identifiers are normalized to a canonical form, used to look users up and to compare them
+// so "Alice@Example.com" and "alice@example.com" are the same account
+type Normalizer interface {
+	// Username returns the canonical form of a username
+	Username(username string) (canonical string)
+
+	// Email returns the canonical form of an email
+	Email(email string) (canonical string)
+}
+
+// EmailProvider is a set of provider specific rules to normalize emails
+// - e.g. gmail ignores the dots and the plus tags of the local part
+type EmailProvider struct {
+	// Domains are the domains of the provider
+	Domains 		[]string
+	// CanonicalDomain replaces the domains of the provider, in case it is not empty (e.g. googlemail.com -> gmail.com)
+	CanonicalDomain string
+	// StripDots removes the dots of the local part
+	StripDots 		bool
+	// StripPlusTags removes the plus tag of the local part (e.g. alice+news -> alice)
+	StripPlusTags 	bool
+}
+
+// EmailProviderGmail are the rules of gmail addresses
+var EmailProviderGmail = EmailProvider{
+	Domains: []string{"gmail.com", "googlemail.com"},
+	CanonicalDomain: "gmail.com",
+	StripDots: true,
+	StripPlusTags: true,
+}
+
+// ConfigNormalizer is the configuration of NormalizerDefault
+type ConfigNormalizer struct {
+	// Providers are the provider specific rules of the emails, none by default
+	Providers []EmailProvider
+}
+
+// NewNormalizerDefault returns a new NormalizerDefault
+func NewNormalizerDefault(cfg *ConfigNormalizer) *NormalizerDefault {
+	// default cfg
+	providers := make(map[string]EmailProvider)
+	if cfg != nil {
+		for _, p := range cfg.Providers {
+			for _, d := range p.Domains {
+				providers[strings.ToLower(d)] = p
+			}
+		}
+	}
+
+	return &NormalizerDefault{
+		fold: cases.Fold(),
+		providers: providers,
+	}
+}
+
+// NormalizerDefault is the implementation of the Normalizer interface
+// - identifiers are trimmed, NFKC normalized and case folded
+type NormalizerDefault struct {
+	// fold is the unicode case folder
+	fold cases.Caser
+	// providers are the provider specific rules of the emails, by domain
+	providers map[string]EmailProvider
+}
+
+// Username returns the canonical form of a username
+func (n *NormalizerDefault) Username(username string) (canonical string) {
+	canonical = n.fold.String(norm.NFKC.String(strings.TrimSpace(username)))
+	return
+}
+
+// Email returns the canonical form of an email
+// - provider rules are applied to the local part, by the domain of the email
+func (n *NormalizerDefault) Email(email string) (canonical string) {
+	canonical = n.fold.String(norm.NFKC.String(strings.TrimSpace(email)))
+
+	at := strings.LastIndex(canonical, "@")
+	if at < 0 {
+		return
+	}
+	local, domain := canonical[:at], canonical[at+1:]
+
+	p, ok := n.providers[domain]
+	if !ok {
+		return
+	}
+	if p.StripPlusTags {
+		local, _, _ = strings.Cut(local, "+")
+	}
+	if p.StripDots {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	if p.CanonicalDomain != "" {
+		domain = p.CanonicalDomain
+	}
+
+	canonical = local + "@" + domain
+	return
+}
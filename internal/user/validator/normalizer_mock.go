@@ -0,0 +1,27 @@
+package validator
+
+import "github.com/stretchr/testify/mock"
+
+// NewNormalizerMock returns a new NormalizerMock
+func NewNormalizerMock() *NormalizerMock {
+	return &NormalizerMock{}
+}
+
+// NormalizerMock is the mock implementation of the Normalizer interface
+type NormalizerMock struct {
+	mock.Mock
+}
+
+// Username returns the canonical form of a username
+func (m *NormalizerMock) Username(username string) (canonical string) {
+	args := m.Called(username)
+	canonical = args.String(0)
+	return
+}
+
+// Email returns the canonical form of an email
+func (m *NormalizerMock) Email(email string) (canonical string) {
+	args := m.Called(email)
+	canonical = args.String(0)
+	return
+}
@@ -0,0 +1,56 @@
+package validator_test
+
+import (
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user/validator"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for NormalizerDefault.Username
+func TestNormalizerDefault_Username(t *testing.T) {
+	cases := []struct{title, input, output string}{
+		{title: "case folded", input: "JohnDoe", output: "johndoe"},
+		{title: "trimmed", input: "  johndoe\t", output: "johndoe"},
+		{title: "NFKC normalized", input: "ｊｏｈｎｄｏｅ", output: "johndoe"},
+		{title: "unicode case folded", input: "STRASSE", output: "strasse"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			nm := validator.NewNormalizerDefault(nil)
+
+			// act
+			canonical := nm.Username(c.input)
+
+			// assert
+			require.Equal(t, c.output, canonical)
+		})
+	}
+}
+
+// Tests for NormalizerDefault.Email
+func TestNormalizerDefault_Email(t *testing.T) {
+	cases := []struct{title, input, output string; providers []validator.EmailProvider}{
+		{title: "case folded and trimmed", input: " Alice@Example.com ", output: "alice@example.com"},
+		{title: "no provider rules by default", input: "a.lice+news@gmail.com", output: "a.lice+news@gmail.com"},
+		{title: "gmail dots and plus tags", input: "A.Lice+News@GoogleMail.com", output: "alice@gmail.com", providers: []validator.EmailProvider{validator.EmailProviderGmail}},
+		{title: "provider rules only by domain", input: "a.lice+news@example.com", output: "a.lice+news@example.com", providers: []validator.EmailProvider{validator.EmailProviderGmail}},
+		{title: "invalid email left as it is", input: "Alice", output: "alice", providers: []validator.EmailProvider{validator.EmailProviderGmail}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			nm := validator.NewNormalizerDefault(&validator.ConfigNormalizer{Providers: c.providers})
+
+			// act
+			canonical := nm.Email(c.input)
+
+			// assert
+			require.Equal(t, c.output, canonical)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package validator
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/LNMMusic/optional"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Policy is the quality policy of the user fields
+type Policy struct {
+	// Username is the policy of the usernames
+	Username 	UsernamePolicy
+	// Password is the policy of the passwords
+	Password 	PasswordPolicy
+	// Normalizer normalizes the usernames and emails to their canonical form (default NormalizerDefault without provider rules)
+	Normalizer 	Normalizer
+}
+
+// UsernamePatternDefault is the default pattern of the usernames
+// - letters, digits, dots, dashes and underscores, so whitespace, control characters and "@" are rejected
+const UsernamePatternDefault = `^[\p{L}\p{N}._\-]+$`
+
+// UsernamePolicy is the quality policy of the usernames
+type UsernamePolicy struct {
+	// MinLength is the minimum number of characters (default 3)
+	MinLength 			optional.Option[int]
+	// MaxLength is the maximum number of characters (default 25)
+	MaxLength 			optional.Option[int]
+	// Pattern is the regex the usernames must match (default UsernamePatternDefault)
+	Pattern 			optional.Option[string]
+	// RejectMixedScripts rejects usernames that mix latin, greek and cyrillic letters (default true)
+	// - they are used to spoof other usernames with confusable letters, e.g. a cyrillic "а" for a latin "a"
+	RejectMixedScripts 	optional.Option[bool]
+}
+
+// PasswordMaxBytes is the maximum number of bytes of a NFKC normalized password
+// - bcrypt only takes into account the first 72 bytes, longer passwords would be truncated silently
+const PasswordMaxBytes = 72
+
+// PasswordPolicy is the quality policy of the passwords
+// - lengths are counted in characters of the NFKC normalized password
+// - regardless of the lengths, a password can not exceed PasswordMaxBytes
+type PasswordPolicy struct {
+	// MinLength is the minimum number of characters (default 8)
+	MinLength 			optional.Option[int]
+	// MaxLength is the maximum number of characters (default 64)
+	// - multibyte characters may reach PasswordMaxBytes first
+	MaxLength 			optional.Option[int]
+	// RequireUpper requires an uppercase letter
+	RequireUpper 		bool
+	// RequireLower requires a lowercase letter
+	RequireLower 		bool
+	// RequireDigit requires a digit
+	RequireDigit 		bool
+	// RequireSymbol requires a symbol or punctuation character
+	RequireSymbol 		bool
+	// Forbidden are substrings the password can not contain (case insensitive)
+	Forbidden 			[]string
+	// ForbidIdentifiers forbids the username and the email local part as substrings (default true)
+	ForbidIdentifiers 	optional.Option[bool]
+	// RejectCommon rejects the most common passwords, see BlocklistCommon (default true)
+	RejectCommon 		optional.Option[bool]
+	// Blocklists are sets of passwords to reject, e.g. a breached password corpus loaded with LoadBlocklistBloom
+	Blocklists 			[]Blocklist
+	// MinScore is the minimum strength score estimated by strength.Estimate, from 0 to 4 (default 2)
+	// - 0 disables the estimation
+	MinScore 			optional.Option[int]
+}
+
+// defaults sets the default values of the username policy
+func (p *UsernamePolicy) defaults() {
+	if !p.MinLength.IsSome() {
+		p.MinLength = optional.Some(3)
+	}
+	if !p.MaxLength.IsSome() {
+		p.MaxLength = optional.Some(25)
+	}
+	if !p.Pattern.IsSome() {
+		p.Pattern = optional.Some(UsernamePatternDefault)
+	}
+	if !p.RejectMixedScripts.IsSome() {
+		p.RejectMixedScripts = optional.Some(true)
+	}
+}
+
+// confusableScripts are the scripts with letters that look alike
+var confusableScripts = []*unicode.RangeTable{unicode.Latin, unicode.Greek, unicode.Cyrillic}
+
+// mixedScripts returns whether a string has letters of more than one of the confusable scripts
+func mixedScripts(s string) (mixed bool) {
+	var found *unicode.RangeTable
+	for _, r := range s {
+		for _, script := range confusableScripts {
+			if !unicode.Is(script, r) {
+				continue
+			}
+			if found != nil && found != script {
+				mixed = true
+				return
+			}
+			found = script
+		}
+	}
+	return
+}
+
+// defaults sets the default values of the password policy
+func (p *PasswordPolicy) defaults() {
+	if !p.MinLength.IsSome() {
+		p.MinLength = optional.Some(8)
+	}
+	if !p.MaxLength.IsSome() {
+		p.MaxLength = optional.Some(64)
+	}
+	if !p.ForbidIdentifiers.IsSome() {
+		p.ForbidIdentifiers = optional.Some(true)
+	}
+	if !p.RejectCommon.IsSome() {
+		p.RejectCommon = optional.Some(true)
+	}
+	if !p.MinScore.IsSome() {
+		p.MinScore = optional.Some(2)
+	}
+}
+
+// NormalizePassword returns the NFKC normalized form of a password
+// - passwords are validated and hashed normalized, so they must be normalized as well before comparing them
+func NormalizePassword(password string) (normalized string) {
+	normalized = norm.NFKC.String(password)
+	return
+}
+
+// forbiddenIdentifiers returns the identifiers of a user forbidden in its password
+// - emails are reduced to their local part, identifiers shorter than 3 characters are ignored
+func forbiddenIdentifiers(identifiers ...string) (ids []string) {
+	for _, id := range identifiers {
+		id, _, _ = strings.Cut(id, "@")
+		if len([]rune(id)) >= 3 {
+			ids = append(ids, id)
+		}
+	}
+	return
+}
+
+// classes returns the character classes of a password
+func classes(password string) (upper, lower, digit, symbol bool) {
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	return
+}
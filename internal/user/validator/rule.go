@@ -0,0 +1,90 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LNMMusic/msauth/internal/user"
+)
+
+// Rule interface for a single validation rule of the users
+// - rules are chained by ValidatorChain, each one checks a concern (e.g. required fields, email format)
+type Rule interface {
+	// Check returns the violations of the rule, as field errors
+	// - a rule only checks the fields that are some, except the rules of required fields
+	Check(u *user.User) (errs []error)
+}
+
+// PasswordRule interface for rules that also validate plain passwords on their own (e.g. on password changes)
+type PasswordRule interface {
+	Rule
+
+	// CheckPassword returns the violations of the rule on a plain password
+	// - identifiers are the user values that should not be part of the password (e.g. username, email)
+	CheckPassword(password string, identifiers []string) (errs []error)
+}
+
+// RuleFunc is an adapter to use a function as a Rule, e.g. for custom hooks
+type RuleFunc func(u *user.User) (errs []error)
+
+// Check calls f(u)
+func (f RuleFunc) Check(u *user.User) (errs []error) {
+	errs = f(u)
+	return
+}
+
+// FieldError is a violation of a rule on a field of the user
+// - it wraps ErrValidatorFieldRequired or ErrValidatorFieldQuality
+type FieldError struct {
+	// Field is the name of the field, as in its json representation (e.g. "email")
+	Field 	string
+	// Message is the human readable description of the violation
+	Message string
+	// Err is the kind of the violation
+	Err 	error
+}
+
+// NewFieldError returns a new FieldError
+func NewFieldError(err error, field string, format string, args ...any) *FieldError {
+	return &FieldError{
+		Field: field,
+		Message: fmt.Sprintf(format, args...),
+		Err: err,
+	}
+}
+
+// Error returns the message of the error
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s - %s, %s", e.Err.Error(), e.Field, e.Message)
+}
+
+// Unwrap returns the kind of the violation
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Fields returns the messages of the field errors of an error, grouped by field
+// - errors are walked through joins and wraps, errors that are not field errors are skipped
+func Fields(err error) (fields map[string][]string) {
+	fields = make(map[string][]string)
+
+	var walk func(err error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+		case *FieldError:
+			fields[e.Field] = append(fields[e.Field], e.Message)
+		case *WeakPasswordError:
+			fields[e.Field] = append(fields[e.Field], e.Message)
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				walk(err)
+			}
+		default:
+			walk(errors.Unwrap(err))
+		}
+	}
+	walk(err)
+
+	return
+}
@@ -0,0 +1,227 @@
+package validator
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/pkg/strength"
+)
+
+// NewRuleRequired returns a new RuleRequired
+func NewRuleRequired() *RuleRequired {
+	return &RuleRequired{}
+}
+
+// RuleRequired is the rule of the required fields: username, password and email
+type RuleRequired struct{}
+
+// Check returns a violation per required field that is none
+func (r *RuleRequired) Check(u *user.User) (errs []error) {
+	if !u.Username.IsSome() {
+		errs = append(errs, NewFieldError(ErrValidatorFieldRequired, "username", "is none"))
+	}
+	if !u.Password.IsSome() {
+		errs = append(errs, NewFieldError(ErrValidatorFieldRequired, "password", "is none"))
+	}
+	if !u.Email.IsSome() {
+		errs = append(errs, NewFieldError(ErrValidatorFieldRequired, "email", "is none"))
+	}
+	return
+}
+
+// NewRuleUsername returns a new RuleUsername
+// - policy values that are none are set to their defaults
+// - it panics in case the pattern of the policy is not a valid regex
+func NewRuleUsername(policy *UsernamePolicy) *RuleUsername {
+	policy.defaults()
+	pattern, _ := policy.Pattern.Unwrap()
+
+	return &RuleUsername{
+		policy: policy,
+		pattern: regexp.MustCompile(pattern),
+	}
+}
+
+// RuleUsername is the rule of the quality of the usernames
+// - length, format and mixed scripts, see UsernamePolicy
+type RuleUsername struct {
+	// policy is the quality policy of the usernames
+	policy *UsernamePolicy
+	// pattern is the compiled pattern of the policy
+	pattern *regexp.Regexp
+}
+
+// Check returns the violations of the username policy
+func (r *RuleUsername) Check(u *user.User) (errs []error) {
+	if !u.Username.IsSome() {
+		return
+	}
+	username, _ := u.Username.Unwrap()
+
+	minLength, _ := r.policy.MinLength.Unwrap()
+	maxLength, _ := r.policy.MaxLength.Unwrap()
+	if n := utf8.RuneCountInString(username); n < minLength || n > maxLength {
+		errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "username", "chars should be between %d and %d", minLength, maxLength))
+	}
+	if !r.pattern.MatchString(username) {
+		errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "username", "invalid format"))
+	}
+	if reject, _ := r.policy.RejectMixedScripts.Unwrap(); reject && mixedScripts(username) {
+		errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "username", "should not mix latin, greek and cyrillic letters"))
+	}
+	return
+}
+
+// NewRuleEmail returns a new RuleEmail
+// - in case of an empty regex, a default one is used
+func NewRuleEmail(emailRegex string) *RuleEmail {
+	// default cfg
+	defaultEmailRegex := `^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`
+	if emailRegex != "" {
+		defaultEmailRegex = emailRegex
+	}
+
+	return &RuleEmail{
+		emailRegex: regexp.MustCompile(defaultEmailRegex),
+	}
+}
+
+// RuleEmail is the rule of the format of the emails
+type RuleEmail struct {
+	// emailRegex is the regex used to validate emails
+	emailRegex *regexp.Regexp
+}
+
+// Check returns a violation if the email does not match the regex
+func (r *RuleEmail) Check(u *user.User) (errs []error) {
+	if !u.Email.IsSome() {
+		return
+	}
+	email, _ := u.Email.Unwrap()
+
+	if !r.emailRegex.MatchString(email) {
+		errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "email", "invalid format"))
+	}
+	return
+}
+
+// NewRulePassword returns a new RulePassword
+// - policy values that are none are set to their defaults
+// - the common blocklist is checked by the rule, it is not added to the blocklists of the policy
+func NewRulePassword(policy *PasswordPolicy) *RulePassword {
+	policy.defaults()
+
+	var blocklists []Blocklist
+	if reject, _ := policy.RejectCommon.Unwrap(); reject {
+		blocklists = append(blocklists, NewBlocklistCommon())
+	}
+	blocklists = append(blocklists, policy.Blocklists...)
+
+	return &RulePassword{
+		policy: policy,
+		blocklists: blocklists,
+	}
+}
+
+// RulePassword is the rule of the quality of the passwords
+// - lengths, character classes, blocklists, strength and forbidden substrings, see PasswordPolicy
+type RulePassword struct {
+	// policy is the quality policy of the passwords
+	policy *PasswordPolicy
+	// blocklists are the common blocklist, if rejected, and the blocklists of the policy
+	blocklists []Blocklist
+}
+
+// Check returns the violations of the password policy, with the username and email as identifiers
+func (r *RulePassword) Check(u *user.User) (errs []error) {
+	if !u.Password.IsSome() {
+		return
+	}
+	password, _ := u.Password.Unwrap()
+	username, _ := u.Username.Unwrap()
+	email, _ := u.Email.Unwrap()
+
+	errs = r.CheckPassword(password, []string{username, email})
+	return
+}
+
+// CheckPassword returns the violations of the password policy on a plain password
+// - the password is checked in its normalized form
+// - emails in the identifiers are reduced to their local part
+func (r *RulePassword) CheckPassword(password string, identifiers []string) (errs []error) {
+	p := r.policy
+	password = NormalizePassword(password)
+	identifiers = forbiddenIdentifiers(identifiers...)
+
+	// length
+	minLength, _ := p.MinLength.Unwrap()
+	maxLength, _ := p.MaxLength.Unwrap()
+	var tooLong bool
+	if n := utf8.RuneCountInString(password); n < minLength || n > maxLength {
+		errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "password", "chars should be between %d and %d", minLength, maxLength))
+		tooLong = n > maxLength
+	}
+	if len(password) > PasswordMaxBytes {
+		errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "password", "should be at most %d bytes, use fewer special characters", PasswordMaxBytes))
+		tooLong = true
+	}
+
+	// character classes
+	upper, lower, digit, symbol := classes(password)
+	if p.RequireUpper && !upper {
+		errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "password", "should contain an uppercase letter"))
+	}
+	if p.RequireLower && !lower {
+		errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "password", "should contain a lowercase letter"))
+	}
+	if p.RequireDigit && !digit {
+		errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "password", "should contain a digit"))
+	}
+	if p.RequireSymbol && !symbol {
+		errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "password", "should contain a symbol"))
+	}
+
+	// blocklists
+	for _, b := range r.blocklists {
+		if b.Contains(password) {
+			errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "password", "is too common or was found in a data breach"))
+			break
+		}
+	}
+
+	// strength
+	// - estimated with the identifiers as user inputs, so passwords built around them score lower
+	// - not estimated on passwords over the length limits, they are rejected anyway and the estimation is the costly check
+	if minScore, _ := p.MinScore.Unwrap(); minScore > 0 && !tooLong {
+		e := strength.Estimate(password, identifiers...)
+		if e.Score < minScore {
+			errs = append(errs, &WeakPasswordError{
+				FieldError: *NewFieldError(ErrValidatorFieldQuality, "password", "too weak (score %d, minimum %d)", e.Score, minScore),
+				Score: e.Score,
+				MinScore: minScore,
+				Warning: e.Warning,
+				Suggestions: e.Suggestions,
+			})
+		}
+	}
+
+	// forbidden substrings
+	lowered := strings.ToLower(password)
+	for _, f := range p.Forbidden {
+		if f != "" && strings.Contains(lowered, strings.ToLower(NormalizePassword(f))) {
+			errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "password", "should not contain %q", f))
+		}
+	}
+	if forbid, _ := p.ForbidIdentifiers.Unwrap(); forbid {
+		for _, id := range identifiers {
+			if id != "" && strings.Contains(lowered, strings.ToLower(NormalizePassword(id))) {
+				errs = append(errs, NewFieldError(ErrValidatorFieldQuality, "password", "should not contain the username or email"))
+				break
+			}
+		}
+	}
+
+	return
+}
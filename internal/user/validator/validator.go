@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"context"
 	"errors"
 
 	"github.com/LNMMusic/msauth/internal/user"
@@ -20,6 +21,21 @@ var (
 	ErrValidatorEncryption  = errors.New("validator: cannot encrypt field")
 )
 
+// WeakPasswordError is returned when the estimated strength of a password is below the minimum score of the policy
+// - it is a field error of the password, wrapping ErrValidatorFieldQuality
+// - it carries the feedback of the estimation, to be shown to the user
+type WeakPasswordError struct {
+	FieldError
+	// Score is the estimated strength of the password, from 0 to 4
+	Score 		int
+	// MinScore is the minimum score required by the policy
+	MinScore 	int
+	// Warning explains why the password is weak, if known
+	Warning 	string
+	// Suggestions are advices to make the password stronger
+	Suggestions []string
+}
+
 // Validator interface for users to handle user validation before storage
 // - handles some default values to certain fields and
 // - handles validation of required fields and its quality
@@ -31,6 +47,32 @@ type Validator interface {
 	// Validate validates required fields and its quality
 	Validate(u *user.User) (err error)
 
+	// ValidatePatch validates the quality of the fields of a partial user
+	// - fields that are none are left as they are, so they are not required
+	// - identifiers are the stored user values that should not be part of the password (e.g. username, email),
+	// as the patch may not include them
+	ValidatePatch(u *user.User, identifiers ...string) (err error)
+
 	// Prepare prepares some fields for storage
+	// - fields that are none are not prepared, so it can be used on partial users
 	Prepare(u *user.User) (err error)
-}
\ No newline at end of file
+
+	// ValidatePassword validates the quality of a plain password
+	// - identifiers are the user values that should not be part of the password (e.g. username, email)
+	ValidatePassword(password string, identifiers ...string) (err error)
+
+	// PreparePassword prepares a plain password for storage
+	PreparePassword(password string) (prepared string, err error)
+}
+
+// ValidatorContext interface for validators aware of a context
+// - only the preparation of the fields takes a context, as it encrypts the password
+type ValidatorContext interface {
+	Validator
+
+	// PrepareContext prepares some fields for storage
+	PrepareContext(ctx context.Context, u *user.User) (err error)
+
+	// PreparePasswordContext prepares a plain password for storage
+	PreparePasswordContext(ctx context.Context, password string) (prepared string, err error)
+}
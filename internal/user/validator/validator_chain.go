@@ -0,0 +1,163 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/pkg/crypter"
+
+	"github.com/LNMMusic/optional"
+)
+
+// NewValidatorChain returns a new ValidatorChain with its rules, in order
+// - in case of a nil normalizer, a NormalizerDefault without provider rules is used
+func NewValidatorChain(cr crypter.Crypter, nm Normalizer, rules ...Rule) *ValidatorChain {
+	// default cfg
+	if nm == nil {
+		nm = NewNormalizerDefault(nil)
+	}
+
+	var crc crypter.CrypterContext
+	if cr != nil {
+		crc = crypter.WithContext(cr)
+	}
+
+	return &ValidatorChain{
+		cr: crc,
+		nm: nm,
+		rules: rules,
+	}
+}
+
+// ValidatorChain is the implementation of the Validator interface with a chain of rules
+// - rules are checked in the order they are registered, and all the violations are returned at once, joined
+// - rules must be registered before the validator is used, registering is not safe for concurrent use
+type ValidatorChain struct {
+	// cr is the Crypter interface to encrypt fields
+	cr crypter.CrypterContext
+
+	// nm is the Normalizer interface to set the canonical identifiers
+	nm Normalizer
+
+	// rules are the rules of the chain, in order
+	rules []Rule
+}
+
+// Register appends rules to the end of the chain
+func (v *ValidatorChain) Register(rules ...Rule) {
+	v.rules = append(v.rules, rules...)
+}
+
+// Default sets default values for a user in case of fields being none
+func (v *ValidatorChain) Default(u *user.User) (err error) {
+	// -> is_active is set to false by default (in case its a none value)
+	if !u.IsActive.IsSome() {
+		u.IsActive = optional.Some(false)
+	}
+
+	return
+}
+
+// Validate checks the rules of the chain
+// - violations are field errors, see Fields
+func (v *ValidatorChain) Validate(u *user.User) (err error) {
+	var errs []error
+	for _, r := range v.rules {
+		errs = append(errs, r.Check(u)...)
+	}
+
+	err = errors.Join(errs...)
+	return
+}
+
+// ValidatePatch checks the rules of the chain on a partial user
+// - violations of required fields are dropped, the quality of the fields that are some is still checked
+// - a password is checked by the password rules against the identifiers of the patch and the given ones
+func (v *ValidatorChain) ValidatePatch(u *user.User, identifiers ...string) (err error) {
+	var errs []error
+	for _, r := range v.rules {
+		if pr, ok := r.(PasswordRule); ok && u.Password.IsSome() {
+			password, _ := u.Password.Unwrap()
+			username, _ := u.Username.Unwrap()
+			email, _ := u.Email.Unwrap()
+			errs = append(errs, pr.CheckPassword(password, append([]string{username, email}, identifiers...))...)
+			continue
+		}
+		for _, e := range r.Check(u) {
+			if !errors.Is(e, ErrValidatorFieldRequired) {
+				errs = append(errs, e)
+			}
+		}
+	}
+
+	err = errors.Join(errs...)
+	return
+}
+
+// Prepare prepares some fields for storage
+// - the canonical identifiers are set from the username and email, which are kept as they are
+// - the password is encrypted only if it is some
+func (v *ValidatorChain) Prepare(u *user.User) (err error) {
+	err = v.PrepareContext(context.Background(), u)
+	return
+}
+
+// PrepareContext prepares some fields for storage
+// - the password is encrypted unless the context is done
+func (v *ValidatorChain) PrepareContext(ctx context.Context, u *user.User) (err error) {
+	// canonical identifiers
+	if u.Username.IsSome() {
+		username, _ := u.Username.Unwrap()
+		(*u).UsernameCanonical = optional.Some(v.nm.Username(username))
+	}
+	if u.Email.IsSome() {
+		email, _ := u.Email.Unwrap()
+		(*u).EmailCanonical = optional.Some(v.nm.Email(email))
+	}
+
+	// encrypt password
+	if !u.Password.IsSome() {
+		return
+	}
+	password, _ := u.Password.Unwrap()
+	encryptedPassword, err := v.PreparePasswordContext(ctx, password)
+	if err != nil {
+		return
+	}
+	(*u).Password = optional.Some(encryptedPassword)
+
+	return
+}
+
+// ValidatePassword checks the password rules of the chain on a plain password
+// - only the rules implementing PasswordRule are checked
+func (v *ValidatorChain) ValidatePassword(password string, identifiers ...string) (err error) {
+	var errs []error
+	for _, r := range v.rules {
+		if pr, ok := r.(PasswordRule); ok {
+			errs = append(errs, pr.CheckPassword(password, identifiers)...)
+		}
+	}
+
+	err = errors.Join(errs...)
+	return
+}
+
+// PreparePassword normalizes and encrypts a plain password for storage
+func (v *ValidatorChain) PreparePassword(password string) (prepared string, err error) {
+	prepared, err = v.PreparePasswordContext(context.Background(), password)
+	return
+}
+
+// PreparePasswordContext normalizes and encrypts a plain password for storage, unless the context is done
+func (v *ValidatorChain) PreparePasswordContext(ctx context.Context, password string) (prepared string, err error) {
+	prepared, err = v.cr.EncryptContext(ctx, NormalizePassword(password))
+	if err != nil {
+		err = fmt.Errorf("%w. %w", ErrValidatorEncryption, err)
+		return
+	}
+
+	return
+}
@@ -0,0 +1,154 @@
+package validator_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/validator"
+
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for ValidatorChain.Validate
+func TestValidatorChain_Validate(t *testing.T) {
+	t.Run("success - no rules", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorChain(nil, nil)
+
+		// act
+		err := vl.Validate(&user.User{})
+
+		// assert
+		require.NoError(t, err)
+	})
+
+	t.Run("failure - rules checked in order of registration", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorChain(nil, nil, validator.NewRuleEmail(""))
+		vl.Register(
+			validator.NewRuleRequired(),
+			validator.RuleFunc(func(u *user.User) (errs []error) {
+				username, _ := u.Username.Unwrap()
+				if strings.HasPrefix(username, "admin") {
+					errs = append(errs, validator.NewFieldError(validator.ErrValidatorFieldQuality, "username", "is reserved"))
+				}
+				return
+			}),
+		)
+		u := &user.User{
+			Username: optional.Some("admin-john"),
+			Email: optional.Some("johndoegmail.com"),
+		}
+
+		// act
+		err := vl.Validate(u)
+
+		// assert
+		require.ErrorIs(t, err, validator.ErrValidatorFieldQuality)
+		require.ErrorIs(t, err, validator.ErrValidatorFieldRequired)
+		require.EqualError(t, err, "validator: field quality - email, invalid format\n" +
+			"validator: field required - password, is none\n" +
+			"validator: field quality - username, is reserved")
+	})
+}
+
+// Tests for ValidatorChain.ValidatePatch
+func TestValidatorChain_ValidatePatch(t *testing.T) {
+	t.Run("success - fields that are none are not required", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorDefault("", nil)
+
+		// act
+		err := vl.ValidatePatch(&user.User{
+			Username: optional.Some("johnny"),
+		})
+
+		// assert
+		require.NoError(t, err)
+	})
+
+	t.Run("failure - fields that are some are checked", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorDefault("", nil)
+
+		// act
+		err := vl.ValidatePatch(&user.User{
+			Username: optional.Some("jo"),
+			Email: optional.Some("johndoegmail.com"),
+		})
+
+		// assert
+		require.ErrorIs(t, err, validator.ErrValidatorFieldQuality)
+		require.NotErrorIs(t, err, validator.ErrValidatorFieldRequired)
+		require.EqualError(t, err, "validator: field quality - username, chars should be between 3 and 25\n" +
+			"validator: field quality - email, invalid format")
+	})
+
+	t.Run("failure - password checked against the stored identifiers", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorDefaultPolicy("", nil, &validator.Policy{
+			Password: validator.PasswordPolicy{MinScore: optional.Some(0)},
+		})
+
+		// act
+		err := vl.ValidatePatch(&user.User{
+			Password: optional.Some("johndoe2024!"),
+		}, "johndoe", "johndoe@gmail.com")
+
+		// assert
+		require.ErrorIs(t, err, validator.ErrValidatorFieldQuality)
+		require.EqualError(t, err, "validator: field quality - password, should not contain the username or email")
+	})
+}
+
+// Tests for ValidatorChain.ValidatePassword
+func TestValidatorChain_ValidatePassword(t *testing.T) {
+	t.Run("only password rules are checked", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorChain(nil, nil,
+			validator.NewRuleRequired(),
+			validator.NewRulePassword(&validator.PasswordPolicy{MinScore: optional.Some(0)}),
+		)
+
+		// act
+		err := vl.ValidatePassword("p4ss")
+
+		// assert
+		require.EqualError(t, err, "validator: field quality - password, chars should be between 8 and 64")
+	})
+}
+
+// Tests for Fields
+func TestFields(t *testing.T) {
+	t.Run("field errors grouped by field, through joins and wraps", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorDefault("", nil)
+		err := vl.Validate(&user.User{
+			Username: optional.Some("us"),
+			Password: optional.Some("p4ss"),
+		})
+		err = fmt.Errorf("%w. %w", errors.New("storage: invalid user"), err)
+
+		// act
+		fields := validator.Fields(err)
+
+		// assert
+		require.Equal(t, map[string][]string{
+			"username": {"chars should be between 3 and 25"},
+			"password": {"chars should be between 8 and 64", "too weak (score 0, minimum 2)"},
+			"email": {"is none"},
+		}, fields)
+	})
+
+	t.Run("no field errors", func(t *testing.T) {
+		// act
+		fields := validator.Fields(errors.New("internal"))
+
+		// assert
+		require.Empty(t, fields)
+	})
+}
@@ -0,0 +1,41 @@
+package validator
+
+import (
+	"context"
+
+	"github.com/LNMMusic/msauth/internal/user"
+)
+
+// WithContext returns a ValidatorContext of a Validator
+// - validators that are already aware of a context are returned as they are
+// - otherwise, the context is only checked before each preparation
+func WithContext(vl Validator) (v ValidatorContext) {
+	v, ok := vl.(ValidatorContext)
+	if !ok {
+		v = &validatorContext{vl}
+	}
+	return
+}
+
+// validatorContext is the adapter of a Validator to the ValidatorContext interface
+type validatorContext struct {
+	Validator
+}
+
+// PrepareContext prepares some fields for storage
+func (v *validatorContext) PrepareContext(ctx context.Context, u *user.User) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = v.Prepare(u)
+	return
+}
+
+// PreparePasswordContext prepares a plain password for storage
+func (v *validatorContext) PreparePasswordContext(ctx context.Context, password string) (prepared string, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	prepared, err = v.PreparePassword(password)
+	return
+}
@@ -1,7 +1,10 @@
 package validator_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/LNMMusic/msauth/internal/user"
 	"github.com/LNMMusic/msauth/internal/user/validator"
@@ -69,7 +72,7 @@ func TestValidator_Validate(t *testing.T) {
 			input: input{user: &user.User{
 				Id: 1,
 				Username: optional.Some("username"),
-				Password: optional.Some("password"),
+				Password: optional.Some("correct-horse"),
 				Email: optional.Some("john_doe@gmail.com"),
 			}},
 			output: output{err: nil},
@@ -79,7 +82,7 @@ func TestValidator_Validate(t *testing.T) {
 			input: input{user: &user.User{
 				Id: 1,
 				Username: optional.Some("username"),
-				Password: optional.Some("password"),
+				Password: optional.Some("correct-horse"),
 				Email: optional.Some("john_doe@gmail.com"),
 				IsActive: optional.Some(true),
 			}},
@@ -91,11 +94,11 @@ func TestValidator_Validate(t *testing.T) {
 		{
 			title: "invalid case - username none",
 			input: input{user: &user.User{
-				Password: optional.Some("password"),
+				Password: optional.Some("correct-horse"),
 			}},
 			output: output{
 				err: validator.ErrValidatorFieldRequired,
-				errMsg: "validator: field required - username is none",
+				errMsg: "validator: field required - username, is none\nvalidator: field required - email, is none",
 			},
 		},
 		{
@@ -105,18 +108,18 @@ func TestValidator_Validate(t *testing.T) {
 			}},
 			output: output{
 				err: validator.ErrValidatorFieldRequired,
-				errMsg: "validator: field required - password is none",
+				errMsg: "validator: field required - password, is none\nvalidator: field required - email, is none",
 			},
 		},
 		{
 			title: "invalid case - email none",
 			input: input{user: &user.User{
 				Username: optional.Some("username"),
-				Password: optional.Some("password"),
+				Password: optional.Some("correct-horse"),
 			}},
 			output: output{
 				err: validator.ErrValidatorFieldRequired,
-				errMsg: "validator: field required - email is none",
+				errMsg: "validator: field required - email, is none",
 			},
 		},
 		// - quality of fields
@@ -124,7 +127,7 @@ func TestValidator_Validate(t *testing.T) {
 			title: "invalid case - username too short",
 			input: input{user: &user.User{
 				Username: optional.Some("us"),
-				Password: optional.Some("password"),
+				Password: optional.Some("correct-horse"),
 				Email: optional.Some("johndoe@gmail.com"),
 			}},
 			output: output{
@@ -136,7 +139,7 @@ func TestValidator_Validate(t *testing.T) {
 			title: "invalid case - username too long",
 			input: input{user: &user.User{
 				Username: optional.Some("usernameusernameusernameusername"),
-				Password: optional.Some("password"),
+				Password: optional.Some("correct-horse"),
 				Email: optional.Some("johndoe@gmail.com"),
 			}},
 			output: output{
@@ -148,31 +151,70 @@ func TestValidator_Validate(t *testing.T) {
 			title: "invalid case - password too short",
 			input: input{user: &user.User{
 				Username: optional.Some("username"),
-				Password: optional.Some("pass"),
+				Password: optional.Some("x9#Kq2!"),
 				Email: optional.Some("johndoe@gmail.com"),
 			}},
 			output: output{
 				err: validator.ErrValidatorFieldQuality,
-				errMsg: "validator: field quality - password, chars should be between 8 and 25",
+				errMsg: "validator: field quality - password, chars should be between 8 and 64",
 			},
 		},
 		{
 			title: "invalid case - password too long",
 			input: input{user: &user.User{
 				Username: optional.Some("username"),
-				Password: optional.Some("passwordpasswordpasswordpassword"),
+				Password: optional.Some("purple-monkey-dishwasher-correct-horse-battery-staple-blue-lion-42"),
 				Email: optional.Some("johndoe@gmail.com"),
 			}},
 			output: output{
 				err: validator.ErrValidatorFieldQuality,
-				errMsg: "validator: field quality - password, chars should be between 8 and 25",
+				errMsg: "validator: field quality - password, chars should be between 8 and 64",
+			},
+		},
+		{
+			title: "invalid case - password contains the username",
+			input: input{user: &user.User{
+				Username: optional.Some("johndoe"),
+				Password: optional.Some("x9#JohnDoe!Kq2"),
+				Email: optional.Some("janedoe@gmail.com"),
+			}},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - password, should not contain the username or email",
+			},
+		},
+		{
+			title: "invalid case - password contains the email local part",
+			input: input{user: &user.User{
+				Username: optional.Some("username"),
+				Password: optional.Some("Kq2!janedoe#x9"),
+				Email: optional.Some("janedoe@gmail.com"),
+			}},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - password, should not contain the username or email",
+			},
+		},
+		{
+			title: "invalid case - all violations at once",
+			input: input{user: &user.User{
+				Username: optional.Some("us"),
+				Password: optional.Some("p4ss"),
+				Email: optional.Some("johndoegmail.com"),
+			}},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - username, chars should be between 3 and 25\n" +
+					"validator: field quality - password, chars should be between 8 and 64\n" +
+					"validator: field quality - password, too weak (score 0, minimum 2)\n" +
+					"validator: field quality - email, invalid format",
 			},
 		},
 		{
 			title: "invalid case - email invalid format",
 			input: input{user: &user.User{
 				Username: optional.Some("username"),
-				Password: optional.Some("password"),
+				Password: optional.Some("correct-horse"),
 				Email: optional.Some("johndoegmail.com"),
 			}},
 			output: output{
@@ -244,4 +286,363 @@ func TestValidator_Prepare(t *testing.T) {
 		require.Equal(t, optional.Some("password"), u.Password)
 		cr.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+
+	t.Run("success to set the canonical identifiers", func(t *testing.T) {
+		// arrange
+		// - crypter: mock
+		cr := crypter.NewCrypterMock()
+		cr.On("Encrypt", "password").Return("encrypted_password", nil)
+
+		// - validator: default, with gmail rules
+		vl := validator.NewValidatorDefaultPolicy("", cr, &validator.Policy{
+			Normalizer: validator.NewNormalizerDefault(&validator.ConfigNormalizer{
+				Providers: []validator.EmailProvider{validator.EmailProviderGmail},
+			}),
+		})
+
+		// act
+		u := &user.User{
+			Username: optional.Some("JohnDoe"),
+			Password: optional.Some("password"),
+			Email: optional.Some("John.Doe+news@Gmail.com"),
+		}
+		err := vl.Prepare(u)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, optional.Some("JohnDoe"), u.Username)
+		require.Equal(t, optional.Some("johndoe"), u.UsernameCanonical)
+		require.Equal(t, optional.Some("John.Doe+news@Gmail.com"), u.Email)
+		require.Equal(t, optional.Some("johndoe@gmail.com"), u.EmailCanonical)
+		cr.AssertExpectations(t)
+	})
+
+	t.Run("success - password none is not encrypted", func(t *testing.T) {
+		// arrange
+		// - crypter: mock
+		cr := crypter.NewCrypterMock()
+
+		// - validator: default
+		vl := validator.NewValidatorDefault("", cr)
+
+		// act
+		u := &user.User{
+			Username: optional.Some("JohnDoe"),
+		}
+		err := vl.Prepare(u)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, optional.Some("johndoe"), u.UsernameCanonical)
+		require.False(t, u.Password.IsSome())
+		cr.AssertExpectations(t)
+	})
+}
+// Test for RuleUsername with a policy
+func TestRuleUsername_Policy(t *testing.T) {
+	type input struct {username string; policy validator.UsernamePolicy}
+	type output struct {err error; errMsg string}
+	type testCase struct {
+		title  string
+		input  input
+		output output
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - letters, digits, dots, dashes and underscores",
+			input: input{username: "john.doe-_42"},
+			output: output{err: nil},
+		},
+		{
+			title: "valid case - letters of other scripts",
+			input: input{username: "Ñandú"},
+			output: output{err: nil},
+		},
+		{
+			title: "valid case - letters of a single confusable script",
+			input: input{username: "иван"},
+			output: output{err: nil},
+		},
+		{
+			title: "valid case - mixed scripts allowed",
+			input: input{username: "pаypal", policy: validator.UsernamePolicy{RejectMixedScripts: optional.Some(false)}},
+			output: output{err: nil},
+		},
+
+		// invalid cases
+		{
+			title: "invalid case - whitespace",
+			input: input{username: "john doe"},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - username, invalid format",
+			},
+		},
+		{
+			title: "invalid case - control character",
+			input: input{username: "john\u200bdoe\x00"},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - username, invalid format",
+			},
+		},
+		{
+			title: "invalid case - at sign",
+			input: input{username: "john@doe"},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - username, invalid format",
+			},
+		},
+		{
+			title: "invalid case - confusable cyrillic letter in a latin username",
+			input: input{username: "pаypal"},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - username, should not mix latin, greek and cyrillic letters",
+			},
+		},
+		{
+			title: "invalid case - custom pattern",
+			input: input{username: "John", policy: validator.UsernamePolicy{Pattern: optional.Some(`^[a-z]+$`)}},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - username, invalid format",
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			vl := validator.NewValidatorChain(nil, nil, validator.NewRuleUsername(&c.input.policy))
+
+			// act
+			err := vl.Validate(&user.User{Username: optional.Some(c.input.username)})
+
+			// assert
+			require.ErrorIs(t, err, c.output.err)
+			if err != nil {
+				require.Equal(t, c.output.errMsg, err.Error())
+				require.Equal(t, map[string][]string{"username": {strings.TrimPrefix(c.output.errMsg, "validator: field quality - username, ")}}, validator.Fields(err))
+			}
+		})
+	}
+}
+
+// Test for ValidatorDefault.ValidatePassword with a policy
+func TestValidator_ValidatePassword_Policy(t *testing.T) {
+	type input struct {password string; identifiers []string}
+	type output struct {err error; errMsg string}
+	type testCase struct {
+		title  string
+		input  input
+		output output
+	}
+
+	cases := []testCase{
+		// valid cases
+		{
+			title: "valid case - all classes",
+			input: input{password: "Passw0rd!!"},
+			output: output{err: nil},
+		},
+		{
+			title: "valid case - long passphrase",
+			input: input{password: "Correct horse battery staple, 42 times!"},
+			output: output{err: nil},
+		},
+		{
+			title: "valid case - length counted in characters, not bytes",
+			input: input{password: "Ñandú-Pingüino-1"},
+			output: output{err: nil},
+		},
+
+		// invalid cases
+		{
+			title: "invalid case - missing classes",
+			input: input{password: "passwordpassword"},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - password, should contain an uppercase letter\n" +
+					"validator: field quality - password, should contain a digit\n" +
+					"validator: field quality - password, should contain a symbol",
+			},
+		},
+		{
+			title: "invalid case - too long",
+			input: input{password: "Passw0rd!" + strings.Repeat("a", 32)},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - password, chars should be between 10 and 40",
+			},
+		},
+		{
+			title: "invalid case - too many bytes, multibyte characters",
+			input: input{password: "Passw0rd!" + strings.Repeat("€", 22)},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - password, should be at most 72 bytes, use fewer special characters",
+			},
+		},
+		{
+			title: "invalid case - too many bytes after NFKC normalization",
+			input: input{password: "Passw0rd!" + strings.Repeat("㍿", 6)},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - password, should be at most 72 bytes, use fewer special characters",
+			},
+		},
+		{
+			title: "invalid case - forbidden substring, case insensitive",
+			input: input{password: "MSAuth-Passw0rd!"},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: `validator: field quality - password, should not contain "msauth"`,
+			},
+		},
+		{
+			title: "invalid case - forbidden substring after NFKC normalization",
+			input: input{password: "ｍｓａｕｔｈ-Passw0rd!"},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: `validator: field quality - password, should not contain "msauth"`,
+			},
+		},
+		{
+			title: "invalid case - identifier",
+			input: input{password: "Johndoe-Passw0rd!", identifiers: []string{"johndoe"}},
+			output: output{
+				err: validator.ErrValidatorFieldQuality,
+				errMsg: "validator: field quality - password, should not contain the username or email",
+			},
+		},
+	}
+
+	// run tests
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// arrange
+			vl := validator.NewValidatorDefaultPolicy("", nil, &validator.Policy{
+				Password: validator.PasswordPolicy{
+					MinLength: optional.Some(10),
+					MaxLength: optional.Some(40),
+					RequireUpper: true,
+					RequireLower: true,
+					RequireDigit: true,
+					RequireSymbol: true,
+					Forbidden: []string{"msauth"},
+					MinScore: optional.Some(0),
+				},
+			})
+
+			// act
+			err := vl.ValidatePassword(c.input.password, c.input.identifiers...)
+
+			// assert
+			require.ErrorIs(t, err, c.output.err)
+			if err != nil {
+				require.Equal(t, c.output.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+// Test for ValidatorDefault.PreparePassword
+func TestValidator_PreparePassword(t *testing.T) {
+	t.Run("success - password normalized before encryption", func(t *testing.T) {
+		// arrange
+		cr := crypter.NewCrypterMock()
+		cr.On("Encrypt", "Passw0rd!").Return("encrypted_password", nil)
+		vl := validator.NewValidatorDefault("", cr)
+
+		// act
+		prepared, err := vl.PreparePassword("Ｐａｓｓｗ０ｒｄ！")
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "encrypted_password", prepared)
+		cr.AssertExpectations(t)
+	})
+}
+
+// Test for ValidatorDefault.ValidatePassword with the strength estimation
+func TestValidator_ValidatePassword_Strength(t *testing.T) {
+	t.Run("failure - weak password returns the feedback", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorDefaultPolicy("", nil, &validator.Policy{
+			Password: validator.PasswordPolicy{RejectCommon: optional.Some(false)},
+		})
+
+		// act
+		err := vl.ValidatePassword("qwertyuiop")
+
+		// assert
+		require.ErrorIs(t, err, validator.ErrValidatorFieldQuality)
+		var weak *validator.WeakPasswordError
+		require.ErrorAs(t, err, &weak)
+		require.Equal(t, 2, weak.MinScore)
+		require.Less(t, weak.Score, 2)
+		require.NotEmpty(t, weak.Warning)
+		require.NotEmpty(t, weak.Suggestions)
+	})
+
+	t.Run("failure - password built around the identifiers", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorDefaultPolicy("", nil, &validator.Policy{
+			Password: validator.PasswordPolicy{ForbidIdentifiers: optional.Some(false)},
+		})
+
+		// act
+		err := vl.ValidatePassword("johndoe1987", "johndoe")
+
+		// assert
+		var weak *validator.WeakPasswordError
+		require.ErrorAs(t, err, &weak)
+		require.Equal(t, "Avoid using your username or email", weak.Warning)
+	})
+
+	t.Run("success - strong passphrase", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorDefaultPolicy("", nil, &validator.Policy{
+			Password: validator.PasswordPolicy{MinScore: optional.Some(4)},
+		})
+
+		// act
+		err := vl.ValidatePassword("purple-monkey-dishwasher")
+
+		// assert
+		require.NoError(t, err)
+	})
+
+	t.Run("success - estimation disabled", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorDefaultPolicy("", nil, &validator.Policy{
+			Password: validator.PasswordPolicy{RejectCommon: optional.Some(false), MinScore: optional.Some(0)},
+		})
+
+		// act
+		err := vl.ValidatePassword("qwertyuiop")
+
+		// assert
+		require.NoError(t, err)
+	})
+
+	t.Run("failure - long repeated password is rejected without being estimated", func(t *testing.T) {
+		// arrange
+		vl := validator.NewValidatorDefault("", nil)
+		start := time.Now()
+
+		// act
+		err := vl.ValidatePassword(strings.Repeat("a", 10000))
+
+		// assert
+		require.ErrorIs(t, err, validator.ErrValidatorFieldQuality)
+		var weak *validator.WeakPasswordError
+		require.False(t, errors.As(err, &weak))
+		require.Less(t, time.Since(start), time.Second)
+	})
+}
@@ -1,6 +1,8 @@
 package validator
 
 import (
+	"context"
+
 	"github.com/LNMMusic/msauth/internal/user"
 
 	"github.com/stretchr/testify/mock"
@@ -41,6 +43,19 @@ func (m *ValidatorMock) Validate(u *user.User) (err error) {
 	return
 }
 
+func (m *ValidatorMock) ValidatePatch(u *user.User, identifiers ...string) (err error) {
+	arguments := []any{u}
+	for _, id := range identifiers {
+		arguments = append(arguments, id)
+	}
+	args := m.Called(arguments...)
+
+	m.MethodValidate(u)
+
+	err = args.Error(0)
+	return
+}
+
 func (m *ValidatorMock) Prepare(u *user.User) (err error) {
 	args := m.Called(u)
 	
@@ -48,4 +63,41 @@ func (m *ValidatorMock) Prepare(u *user.User) (err error) {
 
 	err = args.Error(0)
 	return
-}
\ No newline at end of file
+}
+
+func (m *ValidatorMock) ValidatePassword(password string, identifiers ...string) (err error) {
+	arguments := []any{password}
+	for _, id := range identifiers {
+		arguments = append(arguments, id)
+	}
+	args := m.Called(arguments...)
+
+	err = args.Error(0)
+	return
+}
+
+func (m *ValidatorMock) PreparePassword(password string) (prepared string, err error) {
+	args := m.Called(password)
+
+	prepared = args.String(0)
+	err = args.Error(1)
+	return
+}
+
+// PrepareContext returns the error of a done context, otherwise the Prepare mock is called
+func (m *ValidatorMock) PrepareContext(ctx context.Context, u *user.User) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = m.Prepare(u)
+	return
+}
+
+// PreparePasswordContext returns the error of a done context, otherwise the PreparePassword mock is called
+func (m *ValidatorMock) PreparePasswordContext(ctx context.Context, password string) (prepared string, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	prepared, err = m.PreparePassword(password)
+	return
+}
@@ -0,0 +1,26 @@
+package verification
+
+import (
+	"errors"
+
+	"github.com/LNMMusic/msauth/internal/user"
+)
+
+var (
+	// ErrVerificationInternal is returned when an internal error occurs
+	ErrVerificationInternal = errors.New("verification: internal error")
+	// ErrVerificationSend is returned when the verification mail cannot be sent
+	ErrVerificationSend = errors.New("verification: cannot send verification")
+	// ErrVerificationTokenInvalid is returned when the verification token is invalid, expired or already used
+	ErrVerificationTokenInvalid = errors.New("verification: invalid token")
+)
+
+// Verification interface to handle the email verification flow of users
+// - users are created inactive and activated once they confirm their email
+type Verification interface {
+	// Send sends a verification token to the email of a user
+	Send(u user.User) (err error)
+
+	// Confirm confirms a verification token and activates its user
+	Confirm(token string) (err error)
+}
@@ -0,0 +1,128 @@
+package verification
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/linktoken"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/pkg/mailer"
+)
+
+// TemplateDefault is the default template of the verification mails
+// - data: Link is the confirm link
+var TemplateDefault = mailer.MustTemplate(
+	"Verify your email",
+	"Confirm your email by following this link: {{.Link}}",
+	`<p>Confirm your email by following this <a href="{{.Link}}">link</a>.</p>`,
+)
+
+// NewVerificationDefault returns a new VerificationDefault
+func NewVerificationDefault(lt linktoken.LinkToken, ml mailer.Mailer, stWrite storage.StorageWrite, config *Config) *VerificationDefault {
+	// default values
+	if config.Template == nil {
+		config.Template = TemplateDefault
+	}
+
+	return &VerificationDefault{
+		lt: lt,
+		ml: ml,
+		stWrite: stWrite,
+		config: config,
+	}
+}
+
+// Config is the configuration of VerificationDefault
+type Config struct {
+	// ConfirmURL is the url of the confirm endpoint, the token is appended as the "token" query param
+	ConfirmURL string
+	// Template is the template of the verification mails (default TemplateDefault)
+	Template *mailer.Template
+}
+
+// VerificationDefault is the default implementation of the Verification interface
+// - tokens are issued by a LinkToken and sent by a Mailer
+type VerificationDefault struct {
+	// lt is the LinkToken interface to issue and consume verification tokens
+	lt linktoken.LinkToken
+	// ml is the Mailer interface to send verification mails
+	ml mailer.Mailer
+	// stWrite is the write interface for the user store
+	stWrite storage.StorageWrite
+	// config is the configuration of the verification
+	config *Config
+}
+
+// Send sends a verification token to the email of a user
+func (v *VerificationDefault) Send(u user.User) (err error) {
+	// check email
+	if !u.Email.IsSome() {
+		err = fmt.Errorf("%w. email is none", ErrVerificationSend)
+		return
+	}
+	email, _ := u.Email.Unwrap()
+
+	// issue token
+	token, err := v.lt.Issue(u.Id)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrVerificationInternal, err.Error())
+		return
+	}
+
+	// link
+	link, err := url.Parse(v.config.ConfirmURL)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrVerificationInternal, err.Error())
+		return
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	// send mail
+	m, err := v.config.Template.Render([]string{email}, map[string]any{"Link": link.String()})
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrVerificationInternal, err.Error())
+		return
+	}
+	err = v.ml.Send(m)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrVerificationSend, err.Error())
+		return
+	}
+
+	return
+}
+
+// Confirm confirms a verification token and activates its user
+func (v *VerificationDefault) Confirm(token string) (err error) {
+	// consume token
+	userId, err := v.lt.Consume(token)
+	if err != nil {
+		switch {
+		case errors.Is(err, linktoken.ErrLinkTokenInvalid),
+			errors.Is(err, linktoken.ErrLinkTokenExpired),
+			errors.Is(err, linktoken.ErrLinkTokenUsed):
+			err = fmt.Errorf("%w. %s", ErrVerificationTokenInvalid, err.Error())
+		default:
+			err = fmt.Errorf("%w. %s", ErrVerificationInternal, err.Error())
+		}
+		return
+	}
+
+	// activate user
+	err = v.stWrite.Activate(userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrStorageNotFound):
+			err = fmt.Errorf("%w. %s", ErrVerificationTokenInvalid, err.Error())
+		default:
+			err = fmt.Errorf("%w. %s", ErrVerificationInternal, err.Error())
+		}
+		return
+	}
+
+	return
+}
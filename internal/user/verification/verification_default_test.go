@@ -0,0 +1,125 @@
+package verification_test
+
+import (
+	"testing"
+
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/LNMMusic/msauth/internal/user/linktoken"
+	"github.com/LNMMusic/msauth/internal/user/storage"
+	"github.com/LNMMusic/msauth/internal/user/verification"
+	"github.com/LNMMusic/msauth/pkg/mailer"
+	"github.com/LNMMusic/optional"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for VerificationDefault.Send
+func TestVerificationDefault_Send(t *testing.T) {
+	t.Run("success - verification mail sent", func(t *testing.T) {
+		// arrange
+		lt := linktoken.NewLinkTokenMock()
+		lt.On("Issue", 1).Return("token", nil)
+		ml := mailer.NewMailerMock()
+		ml.On("Send", &mailer.Mail{
+			To: []string{"johndoe@gmail.com"},
+			Subject: "Verify your email",
+			Text: "Confirm your email by following this link: https://auth.example.com/verify?token=token",
+			HTML: `<p>Confirm your email by following this <a href="https://auth.example.com/verify?token=token">link</a>.</p>`,
+		}).Return(nil)
+		vr := verification.NewVerificationDefault(lt, ml, nil, &verification.Config{ConfirmURL: "https://auth.example.com/verify"})
+
+		// act
+		err := vr.Send(user.User{Id: 1, Email: optional.Some("johndoe@gmail.com")})
+
+		// assert
+		require.NoError(t, err)
+		lt.AssertExpectations(t)
+		ml.AssertExpectations(t)
+	})
+
+	t.Run("failure - email is none", func(t *testing.T) {
+		// arrange
+		vr := verification.NewVerificationDefault(nil, nil, nil, &verification.Config{})
+
+		// act
+		err := vr.Send(user.User{Id: 1})
+
+		// assert
+		require.ErrorIs(t, err, verification.ErrVerificationSend)
+		require.EqualError(t, err, "verification: cannot send verification. email is none")
+	})
+
+	t.Run("failure - mailer error", func(t *testing.T) {
+		// arrange
+		lt := linktoken.NewLinkTokenMock()
+		lt.On("Issue", 1).Return("token", nil)
+		ml := mailer.NewMailerMock()
+		ml.On("Send", &mailer.Mail{
+			To: []string{"johndoe@gmail.com"},
+			Subject: "Verify your email",
+			Text: "Confirm your email by following this link: https://auth.example.com/verify?token=token",
+			HTML: `<p>Confirm your email by following this <a href="https://auth.example.com/verify?token=token">link</a>.</p>`,
+		}).Return(mailer.ErrMailerSend)
+		vr := verification.NewVerificationDefault(lt, ml, nil, &verification.Config{ConfirmURL: "https://auth.example.com/verify"})
+
+		// act
+		err := vr.Send(user.User{Id: 1, Email: optional.Some("johndoe@gmail.com")})
+
+		// assert
+		require.ErrorIs(t, err, verification.ErrVerificationSend)
+		require.EqualError(t, err, "verification: cannot send verification. mailer: cannot send mail")
+		lt.AssertExpectations(t)
+		ml.AssertExpectations(t)
+	})
+}
+
+// Tests for VerificationDefault.Confirm
+func TestVerificationDefault_Confirm(t *testing.T) {
+	t.Run("success - user activated", func(t *testing.T) {
+		// arrange
+		lt := linktoken.NewLinkTokenMock()
+		lt.On("Consume", "token").Return(1, nil)
+		st := storage.NewStorageWriteMock()
+		st.On("Activate", 1).Return(nil)
+		vr := verification.NewVerificationDefault(lt, nil, st, &verification.Config{})
+
+		// act
+		err := vr.Confirm("token")
+
+		// assert
+		require.NoError(t, err)
+		lt.AssertExpectations(t)
+		st.AssertExpectations(t)
+	})
+
+	t.Run("failure - token already used", func(t *testing.T) {
+		// arrange
+		lt := linktoken.NewLinkTokenMock()
+		lt.On("Consume", "token").Return(0, linktoken.ErrLinkTokenUsed)
+		vr := verification.NewVerificationDefault(lt, nil, nil, &verification.Config{})
+
+		// act
+		err := vr.Confirm("token")
+
+		// assert
+		require.ErrorIs(t, err, verification.ErrVerificationTokenInvalid)
+		require.EqualError(t, err, "verification: invalid token. linktoken: token already used")
+		lt.AssertExpectations(t)
+	})
+
+	t.Run("failure - user not found", func(t *testing.T) {
+		// arrange
+		lt := linktoken.NewLinkTokenMock()
+		lt.On("Consume", "token").Return(1, nil)
+		st := storage.NewStorageWriteMock()
+		st.On("Activate", 1).Return(storage.ErrStorageNotFound)
+		vr := verification.NewVerificationDefault(lt, nil, st, &verification.Config{})
+
+		// act
+		err := vr.Confirm("token")
+
+		// assert
+		require.ErrorIs(t, err, verification.ErrVerificationTokenInvalid)
+		lt.AssertExpectations(t)
+		st.AssertExpectations(t)
+	})
+}
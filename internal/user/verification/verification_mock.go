@@ -0,0 +1,31 @@
+package verification
+
+import (
+	"github.com/LNMMusic/msauth/internal/user"
+	"github.com/stretchr/testify/mock"
+)
+
+// NewVerificationMock returns a new mock verification
+func NewVerificationMock() *VerificationMock {
+	return &VerificationMock{}
+}
+
+// VerificationMock is a mock implementation of the Verification interface
+type VerificationMock struct {
+	// mock.Mock is a struct that implements the Mock struct for testing purposes
+	mock.Mock
+}
+
+// Send sends a verification token to the email of a user
+func (m *VerificationMock) Send(u user.User) (err error) {
+	args := m.Called(u)
+	err = args.Error(0)
+	return
+}
+
+// Confirm confirms a verification token and activates its user
+func (m *VerificationMock) Confirm(token string) (err error) {
+	args := m.Called(token)
+	err = args.Error(0)
+	return
+}
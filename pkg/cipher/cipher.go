@@ -0,0 +1,22 @@
+package cipher
+
+import "errors"
+
+var (
+	// ErrCipherKey is returned when the key is not valid
+	ErrCipherKey = errors.New("cipher: invalid key")
+	// ErrCipherEncryption is returned when an encryption error occurs
+	ErrCipherEncryption = errors.New("cipher: encryption error")
+	// ErrCipherDecryption is returned when a decryption error occurs
+	ErrCipherDecryption = errors.New("cipher: decryption error")
+)
+
+// Cipher interface for reversible encryption of secrets at rest
+// - unlike crypter.Crypter, the plain value can be recovered
+type Cipher interface {
+	// Encrypt encrypts a plain value
+	Encrypt(plain []byte) (sealed []byte, err error)
+
+	// Decrypt decrypts a value returned by Encrypt
+	Decrypt(sealed []byte) (plain []byte, err error)
+}
@@ -0,0 +1,62 @@
+package cipher
+
+import (
+	"crypto/aes"
+	gocipher "crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+// NewCipherAESGCM returns a new CipherAESGCM
+// - key must be 16, 24 or 32 bytes long (AES-128, AES-192 or AES-256)
+func NewCipherAESGCM(key []byte) (c *CipherAESGCM, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrCipherKey, err.Error())
+		return
+	}
+	aead, err := gocipher.NewGCM(block)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrCipherKey, err.Error())
+		return
+	}
+
+	c = &CipherAESGCM{aead: aead}
+	return
+}
+
+// CipherAESGCM is the implementation of the Cipher interface with AES-GCM
+// - sealed format: nonce + ciphertext + tag
+type CipherAESGCM struct {
+	// aead is the AES-GCM block cipher
+	aead gocipher.AEAD
+}
+
+// Encrypt encrypts a plain value with a random nonce
+func (c *CipherAESGCM) Encrypt(plain []byte) (sealed []byte, err error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrCipherEncryption, err.Error())
+		return
+	}
+
+	sealed = c.aead.Seal(nonce, nonce, plain, nil)
+	return
+}
+
+// Decrypt decrypts and authenticates a sealed value
+func (c *CipherAESGCM) Decrypt(sealed []byte) (plain []byte, err error) {
+	size := c.aead.NonceSize()
+	if len(sealed) < size {
+		err = fmt.Errorf("%w. sealed value too short", ErrCipherDecryption)
+		return
+	}
+
+	plain, err = c.aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrCipherDecryption, err.Error())
+		return
+	}
+	return
+}
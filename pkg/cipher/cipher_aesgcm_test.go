@@ -0,0 +1,85 @@
+package cipher_test
+
+import (
+	"testing"
+
+	"github.com/LNMMusic/msauth/pkg/cipher"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for CipherAESGCM
+func TestCipherAESGCM(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	t.Run("success - encrypt and decrypt", func(t *testing.T) {
+		// arrange
+		c, err := cipher.NewCipherAESGCM(key)
+		require.NoError(t, err)
+
+		// act
+		sealed, err := c.Encrypt([]byte("secret"))
+		require.NoError(t, err)
+		other, err := c.Encrypt([]byte("secret"))
+		require.NoError(t, err)
+		plain, err := c.Decrypt(sealed)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, []byte("secret"), plain)
+		require.NotContains(t, string(sealed), "secret")
+		require.NotEqual(t, sealed, other, "nonces must be random")
+	})
+
+	t.Run("failure - invalid key", func(t *testing.T) {
+		// act
+		c, err := cipher.NewCipherAESGCM([]byte("short"))
+
+		// assert
+		require.Nil(t, c)
+		require.ErrorIs(t, err, cipher.ErrCipherKey)
+	})
+
+	t.Run("failure - tampered value", func(t *testing.T) {
+		// arrange
+		c, err := cipher.NewCipherAESGCM(key)
+		require.NoError(t, err)
+		sealed, err := c.Encrypt([]byte("secret"))
+		require.NoError(t, err)
+		sealed[len(sealed)-1] ^= 0xff
+
+		// act
+		_, err = c.Decrypt(sealed)
+
+		// assert
+		require.ErrorIs(t, err, cipher.ErrCipherDecryption)
+	})
+
+	t.Run("failure - another key", func(t *testing.T) {
+		// arrange
+		c, err := cipher.NewCipherAESGCM(key)
+		require.NoError(t, err)
+		other, err := cipher.NewCipherAESGCM([]byte("fedcba9876543210fedcba9876543210"))
+		require.NoError(t, err)
+		sealed, err := c.Encrypt([]byte("secret"))
+		require.NoError(t, err)
+
+		// act
+		_, err = other.Decrypt(sealed)
+
+		// assert
+		require.ErrorIs(t, err, cipher.ErrCipherDecryption)
+	})
+
+	t.Run("failure - value too short", func(t *testing.T) {
+		// arrange
+		c, err := cipher.NewCipherAESGCM(key)
+		require.NoError(t, err)
+
+		// act
+		_, err = c.Decrypt([]byte("short"))
+
+		// assert
+		require.ErrorIs(t, err, cipher.ErrCipherDecryption)
+		require.EqualError(t, err, "cipher: decryption error. sealed value too short")
+	})
+}
@@ -0,0 +1,29 @@
+package cipher
+
+import "github.com/stretchr/testify/mock"
+
+// NewCipherMock creates a new mock for the cipher interface
+func NewCipherMock() *CipherMock {
+	return &CipherMock{}
+}
+
+// CipherMock is the mock for the cipher interface
+type CipherMock struct {
+	mock.Mock
+}
+
+// Encrypt is the mock for the Encrypt method
+func (c *CipherMock) Encrypt(plain []byte) (sealed []byte, err error) {
+	args := c.Called(plain)
+	sealed, _ = args.Get(0).([]byte)
+	err = args.Error(1)
+	return
+}
+
+// Decrypt is the mock for the Decrypt method
+func (c *CipherMock) Decrypt(sealed []byte) (plain []byte, err error) {
+	args := c.Called(sealed)
+	plain, _ = args.Get(0).([]byte)
+	err = args.Error(1)
+	return
+}
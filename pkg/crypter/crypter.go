@@ -1,6 +1,9 @@
 package crypter
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 var (
 	// ErrCrypterEncryption is returned when an encryption error occurs
@@ -17,4 +20,16 @@ type Crypter interface {
 	// Compare an encrypted string with a plain string and
 	// validate if they are the same
 	Compare(e string, s string) (err error)
-}
\ No newline at end of file
+}
+
+// CrypterContext interface for crypters aware of a context
+// - hashes are not started once the context is done, a started hash runs to completion
+type CrypterContext interface {
+	Crypter
+
+	// EncryptContext encrypts a string
+	EncryptContext(ctx context.Context, s string) (e string, err error)
+
+	// CompareContext compares an encrypted string with a plain string
+	CompareContext(ctx context.Context, e string, s string) (err error)
+}
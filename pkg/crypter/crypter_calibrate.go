@@ -0,0 +1,95 @@
+package crypter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrCrypterCalibration is returned when the cost cannot be calibrated
+	ErrCrypterCalibration = errors.New("crypter: calibration error")
+)
+
+// ConfigCalibration is the configuration to calibrate the cost of the bcrypt algorithm
+type ConfigCalibration struct {
+	// Target is the expected latency of a single hash (default 250ms)
+	Target 	time.Duration
+	// MinCost is the lowest cost to benchmark (default bcrypt.MinCost)
+	MinCost int
+	// MaxCost is the highest cost to benchmark (default bcrypt.MaxCost)
+	MaxCost int
+	// Logger logs the chosen cost (default log.Default())
+	Logger 	*log.Logger
+}
+
+// CalibrateCost benchmarks the host and returns the highest bcrypt cost whose hash latency does not exceed the target
+// - in case even the min cost exceeds the target, the min cost is returned
+// - bcrypt doubles its latency per cost, so the benchmark stops at the first cost above the target
+func CalibrateCost(config *ConfigCalibration) (cost int, err error) {
+	// default config
+	cfg := ConfigCalibration{
+		Target: 250 * time.Millisecond,
+		MinCost: bcrypt.MinCost,
+		MaxCost: bcrypt.MaxCost,
+		Logger: log.Default(),
+	}
+	if config != nil {
+		if config.Target > 0 {
+			cfg.Target = config.Target
+		}
+		if config.MinCost > 0 {
+			cfg.MinCost = config.MinCost
+		}
+		if config.MaxCost > 0 {
+			cfg.MaxCost = config.MaxCost
+		}
+		if config.Logger != nil {
+			cfg.Logger = config.Logger
+		}
+	}
+	if cfg.MinCost < bcrypt.MinCost || cfg.MaxCost > bcrypt.MaxCost || cfg.MinCost > cfg.MaxCost {
+		err = fmt.Errorf("%w. cost range [%d, %d] is invalid", ErrCrypterCalibration, cfg.MinCost, cfg.MaxCost)
+		return
+	}
+
+	// benchmark
+	sample := []byte("crypter-calibration-sample")
+	cost = cfg.MinCost
+	var latency time.Duration
+	for c := cfg.MinCost; c <= cfg.MaxCost; c++ {
+		start := time.Now()
+		_, err = bcrypt.GenerateFromPassword(sample, c)
+		if err != nil {
+			err = fmt.Errorf("%w. %s", ErrCrypterCalibration, err.Error())
+			return
+		}
+		elapsed := time.Since(start)
+
+		// check target
+		if elapsed > cfg.Target && c > cfg.MinCost {
+			break
+		}
+		cost, latency = c, elapsed
+		if elapsed > cfg.Target {
+			break
+		}
+	}
+
+	cfg.Logger.Printf("crypter: calibrated bcrypt cost %d (%s per hash, target %s)", cost, latency, cfg.Target)
+	return
+}
+
+// NewCrypterDefaultCalibrated returns a new CrypterDefault with a cost calibrated for the host
+func NewCrypterDefaultCalibrated(config *ConfigCalibration) (c *CrypterDefault, err error) {
+	cost, err := CalibrateCost(config)
+	if err != nil {
+		return
+	}
+
+	c = NewCrypterDefault(cost)
+	return
+}
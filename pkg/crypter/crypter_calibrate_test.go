@@ -0,0 +1,91 @@
+package crypter_test
+
+import (
+	"bytes"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/pkg/crypter"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Tests for CalibrateCost
+func TestCalibrateCost(t *testing.T) {
+	t.Run("success - target below min cost latency returns min cost", func(t *testing.T) {
+		// arrange
+		var buf bytes.Buffer
+		cfg := &crypter.ConfigCalibration{
+			Target: time.Nanosecond,
+			Logger: log.New(&buf, "", 0),
+		}
+
+		// act
+		cost, err := crypter.CalibrateCost(cfg)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, bcrypt.MinCost, cost)
+		require.Contains(t, buf.String(), "crypter: calibrated bcrypt cost 4")
+	})
+
+	t.Run("success - cost is bounded by max cost", func(t *testing.T) {
+		// arrange
+		var buf bytes.Buffer
+		cfg := &crypter.ConfigCalibration{
+			Target: time.Hour,
+			MinCost: bcrypt.MinCost,
+			MaxCost: bcrypt.MinCost + 1,
+			Logger: log.New(&buf, "", 0),
+		}
+
+		// act
+		cost, err := crypter.CalibrateCost(cfg)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, bcrypt.MinCost + 1, cost)
+	})
+
+	t.Run("failure - invalid cost range", func(t *testing.T) {
+		// arrange
+		var buf bytes.Buffer
+		cfg := &crypter.ConfigCalibration{
+			MinCost: 10,
+			MaxCost: 5,
+			Logger: log.New(&buf, "", 0),
+		}
+
+		// act
+		cost, err := crypter.CalibrateCost(cfg)
+
+		// assert
+		require.ErrorIs(t, err, crypter.ErrCrypterCalibration)
+		require.EqualError(t, err, "crypter: calibration error. cost range [10, 5] is invalid")
+		require.Zero(t, cost)
+		require.Empty(t, buf.String())
+	})
+}
+
+// Tests for NewCrypterDefaultCalibrated
+func TestNewCrypterDefaultCalibrated(t *testing.T) {
+	t.Run("success - crypter uses the calibrated cost", func(t *testing.T) {
+		// arrange
+		var buf bytes.Buffer
+		cfg := &crypter.ConfigCalibration{
+			Target: time.Nanosecond,
+			Logger: log.New(&buf, "", 0),
+		}
+
+		// act
+		cr, err := crypter.NewCrypterDefaultCalibrated(cfg)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, bcrypt.MinCost, cr.Cost())
+		e, err := cr.Encrypt("password")
+		require.NoError(t, err)
+		require.NoError(t, cr.Compare(e, "password"))
+	})
+}
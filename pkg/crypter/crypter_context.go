@@ -0,0 +1,47 @@
+package crypter
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithContext returns a CrypterContext of a Crypter
+// - crypters that are already aware of a context are returned as they are
+func WithContext(cr Crypter) (c CrypterContext) {
+	c, ok := cr.(CrypterContext)
+	if !ok {
+		c = &crypterContext{cr}
+	}
+	return
+}
+
+// crypterContext is the adapter of a Crypter to the CrypterContext interface
+type crypterContext struct {
+	Crypter
+}
+
+// EncryptContext encrypts a string, unless the context is done
+func (c *crypterContext) EncryptContext(ctx context.Context, s string) (e string, err error) {
+	e, err = run(ctx, ErrCrypterEncryption, func() (string, error) { return c.Encrypt(s) })
+	return
+}
+
+// CompareContext compares an encrypted string with a plain string, unless the context is done
+func (c *crypterContext) CompareContext(ctx context.Context, e string, s string) (err error) {
+	_, err = run(ctx, ErrCrypterComparison, func() (struct{}, error) { return struct{}{}, c.Compare(e, s) })
+	return
+}
+
+// run runs a hash operation, unless the context is done
+// - hashes are cpu bound and can not be interrupted, so the context is checked before the operation starts
+// and the operation runs inline, once started it runs to completion
+// - in case the context is done, the error wraps errKind and the error of the context
+func run[T any](ctx context.Context, errKind error, fn func() (T, error)) (v T, err error) {
+	if err = ctx.Err(); err != nil {
+		err = fmt.Errorf("%w. %w", errKind, err)
+		return
+	}
+
+	v, err = fn()
+	return
+}
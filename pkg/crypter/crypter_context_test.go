@@ -0,0 +1,85 @@
+package crypter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/pkg/crypter"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Tests for CrypterDefault.EncryptContext
+func TestCrypterDefault_EncryptContext(t *testing.T) {
+	t.Run("success - context not done", func(t *testing.T) {
+		// arrange
+		cr := crypter.NewCrypterDefault(bcrypt.MinCost)
+
+		// act
+		e, err := cr.EncryptContext(context.Background(), "password")
+
+		// assert
+		require.NoError(t, err)
+		require.NoError(t, cr.CompareContext(context.Background(), e, "password"))
+	})
+
+	t.Run("failure - context canceled before the hash", func(t *testing.T) {
+		// arrange
+		cr := crypter.NewCrypterDefault(bcrypt.MinCost)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// act
+		e, err := cr.EncryptContext(ctx, "password")
+
+		// assert
+		require.ErrorIs(t, err, crypter.ErrCrypterEncryption)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Empty(t, e)
+	})
+
+	t.Run("success - started hash runs to completion", func(t *testing.T) {
+		// arrange
+		cr := crypter.NewCrypterDefault(10)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+
+		// act
+		e, err := cr.EncryptContext(ctx, "password")
+
+		// assert
+		require.NoError(t, err)
+		require.NoError(t, cr.Compare(e, "password"))
+	})
+}
+
+// Tests for WithContext
+func TestWithContext(t *testing.T) {
+	t.Run("crypters aware of a context are returned as they are", func(t *testing.T) {
+		// arrange
+		cr := crypter.NewCrypterDefault(bcrypt.MinCost)
+
+		// act
+		c := crypter.WithContext(cr)
+
+		// assert
+		require.Same(t, cr, c)
+	})
+
+	t.Run("failure - plain crypters are not called once the context is done", func(t *testing.T) {
+		// arrange
+		mk := crypter.NewCrypterMock()
+		c := crypter.WithContext(struct{ crypter.Crypter }{mk})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// act
+		err := c.CompareContext(ctx, "hashed", "password")
+
+		// assert
+		require.ErrorIs(t, err, crypter.ErrCrypterComparison)
+		require.ErrorIs(t, err, context.Canceled)
+		mk.AssertExpectations(t)
+	})
+}
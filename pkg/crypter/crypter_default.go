@@ -1,6 +1,7 @@
 package crypter
 
 import (
+	"context"
 	"fmt"
 
 	"golang.org/x/crypto/bcrypt"
@@ -25,8 +26,26 @@ type CrypterDefault struct {
 	cost int
 }
 
+// Cost returns the cost of the bcrypt algorithm used by the crypter
+func (c *CrypterDefault) Cost() (cost int) {
+	cost = c.cost
+	return
+}
+
 // Encrypt encrypts an string
 func (c *CrypterDefault) Encrypt(s string) (e string, err error) {
+	e, err = c.EncryptContext(context.Background(), s)
+	return
+}
+
+// EncryptContext encrypts an string, unless the context is done
+func (c *CrypterDefault) EncryptContext(ctx context.Context, s string) (e string, err error) {
+	e, err = run(ctx, ErrCrypterEncryption, func() (string, error) { return c.encrypt(s) })
+	return
+}
+
+// encrypt encrypts an string
+func (c *CrypterDefault) encrypt(s string) (e string, err error) {
 	var b []byte
 	b, err = bcrypt.GenerateFromPassword([]byte(s), c.cost)
 	if err != nil {
@@ -40,6 +59,18 @@ func (c *CrypterDefault) Encrypt(s string) (e string, err error) {
 
 // Compare compares an encrypted string with a plain string
 func (c *CrypterDefault) Compare(e string, s string) (err error) {
+	err = c.CompareContext(context.Background(), e, s)
+	return
+}
+
+// CompareContext compares an encrypted string with a plain string, unless the context is done
+func (c *CrypterDefault) CompareContext(ctx context.Context, e string, s string) (err error) {
+	_, err = run(ctx, ErrCrypterComparison, func() (struct{}, error) { return struct{}{}, c.compare(e, s) })
+	return
+}
+
+// compare compares an encrypted string with a plain string
+func (c *CrypterDefault) compare(e string, s string) (err error) {
 	err = bcrypt.CompareHashAndPassword([]byte(e), []byte(s))
 	if err != nil {
 		err = fmt.Errorf("%w. %s", ErrCrypterComparison, err.Error())
@@ -1,6 +1,10 @@
 package crypter
 
-import "github.com/stretchr/testify/mock"
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
 
 // NewCrypterMock creates a new mock for the crypter interface
 func NewCrypterMock() *CrypterMock {
@@ -25,4 +29,24 @@ func (c *CrypterMock) Compare(s, e string) (err error) {
 	args := c.Called(s, e)
 	err = args.Error(0)
 	return
-}
\ No newline at end of file
+}
+
+// EncryptContext is the mock for the EncryptContext method
+// - it returns the error of a done context, otherwise the Encrypt mock is called
+func (c *CrypterMock) EncryptContext(ctx context.Context, s string) (e string, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	e, err = c.Encrypt(s)
+	return
+}
+
+// CompareContext is the mock for the CompareContext method
+// - it returns the error of a done context, otherwise the Compare mock is called
+func (c *CrypterMock) CompareContext(ctx context.Context, s, e string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	err = c.Compare(s, e)
+	return
+}
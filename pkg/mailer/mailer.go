@@ -0,0 +1,26 @@
+package mailer
+
+import "errors"
+
+var (
+	// ErrMailerSend is returned when a mail cannot be sent
+	ErrMailerSend = errors.New("mailer: cannot send mail")
+)
+
+// Mail is a struct that represents an outbound mail
+type Mail struct {
+	// To is the list of recipients
+	To 		[]string
+	// Subject is the subject of the mail
+	Subject string
+	// Text is the plain text body of the mail
+	Text 	string
+	// HTML is the html body of the mail (optional)
+	HTML 	string
+}
+
+// Mailer interface to send outbound mails (verification, password reset, notifications, etc)
+type Mailer interface {
+	// Send sends a mail
+	Send(m *Mail) (err error)
+}
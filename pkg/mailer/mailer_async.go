@@ -0,0 +1,85 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// NewMailerAsync returns a new MailerAsync and starts its worker
+// - config values that are zero are set to their defaults
+func NewMailerAsync(ml Mailer, config *ConfigAsync) *MailerAsync {
+	// default config
+	cfg := ConfigAsync{
+		QueueSize: 100,
+		Logger: log.Default(),
+	}
+	if config != nil {
+		if config.QueueSize > 0 {
+			cfg.QueueSize = config.QueueSize
+		}
+		if config.Logger != nil {
+			cfg.Logger = config.Logger
+		}
+	}
+
+	m := &MailerAsync{
+		ml: ml,
+		queue: make(chan *Mail, cfg.QueueSize),
+		logger: cfg.Logger,
+		wg: &sync.WaitGroup{},
+	}
+	m.wg.Add(1)
+	go m.work()
+	return m
+}
+
+// ConfigAsync is the configuration of MailerAsync
+type ConfigAsync struct {
+	// QueueSize is the number of mails waiting to be sent (default 100)
+	QueueSize 	int
+	// Logger logs the mails that could not be sent (default log.Default())
+	Logger 		*log.Logger
+}
+
+// MailerAsync is a decorator of the Mailer interface that sends the mails in the background
+// - Send only queues the mail, so the latency and the failures of the mailer do not reach the caller
+// (e.g. a reset request responds the same whether the email is registered or not)
+type MailerAsync struct {
+	// ml is the Mailer interface that sends the mails
+	ml Mailer
+	// queue is the queue of the mails waiting to be sent
+	queue chan *Mail
+	// logger logs the mails that could not be sent
+	logger *log.Logger
+	// wg waits for the worker to send the queued mails on Close
+	wg *sync.WaitGroup
+}
+
+// Send queues a mail
+// - it fails only when the queue is full
+func (s *MailerAsync) Send(m *Mail) (err error) {
+	select {
+	case s.queue <- m:
+	default:
+		err = fmt.Errorf("%w. queue is full", ErrMailerSend)
+	}
+	return
+}
+
+// Close stops queuing mails and waits for the queued ones to be sent
+func (s *MailerAsync) Close() {
+	close(s.queue)
+	s.wg.Wait()
+}
+
+// work sends the queued mails until the queue is closed
+func (s *MailerAsync) work() {
+	defer s.wg.Done()
+
+	for m := range s.queue {
+		if err := s.ml.Send(m); err != nil {
+			s.logger.Printf("mailer: mail to %v not sent: %s", m.To, err.Error())
+		}
+	}
+}
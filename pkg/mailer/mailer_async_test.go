@@ -0,0 +1,75 @@
+package mailer_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/LNMMusic/msauth/pkg/mailer"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for MailerAsync.Send
+func TestMailerAsync_Send(t *testing.T) {
+	t.Run("success - queued mails sent in the background", func(t *testing.T) {
+		// arrange
+		mm := mailer.NewMailerMemory()
+		ml := mailer.NewMailerAsync(mm, nil)
+
+		// act
+		err := ml.Send(&mailer.Mail{To: []string{"johndoe@gmail.com"}, Subject: "Verify your email"})
+		ml.Close()
+
+		// assert
+		require.NoError(t, err)
+		require.Len(t, mm.Mails(), 1)
+		require.Equal(t, []string{"johndoe@gmail.com"}, mm.Mails()[0].To)
+	})
+
+	t.Run("success - failures of the mailer are logged, not returned", func(t *testing.T) {
+		// arrange
+		mk := mailer.NewMailerMock()
+		mk.On("Send", mock.Anything).Return(errors.New("connection refused"))
+		var buf bytes.Buffer
+		ml := mailer.NewMailerAsync(mk, &mailer.ConfigAsync{Logger: log.New(&buf, "", 0)})
+
+		// act
+		err := ml.Send(&mailer.Mail{To: []string{"johndoe@gmail.com"}})
+		ml.Close()
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "mailer: mail to [johndoe@gmail.com] not sent: connection refused\n", buf.String())
+		mk.AssertExpectations(t)
+	})
+
+	t.Run("failure - queue is full", func(t *testing.T) {
+		// arrange
+		// - the worker blocks on the first mail, the second one fills the queue
+		sending, release := make(chan struct{}, 1), make(chan struct{})
+		mk := mailer.NewMailerMock()
+		mk.On("Send", mock.Anything).Run(func(args mock.Arguments) {
+			select {
+			case sending <- struct{}{}:
+			default:
+			}
+			<-release
+		}).Return(nil)
+		ml := mailer.NewMailerAsync(mk, &mailer.ConfigAsync{QueueSize: 1})
+		require.NoError(t, ml.Send(&mailer.Mail{}))
+		<-sending
+		require.NoError(t, ml.Send(&mailer.Mail{}))
+
+		// act
+		err := ml.Send(&mailer.Mail{})
+		close(release)
+		ml.Close()
+
+		// assert
+		require.ErrorIs(t, err, mailer.ErrMailerSend)
+		require.EqualError(t, err, "mailer: cannot send mail. queue is full")
+		mk.AssertNumberOfCalls(t, "Send", 2)
+	})
+}
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// NewMailerFile returns a new MailerFile
+func NewMailerFile(dir string, from string) *MailerFile {
+	return &MailerFile{
+		dir: dir,
+		from: from,
+	}
+}
+
+// MailerFile is a file implementation of the Mailer interface
+// - mails are not sent but written as .eml files in a directory, meant for development
+type MailerFile struct {
+	// dir is the directory where the mails are written
+	dir string
+	// from is the sender address of the mails
+	from string
+}
+
+// Send sends a mail
+func (s *MailerFile) Send(m *Mail) (err error) {
+	// message
+	msg, err := Message(s.from, m)
+	if err != nil {
+		return
+	}
+
+	// file name: date and random suffix
+	b := make([]byte, 4)
+	_, err = rand.Read(b)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMailerSend, err.Error())
+		return
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(b))
+
+	// write
+	err = os.WriteFile(filepath.Join(s.dir, name), msg, 0o600)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMailerSend, err.Error())
+		return
+	}
+
+	return
+}
@@ -0,0 +1,63 @@
+package mailer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LNMMusic/msauth/pkg/mailer"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for MailerFile.Send
+func TestMailerFile_Send(t *testing.T) {
+	t.Run("success - mail written as eml file", func(t *testing.T) {
+		// arrange
+		dir := t.TempDir()
+		ml := mailer.NewMailerFile(dir, "no-reply@msauth.dev")
+
+		// act
+		err := ml.Send(&mailer.Mail{
+			To: []string{"johndoe@gmail.com"},
+			Subject: "Verify your email",
+			Text: "Confirm your email",
+		})
+
+		// assert
+		require.NoError(t, err)
+		files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		b, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		require.Contains(t, string(b), "To: johndoe@gmail.com\r\n")
+		require.Contains(t, string(b), "\r\n\r\nConfirm your email")
+	})
+
+	t.Run("failure - directory does not exist", func(t *testing.T) {
+		// arrange
+		ml := mailer.NewMailerFile(filepath.Join(t.TempDir(), "missing"), "no-reply@msauth.dev")
+
+		// act
+		err := ml.Send(&mailer.Mail{To: []string{"johndoe@gmail.com"}})
+
+		// assert
+		require.ErrorIs(t, err, mailer.ErrMailerSend)
+	})
+}
+
+// Tests for MailerMemory.Send
+func TestMailerMemory_Send(t *testing.T) {
+	t.Run("success - mails kept in memory", func(t *testing.T) {
+		// arrange
+		ml := mailer.NewMailerMemory()
+		m := &mailer.Mail{To: []string{"johndoe@gmail.com"}, Subject: "subject", Text: "text"}
+
+		// act
+		err := ml.Send(m)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, []*mailer.Mail{m}, ml.Mails())
+	})
+}
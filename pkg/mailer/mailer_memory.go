@@ -0,0 +1,38 @@
+package mailer
+
+import "sync"
+
+// NewMailerMemory returns a new MailerMemory
+func NewMailerMemory() *MailerMemory {
+	return &MailerMemory{
+		mu: &sync.Mutex{},
+	}
+}
+
+// MailerMemory is an in-memory implementation of the Mailer interface
+// - mails are not sent but kept in memory, meant for development and tests
+type MailerMemory struct {
+	// mails are the sent mails
+	mails []*Mail
+	// mu is the mutex of the mails
+	mu *sync.Mutex
+}
+
+// Send sends a mail
+func (s *MailerMemory) Send(m *Mail) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mails = append(s.mails, m)
+	return
+}
+
+// Mails returns the sent mails
+func (s *MailerMemory) Mails() (mails []*Mail) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mails = make([]*Mail, len(s.mails))
+	copy(mails, s.mails)
+	return
+}
@@ -0,0 +1,21 @@
+package mailer
+
+import "github.com/stretchr/testify/mock"
+
+// NewMailerMock returns a new mock mailer
+func NewMailerMock() *MailerMock {
+	return &MailerMock{}
+}
+
+// MailerMock is a mock implementation of the Mailer interface
+type MailerMock struct {
+	// mock.Mock is a struct that implements the Mock struct for testing purposes
+	mock.Mock
+}
+
+// Send sends a mail
+func (m *MailerMock) Send(mail *Mail) (err error) {
+	args := m.Called(mail)
+	err = args.Error(0)
+	return
+}
@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+// NewMailerSMTP returns a new MailerSMTP
+func NewMailerSMTP(config *ConfigSMTP) *MailerSMTP {
+	return &MailerSMTP{
+		config: config,
+	}
+}
+
+// ConfigSMTP is the configuration of MailerSMTP
+type ConfigSMTP struct {
+	// Host is the host of the smtp server
+	Host 		string
+	// Port is the port of the smtp server
+	Port 		int
+	// Username is the username to authenticate (optional, PLAIN auth)
+	Username 	string
+	// Password is the password to authenticate
+	Password 	string
+	// From is the sender address of the mails
+	From 		string
+}
+
+// MailerSMTP is the smtp implementation of the Mailer interface
+// - STARTTLS is used when the server supports it
+type MailerSMTP struct {
+	// config is the configuration of the smtp server
+	config *ConfigSMTP
+}
+
+// Send sends a mail
+func (s *MailerSMTP) Send(m *Mail) (err error) {
+	// message
+	msg, err := Message(s.config.From, m)
+	if err != nil {
+		return
+	}
+
+	// auth
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	// send
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	err = smtp.SendMail(addr, auth, s.config.From, m.To, msg)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMailerSend, err.Error())
+		return
+	}
+
+	return
+}
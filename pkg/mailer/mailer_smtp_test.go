@@ -0,0 +1,159 @@
+package mailer_test
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/LNMMusic/msauth/pkg/mailer"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSession is a session received by the smtp stand-in server
+type smtpSession struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// newSMTPServer starts a local smtp stand-in server that accepts a single session
+// - it supports EHLO, AUTH PLAIN, MAIL, RCPT, DATA and QUIT
+func newSMTPServer(t *testing.T) (port int, sessions chan *smtpSession) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	sessions = make(chan *smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		s := &smtpSession{}
+		tp.PrintfLine("220 localhost ESMTP stand-in")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO":
+				tp.PrintfLine("250-localhost")
+				tp.PrintfLine("250 AUTH PLAIN")
+			case "AUTH":
+				s.auth = line
+				tp.PrintfLine("235 2.7.0 authentication successful")
+			case "MAIL":
+				s.from = line
+				tp.PrintfLine("250 2.1.0 ok")
+			case "RCPT":
+				s.to = append(s.to, line)
+				tp.PrintfLine("250 2.1.5 ok")
+			case "DATA":
+				tp.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+				b, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				s.data = string(b)
+				tp.PrintfLine("250 2.0.0 ok")
+			case "QUIT":
+				tp.PrintfLine("221 2.0.0 bye")
+				sessions <- s
+				return
+			default:
+				tp.PrintfLine("502 5.5.2 command not implemented")
+			}
+		}
+	}()
+
+	port = ln.Addr().(*net.TCPAddr).Port
+	return
+}
+
+// Tests for MailerSMTP.Send
+func TestMailerSMTP_Send(t *testing.T) {
+	t.Run("success - text mail without auth", func(t *testing.T) {
+		// arrange
+		port, sessions := newSMTPServer(t)
+		ml := mailer.NewMailerSMTP(&mailer.ConfigSMTP{
+			Host: "127.0.0.1",
+			Port: port,
+			From: "no-reply@msauth.dev",
+		})
+
+		// act
+		err := ml.Send(&mailer.Mail{
+			To: []string{"johndoe@gmail.com"},
+			Subject: "Verify your email",
+			Text: "Confirm your email",
+		})
+
+		// assert
+		require.NoError(t, err)
+		s := <-sessions
+		require.Empty(t, s.auth)
+		require.Equal(t, "MAIL FROM:<no-reply@msauth.dev>", s.from)
+		require.Equal(t, []string{"RCPT TO:<johndoe@gmail.com>"}, s.to)
+		require.Contains(t, s.data, "From: no-reply@msauth.dev\n")
+		require.Contains(t, s.data, "To: johndoe@gmail.com\n")
+		require.Contains(t, s.data, "Subject: Verify your email\n")
+		require.Contains(t, s.data, "Content-Type: text/plain; charset=utf-8\n")
+		require.True(t, strings.HasSuffix(s.data, "\n\nConfirm your email\n"))
+	})
+
+	t.Run("success - multipart mail with auth", func(t *testing.T) {
+		// arrange
+		port, sessions := newSMTPServer(t)
+		ml := mailer.NewMailerSMTP(&mailer.ConfigSMTP{
+			Host: "localhost",
+			Port: port,
+			Username: "user",
+			Password: "pass",
+			From: "no-reply@msauth.dev",
+		})
+
+		// act
+		err := ml.Send(&mailer.Mail{
+			To: []string{"johndoe@gmail.com", "janedoe@gmail.com"},
+			Subject: "Verify your email",
+			Text: "Confirm your email",
+			HTML: "<p>Confirm your email</p>",
+		})
+
+		// assert
+		require.NoError(t, err)
+		s := <-sessions
+		require.Equal(t, "AUTH PLAIN AHVzZXIAcGFzcw==", s.auth)
+		require.Equal(t, []string{"RCPT TO:<johndoe@gmail.com>", "RCPT TO:<janedoe@gmail.com>"}, s.to)
+		require.Contains(t, s.data, "To: johndoe@gmail.com, janedoe@gmail.com\n")
+		require.Contains(t, s.data, "Content-Type: multipart/alternative; boundary=")
+		require.Contains(t, s.data, "Content-Type: text/plain; charset=utf-8\n\nConfirm your email\n")
+		require.Contains(t, s.data, "Content-Type: text/html; charset=utf-8\n\n<p>Confirm your email</p>\n")
+	})
+
+	t.Run("failure - server unreachable", func(t *testing.T) {
+		// arrange
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := ln.Addr().(*net.TCPAddr).Port
+		ln.Close()
+		ml := mailer.NewMailerSMTP(&mailer.ConfigSMTP{
+			Host: "127.0.0.1",
+			Port: port,
+			From: "no-reply@msauth.dev",
+		})
+
+		// act
+		err = ml.Send(&mailer.Mail{To: []string{"johndoe@gmail.com"}, Subject: "subject", Text: "text"})
+
+		// assert
+		require.ErrorIs(t, err, mailer.ErrMailerSend)
+	})
+}
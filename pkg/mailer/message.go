@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message builds the MIME message of a mail
+// - in case of the mail having an html body, it is sent as multipart/alternative with the text body
+func Message(from string, m *Mail) (msg []byte, err error) {
+	var buf bytes.Buffer
+
+	// headers
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	// body: text
+	if m.HTML == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		buf.WriteString(m.Text)
+		msg = buf.Bytes()
+		return
+	}
+
+	// body: text and html
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	parts := []struct{contentType string; body string}{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	}
+	for _, p := range parts {
+		var w io.Writer
+		w, err = mw.CreatePart(textproto.MIMEHeader{"Content-Type": {p.contentType}})
+		if err != nil {
+			err = fmt.Errorf("%w. %s", ErrMailerSend, err.Error())
+			return
+		}
+		_, err = w.Write([]byte(p.body))
+		if err != nil {
+			err = fmt.Errorf("%w. %s", ErrMailerSend, err.Error())
+			return
+		}
+	}
+	err = mw.Close()
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMailerSend, err.Error())
+		return
+	}
+
+	msg = buf.Bytes()
+	return
+}
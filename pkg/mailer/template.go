@@ -0,0 +1,90 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+var (
+	// ErrMailerTemplate is returned when a template cannot be parsed or rendered
+	ErrMailerTemplate = errors.New("mailer: template error")
+)
+
+// NewTemplate returns a new Template from the subject, text and html templates
+// - html is optional, an empty html template renders mails without html body
+func NewTemplate(subject string, text string, html string) (t *Template, err error) {
+	tp := &Template{}
+	tp.subject, err = texttemplate.New("subject").Parse(subject)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMailerTemplate, err.Error())
+		return
+	}
+	tp.text, err = texttemplate.New("text").Parse(text)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMailerTemplate, err.Error())
+		return
+	}
+	if html != "" {
+		tp.html, err = htmltemplate.New("html").Parse(html)
+		if err != nil {
+			err = fmt.Errorf("%w. %s", ErrMailerTemplate, err.Error())
+			return
+		}
+	}
+
+	t = tp
+	return
+}
+
+// MustTemplate is like NewTemplate but panics in case of error
+func MustTemplate(subject string, text string, html string) (t *Template) {
+	t, err := NewTemplate(subject, text, html)
+	if err != nil {
+		panic(err)
+	}
+	return
+}
+
+// Template is a mail template with text and html bodies
+// - html bodies are escaped with html/template
+type Template struct {
+	// subject is the template of the subject
+	subject *texttemplate.Template
+	// text is the template of the text body
+	text *texttemplate.Template
+	// html is the template of the html body (optional)
+	html *htmltemplate.Template
+}
+
+// Render renders a mail for the recipients with the given data
+func (t *Template) Render(to []string, data any) (m *Mail, err error) {
+	var subject, text, html bytes.Buffer
+	err = t.subject.Execute(&subject, data)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMailerTemplate, err.Error())
+		return
+	}
+	err = t.text.Execute(&text, data)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrMailerTemplate, err.Error())
+		return
+	}
+	if t.html != nil {
+		err = t.html.Execute(&html, data)
+		if err != nil {
+			err = fmt.Errorf("%w. %s", ErrMailerTemplate, err.Error())
+			return
+		}
+	}
+
+	m = &Mail{
+		To: to,
+		Subject: subject.String(),
+		Text: text.String(),
+		HTML: html.String(),
+	}
+	return
+}
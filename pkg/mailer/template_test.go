@@ -0,0 +1,70 @@
+package mailer_test
+
+import (
+	"testing"
+
+	"github.com/LNMMusic/msauth/pkg/mailer"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Template.Render
+func TestTemplate_Render(t *testing.T) {
+	t.Run("success - text and html bodies", func(t *testing.T) {
+		// arrange
+		tp, err := mailer.NewTemplate(
+			"Welcome {{.Username}}",
+			"Hi {{.Username}}, follow {{.Link}}",
+			`<p>Hi {{.Username}}, follow <a href="{{.Link}}">this link</a></p>`,
+		)
+		require.NoError(t, err)
+
+		// act
+		m, err := tp.Render([]string{"johndoe@gmail.com"}, map[string]any{
+			"Username": "<johndoe>",
+			"Link": "https://auth.example.com/verify?token=a&b",
+		})
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, &mailer.Mail{
+			To: []string{"johndoe@gmail.com"},
+			Subject: "Welcome <johndoe>",
+			Text: "Hi <johndoe>, follow https://auth.example.com/verify?token=a&b",
+			HTML: `<p>Hi &lt;johndoe&gt;, follow <a href="https://auth.example.com/verify?token=a&amp;b">this link</a></p>`,
+		}, m)
+	})
+
+	t.Run("success - text body only", func(t *testing.T) {
+		// arrange
+		tp := mailer.MustTemplate("Subject", "Text {{.}}", "")
+
+		// act
+		m, err := tp.Render([]string{"johndoe@gmail.com"}, "body")
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "Text body", m.Text)
+		require.Empty(t, m.HTML)
+	})
+
+	t.Run("failure - invalid template", func(t *testing.T) {
+		// act
+		tp, err := mailer.NewTemplate("{{.Subject", "", "")
+
+		// assert
+		require.ErrorIs(t, err, mailer.ErrMailerTemplate)
+		require.Nil(t, tp)
+	})
+
+	t.Run("failure - render error", func(t *testing.T) {
+		// arrange
+		tp := mailer.MustTemplate("Subject", "{{.Missing.Field}}", "")
+
+		// act
+		m, err := tp.Render([]string{"johndoe@gmail.com"}, struct{}{})
+
+		// assert
+		require.ErrorIs(t, err, mailer.ErrMailerTemplate)
+		require.Nil(t, m)
+	})
+}
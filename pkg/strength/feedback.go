@@ -0,0 +1,86 @@
+package strength
+
+import "unicode"
+
+// feedback returns the warning and suggestions of a password from the patterns it is made of
+// - strong passwords get no feedback
+func feedback(score int, runes []rune, seq []match) (warning string, suggestions []string) {
+	if score >= 3 {
+		return
+	}
+	if len(seq) == 0 {
+		suggestions = []string{
+			"Use a few words, avoid common phrases",
+			"No need for symbols, digits, or uppercase letters",
+		}
+		if len(runes) < 12 {
+			suggestions = append(suggestions, "Use a longer password")
+		}
+		return
+	}
+
+	// the longest match drives the warning
+	longest := seq[0]
+	for _, m := range seq[1:] {
+		if m.j-m.i > longest.j-longest.i {
+			longest = m
+		}
+	}
+	warning, suggestions = feedbackMatch(longest, runes, len(seq) == 1)
+	suggestions = append([]string{"Add another word or two. Uncommon words are better"}, suggestions...)
+	return
+}
+
+// feedbackMatch returns the warning and suggestions of a match
+// - sole is set when the match is the only pattern of the password
+func feedbackMatch(m match, runes []rune, sole bool) (warning string, suggestions []string) {
+	switch m.pattern {
+	case patternUserInput:
+		warning = "Avoid using your username or email"
+	case patternDictionary:
+		switch {
+		case sole && m.rank <= 10:
+			warning = "This is a top-10 common password"
+		case sole && m.rank <= 100:
+			warning = "This is a top-100 common password"
+		case sole:
+			warning = "This is a very common password"
+		default:
+			warning = "A word by itself is easy to guess"
+		}
+		word := runes[m.i:m.j]
+		switch {
+		case unicode.IsUpper(word[0]) && !hasUpper(word[1:]):
+			suggestions = append(suggestions, "Capitalization doesn't help very much")
+		case m.upper && !hasLower(word):
+			suggestions = append(suggestions, "All-uppercase is almost as easy to guess as all-lowercase")
+		}
+		if m.l33t {
+			suggestions = append(suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much")
+		}
+	case patternKeyboard:
+		warning = "Straight rows or short patterns of keys are easy to guess"
+		suggestions = append(suggestions, "Use a longer keyboard pattern with more turns")
+	case patternRepeat:
+		warning = "Repeats like \"aaa\" or \"abcabc\" are easy to guess"
+		suggestions = append(suggestions, "Avoid repeated words and characters")
+	case patternSequence:
+		warning = "Sequences like abc or 6543 are easy to guess"
+		suggestions = append(suggestions, "Avoid sequences")
+	case patternDate:
+		warning = "Dates are often easy to guess"
+		suggestions = append(suggestions, "Avoid dates and years that are associated with you")
+	}
+	return
+}
+
+// hasLower reports whether there is a lowercase letter
+func hasLower(runes []rune) (ok bool) {
+	for _, r := range runes {
+		if unicode.IsLower(r) {
+			ok = true
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,366 @@
+package strength
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// pattern is the kind of a match
+type pattern int
+
+const (
+	patternDictionary pattern = iota
+	patternUserInput
+	patternKeyboard
+	patternRepeat
+	patternSequence
+	patternDate
+)
+
+// match is a pattern found in the runes [i, j) of a password
+type match struct {
+	pattern pattern
+	i, j    int
+	// bits is the entropy of the match
+	bits 	float64
+	// rank is the rank of a dictionary word (1 is the most common)
+	rank 	int
+	// l33t is set when a dictionary word was found after undoing substitutions
+	l33t 	bool
+	// upper is set when a dictionary word has uppercase letters
+	upper 	bool
+}
+
+//go:embed dictionary.txt
+var dictionaryRaw string
+
+// dictionary maps the lowercase words to their rank, the most common passwords first and then common words
+var dictionary = func() (d map[string]int) {
+	d = make(map[string]int)
+	for _, line := range strings.Split(dictionaryRaw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, ok := d[line]; !ok {
+			d[line] = len(d) + 1
+		}
+	}
+	return
+}()
+
+// maxWord is the length of the longest word matched in the dictionaries
+const maxWord = 32
+
+// l33t are the usual substitutions of letters
+var l33t = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i', '!': 'i', '|': 'l',
+	'0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+// matches returns all the patterns found in the password
+// - chunks memoizes the entropy of the repeated chunks, shared by the nested estimations of the repeats
+func matches(runes []rune, user map[string]int, chunks map[string]float64) (ms []match) {
+	ms = append(ms, matchDictionary(runes, user)...)
+	ms = append(ms, matchKeyboard(runes)...)
+	ms = append(ms, matchRepeat(runes, chunks)...)
+	ms = append(ms, matchSequence(runes)...)
+	ms = append(ms, matchDate(runes)...)
+	return
+}
+
+// matchDictionary matches the words of the dictionary and of the user inputs, also after undoing l33t substitutions
+func matchDictionary(runes []rune, user map[string]int) (ms []match) {
+	lowered := []rune(strings.ToLower(string(runes)))
+	unl33t := make([]rune, len(lowered))
+	for k, r := range lowered {
+		unl33t[k] = r
+		if s, ok := l33t[r]; ok {
+			unl33t[k] = s
+		}
+	}
+
+	for i := range runes {
+		for j := i + 3; j <= len(runes) && j-i <= maxWord; j++ {
+			upper := hasUpper(runes[i:j])
+			for _, candidate := range []struct {
+				word string
+				l33t bool
+			}{{string(lowered[i:j]), false}, {string(unl33t[i:j]), true}} {
+				if candidate.l33t && candidate.word == string(lowered[i:j]) {
+					continue
+				}
+				if rank, ok := user[candidate.word]; ok {
+					ms = append(ms, dictionaryMatch(patternUserInput, i, j, rank, upper, candidate.l33t, runes[i:j]))
+				}
+				if rank, ok := dictionary[candidate.word]; ok {
+					ms = append(ms, dictionaryMatch(patternDictionary, i, j, rank, upper, candidate.l33t, runes[i:j]))
+				}
+			}
+		}
+	}
+	return
+}
+
+// dictionaryMatch returns a dictionary match with its entropy
+// - capitalizing the first or all the letters adds a single bit, any other capitalization one bit per uppercase letter
+func dictionaryMatch(p pattern, i, j, rank int, upper, l bool, word []rune) (m match) {
+	m = match{pattern: p, i: i, j: j, rank: rank, upper: upper, l33t: l}
+	m.bits = math.Log2(float64(rank + 1))
+	if upper {
+		n := 0
+		for _, r := range word {
+			if unicode.IsUpper(r) {
+				n++
+			}
+		}
+		if unicode.IsUpper(word[0]) && n == 1 || n == len(word) {
+			m.bits++
+		} else {
+			m.bits += float64(n)
+		}
+	}
+	if l {
+		m.bits++
+	}
+	return
+}
+
+// keyboard is the qwerty layout, unshifted and shifted rows
+var keyboard = [][2]string{
+	{"`1234567890-=", "~!@#$%^&*()_+"},
+	{"qwertyuiop[]\\", "QWERTYUIOP{}|"},
+	{"asdfghjkl;'", "ASDFGHJKL:\""},
+	{"zxcvbnm,./", "ZXCVBNM<>?"},
+}
+
+// keys maps every character of the keyboard to its row and column
+var keys = func() (k map[rune][2]int) {
+	k = make(map[rune][2]int)
+	for row, r := range keyboard {
+		for _, s := range r {
+			for col, c := range s {
+				k[c] = [2]int{row, col}
+			}
+		}
+	}
+	return
+}()
+
+// direction returns the direction of the move between two adjacent keys, 0 if they are not adjacent
+// - rows are staggered, each row is shifted half a key to the right of the row above
+func direction(a, b rune) (d int) {
+	ka, ok1 := keys[a]
+	kb, ok2 := keys[b]
+	if !ok1 || !ok2 || ka == kb {
+		return
+	}
+	dr, dc := kb[0]-ka[0], kb[1]-ka[1]
+	switch {
+	case dr == 0 && (dc == 1 || dc == -1):
+		d = 1 + (dc+1)/2
+	case dr == 1 && (dc == 0 || dc == -1):
+		d = 3 + (dc + 1)
+	case dr == -1 && (dc == 0 || dc == 1):
+		d = 5 + dc
+	}
+	return
+}
+
+// matchKeyboard matches walks of at least 4 adjacent keys
+// - the entropy grows with the starting key, the length and the number of turns of the walk
+func matchKeyboard(runes []rune) (ms []match) {
+	for i := 0; i < len(runes)-1; {
+		j, turns, prev := i+1, 0, 0
+		for j < len(runes) {
+			d := direction(runes[j-1], runes[j])
+			if d == 0 {
+				break
+			}
+			if prev != 0 && d != prev {
+				turns++
+			}
+			prev = d
+			j++
+		}
+		if j-i >= 4 {
+			bits := math.Log2(float64(len(keys)/2)) + math.Log2(float64(j-i)) + float64(turns)*2
+			if hasUpper(runes[i:j]) {
+				bits++
+			}
+			ms = append(ms, match{pattern: patternKeyboard, i: i, j: j, bits: bits})
+		}
+		if j-1 > i {
+			i = j - 1
+		} else {
+			i++
+		}
+	}
+	return
+}
+
+// matchRepeat matches a character repeated at least 3 times and a chunk repeated at least twice
+// - the entropy of a repeat is the entropy of its chunk plus the number of repetitions
+// - the entropy of a chunk is estimated once and memoized in chunks, so nested repeats are not estimated again
+func matchRepeat(runes []rune, chunks map[string]float64) (ms []match) {
+	n := len(runes)
+	for i := 0; i < n; i++ {
+		for size := 1; size <= (n-i)/2; size++ {
+			count := 1
+			for i+(count+1)*size <= n && string(runes[i+count*size:i+(count+1)*size]) == string(runes[i:i+size]) {
+				count++
+			}
+			if count < 2 || size == 1 && count < 3 {
+				continue
+			}
+			chunk := string(runes[i:i+size])
+			bits, ok := chunks[chunk]
+			if !ok {
+				_, bits = cheapest(runes[i:i+size], matches(runes[i:i+size], nil, chunks))
+				chunks[chunk] = bits
+			}
+			ms = append(ms, match{pattern: patternRepeat, i: i, j: i + count*size, bits: bits + math.Log2(float64(count))})
+		}
+	}
+	return
+}
+
+// matchSequence matches at least 3 consecutive letters or digits, ascending or descending (e.g. abc, 6543)
+func matchSequence(runes []rune) (ms []match) {
+	for i := 0; i < len(runes)-2; {
+		delta := runes[i+1] - runes[i]
+		j := i + 1
+		if (delta == 1 || delta == -1) && sameClass(runes[i], runes[j]) {
+			for j+1 < len(runes) && runes[j+1]-runes[j] == delta && sameClass(runes[j], runes[j+1]) {
+				j++
+			}
+		}
+		if j-i+1 >= 3 {
+			base := 26.0
+			if unicode.IsDigit(runes[i]) {
+				base = 10
+			}
+			switch runes[i] {
+			case 'a', 'A', 'z', 'Z', '0', '1', '9':
+				base = 2
+			}
+			bits := math.Log2(base) + math.Log2(float64(j-i+1))
+			if delta < 0 {
+				bits++
+			}
+			ms = append(ms, match{pattern: patternSequence, i: i, j: j + 1, bits: bits})
+			i = j
+			continue
+		}
+		i++
+	}
+	return
+}
+
+// sameClass reports whether two characters are both lowercase letters, uppercase letters or digits
+func sameClass(a, b rune) (ok bool) {
+	for _, in := range []func(rune) bool{
+		func(r rune) bool { return r >= 'a' && r <= 'z' },
+		func(r rune) bool { return r >= 'A' && r <= 'Z' },
+		func(r rune) bool { return r >= '0' && r <= '9' },
+	} {
+		if in(a) && in(b) {
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+// date entropies: years from 1900 to 2049 and days of the year
+var (
+	yearBits = math.Log2(150)
+	dateBits = math.Log2(150 * 365)
+)
+
+// matchDate matches years and dates of 4 to 10 characters (e.g. 1987, 19870412, 12/04/87)
+func matchDate(runes []rune) (ms []match) {
+	for i := range runes {
+		for j := i + 4; j <= len(runes) && j-i <= 10; j++ {
+			s := string(runes[i:j])
+			switch {
+			case len(s) == 4 && isYear(s):
+				ms = append(ms, match{pattern: patternDate, i: i, j: j, bits: yearBits})
+			case isDate(s):
+				bits := dateBits
+				if strings.ContainsAny(s, "-/._ ") {
+					bits++
+				}
+				ms = append(ms, match{pattern: patternDate, i: i, j: j, bits: bits})
+			}
+		}
+	}
+	return
+}
+
+// isYear reports whether s is a year between 1900 and 2049
+func isYear(s string) (ok bool) {
+	y, ok := number(s)
+	ok = ok && y >= 1900 && y <= 2049
+	return
+}
+
+// isDate reports whether s is a date, as day-month-year, month-day-year or year-month-day
+// - with an optional separator and two or four digits years
+func isDate(s string) (ok bool) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return strings.ContainsRune("-/._ ", r) })
+	switch {
+	case len(parts) == 3:
+	case len(parts) == 1 && len(s) == 8 && isYear(s[:4]):
+		parts = []string{s[:4], s[4:6], s[6:]}
+	case len(parts) == 1 && (len(s) == 6 || len(s) == 8):
+		parts = []string{s[:2], s[2:4], s[4:]}
+	default:
+		return
+	}
+
+	a, ok1 := number(parts[0])
+	b, ok2 := number(parts[1])
+	c, ok3 := number(parts[2])
+	if !ok1 || !ok2 || !ok3 {
+		return
+	}
+	day := func(d int) bool { return d >= 1 && d <= 31 }
+	month := func(m int) bool { return m >= 1 && m <= 12 }
+	year := func(p string, y int) bool { return len(p) == 2 || len(p) == 4 && y >= 1900 && y <= 2049 }
+	switch {
+	case len(parts[0]) == 4:
+		ok = year(parts[0], a) && month(b) && day(c) && len(parts[1]) <= 2 && len(parts[2]) <= 2
+	default:
+		ok = year(parts[2], c) && len(parts[0]) <= 2 && len(parts[1]) <= 2 && (day(a) && month(b) || month(a) && day(b))
+	}
+	return
+}
+
+// number parses a string of digits
+func number(s string) (n int, ok bool) {
+	if s == "" {
+		return
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return
+		}
+		n = n*10 + int(r-'0')
+	}
+	ok = true
+	return
+}
+
+// hasUpper reports whether there is an uppercase letter
+func hasUpper(runes []rune) (ok bool) {
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			ok = true
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,143 @@
+// Package strength estimates the strength of passwords from the patterns they are made of
+// - a password is split in the cheapest sequence of patterns (dictionary words, keyboard walks, repeats, sequences, dates)
+// and characters guessed by brute force, its entropy is the number of guesses in bits of that sequence
+// - inspired by zxcvbn, scores range from 0 (too guessable) to 4 (very unguessable)
+package strength
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// ScoreMax is the highest score of a password
+	ScoreMax = 4
+	// MaxLength is the number of characters taken into account, the rest of the password is estimated by brute force
+	MaxLength = 100
+)
+
+// thresholds are the entropies in bits (log2 of guesses) a password must reach to get each score above 0
+// - 10^3, 10^6, 10^8 and 10^10 guesses
+var thresholds = [ScoreMax]float64{9.97, 19.93, 26.58, 33.22}
+
+// Result is the estimation of the strength of a password
+type Result struct {
+	// Score is the strength of the password, from 0 to ScoreMax
+	Score 		int
+	// Entropy is the estimated number of guesses to crack the password, in bits
+	Entropy 	float64
+	// Warning explains the weakest pattern of the password, if any
+	Warning 	string
+	// Suggestions are advices to make the password stronger
+	Suggestions []string
+}
+
+// Estimate estimates the strength of a password
+// - inputs are user values an attacker would try first (e.g. username, email), they are matched as dictionary words
+func Estimate(password string, inputs ...string) (r Result) {
+	runes := []rune(password)
+	rest := 0
+	if len(runes) > MaxLength {
+		rest = len(runes) - MaxLength
+		runes = runes[:MaxLength]
+	}
+
+	ms := matches(runes, userDictionary(inputs), make(map[string]float64))
+	seq, bits := cheapest(runes, ms)
+	bits += float64(rest) * math.Log2(cardinality(runes))
+
+	r.Entropy = bits
+	for r.Score < ScoreMax && bits >= thresholds[r.Score] {
+		r.Score++
+	}
+	r.Warning, r.Suggestions = feedback(r.Score, runes, seq)
+	return
+}
+
+// cheapest returns the sequence of matches that covers the password with the lowest entropy
+// - characters not covered by a match are guessed by brute force
+func cheapest(runes []rune, ms []match) (seq []match, bits float64) {
+	n := len(runes)
+	if n == 0 {
+		return
+	}
+	bruteforce := math.Log2(cardinality(runes))
+
+	// best[i] is the lowest entropy of the prefix of length i, last[i] is the match ending it (nil for brute force)
+	best := make([]float64, n+1)
+	last := make([]*match, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = best[i-1] + bruteforce
+		last[i] = nil
+		for j := range ms {
+			m := &ms[j]
+			if m.j != i {
+				continue
+			}
+			if b := best[m.i] + m.bits; b < best[i] {
+				best[i] = b
+				last[i] = m
+			}
+		}
+	}
+
+	// backtrack
+	for i := n; i > 0; {
+		if last[i] == nil {
+			i--
+			continue
+		}
+		seq = append([]match{*last[i]}, seq...)
+		i = last[i].i
+	}
+	bits = best[n]
+	return
+}
+
+// cardinality returns the size of the alphabet of a brute force attack on the password
+func cardinality(runes []rune) (c float64) {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+	for _, class := range []struct {
+		ok   bool
+		size float64
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.ok {
+			c += class.size
+		}
+	}
+	if c == 0 {
+		c = 1
+	}
+	return
+}
+
+// userDictionary returns the dictionary of the user inputs
+// - the email is also matched by its local part
+func userDictionary(inputs []string) (d map[string]int) {
+	d = make(map[string]int)
+	for _, in := range inputs {
+		in = strings.ToLower(in)
+		local, _, _ := strings.Cut(in, "@")
+		for _, w := range []string{in, local} {
+			if len([]rune(w)) >= 3 {
+				d[w] = 1
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,83 @@
+package strength_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/pkg/strength"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Estimate
+func TestEstimate(t *testing.T) {
+	type input struct {password string; inputs []string}
+	type output struct {score int; warning string}
+	type testCase struct {
+		title  string
+		input  input
+		output output
+	}
+
+	cases := []testCase{
+		{title: "common password", input: input{password: "password"}, output: output{score: 0, warning: "This is a top-10 common password"}},
+		{title: "l33t common password", input: input{password: "P4ssw0rd"}, output: output{score: 0, warning: "This is a top-10 common password"}},
+		{title: "keyboard walk", input: input{password: "zxcvbnm,./"}, output: output{score: 0, warning: "Straight rows or short patterns of keys are easy to guess"}},
+		{title: "repeat", input: input{password: "xkxkxkxkxk"}, output: output{score: 1, warning: "Repeats like \"aaa\" or \"abcabc\" are easy to guess"}},
+		{title: "sequence", input: input{password: "lmnopqrstu"}, output: output{score: 0, warning: "Sequences like abc or 6543 are easy to guess"}},
+		{title: "date", input: input{password: "12/04/1987"}, output: output{score: 1, warning: "Dates are often easy to guess"}},
+		{title: "user input", input: input{password: "jsmith2024", inputs: []string{"jsmith@mail.com"}}, output: output{score: 0, warning: "Avoid using your username or email"}},
+		{title: "passphrase", input: input{password: "purple-monkey-dishwasher"}, output: output{score: 4}},
+		{title: "random", input: input{password: "xK9#mP2$vL"}, output: output{score: 4}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// act
+			r := strength.Estimate(c.input.password, c.input.inputs...)
+
+			// assert
+			require.Equal(t, c.output.score, r.Score)
+			require.Equal(t, c.output.warning, r.Warning)
+			if r.Score < 3 {
+				require.NotEmpty(t, r.Suggestions)
+			} else {
+				require.Empty(t, r.Suggestions)
+			}
+		})
+	}
+
+	t.Run("entropy grows with the length", func(t *testing.T) {
+		// act
+		short := strength.Estimate("xK9#mP2$")
+		long := strength.Estimate("xK9#mP2$vLq7")
+
+		// assert
+		require.Greater(t, long.Entropy, short.Entropy)
+	})
+
+	t.Run("long passwords are bounded", func(t *testing.T) {
+		// act
+		r := strength.Estimate(strings.Repeat("xK9#mP2$vL", 1000))
+
+		// assert
+		require.Equal(t, strength.ScoreMax, r.Score)
+	})
+
+	t.Run("long repeated passwords are estimated in bounded time", func(t *testing.T) {
+		for _, password := range []string{
+			strings.Repeat("a", strength.MaxLength),
+			strings.Repeat("ab", strength.MaxLength),
+			strings.Repeat("abc", strength.MaxLength),
+		} {
+			// arrange
+			start := time.Now()
+
+			// act
+			strength.Estimate(password)
+
+			// assert
+			require.Less(t, time.Since(start), time.Second)
+		}
+	})
+}
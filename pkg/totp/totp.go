@@ -0,0 +1,116 @@
+// Package totp implements time-based one-time passwords (RFC 6238)
+// - codes are 6 digits, HMAC-SHA1 and 30 seconds steps, the defaults supported by authenticator apps
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits is the number of digits of a code
+	Digits = 6
+	// Period is the duration of a time-step
+	Period = 30 * time.Second
+	// SecretSize is the size in bytes of the generated secrets (160 bits, as recommended by RFC 4226)
+	SecretSize = 20
+)
+
+var (
+	// ErrTOTPSecret is returned when a secret can not be generated or decoded
+	ErrTOTPSecret = errors.New("totp: invalid secret")
+)
+
+// encoding is the base32 encoding of the secrets, without padding
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret generates a new random secret, base32 encoded
+func GenerateSecret() (secret string, err error) {
+	b := make([]byte, SecretSize)
+	_, err = rand.Read(b)
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrTOTPSecret, err.Error())
+		return
+	}
+
+	secret = encoding.EncodeToString(b)
+	return
+}
+
+// Step returns the time-step of a date
+func Step(t time.Time) (step int64) {
+	step = t.Unix() / int64(Period/time.Second)
+	return
+}
+
+// Code returns the code of a base32 encoded secret for a time-step
+func Code(secret string, step int64) (code string, err error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrTOTPSecret, err.Error())
+		return
+	}
+
+	// hotp (RFC 4226)
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	// - dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	code = fmt.Sprintf("%0*d", Digits, value%1000000)
+	return
+}
+
+// Validate validates a code against the time-steps around a date
+// - skew is the number of time-steps accepted before and after the current one, to tolerate clock drift
+// - it returns the matched time-step, so callers can reject codes already used
+func Validate(secret string, code string, t time.Time, skew int) (step int64, ok bool, err error) {
+	current := Step(t)
+	for i := -int64(skew); i <= int64(skew); i++ {
+		var expected string
+		expected, err = Code(secret, current+i)
+		if err != nil {
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			step, ok = current+i, true
+			return
+		}
+	}
+
+	return
+}
+
+// URI returns the otpauth:// key uri of a secret
+// - it is the payload to encode in the enrollment QR code
+// - format: https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func URI(issuer string, account string, secret string) (uri string) {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period/time.Second)))
+
+	u := url.URL{
+		Scheme: "otpauth",
+		Host: "totp",
+		Path: "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	uri = u.String()
+	return
+}
@@ -0,0 +1,120 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/pkg/totp"
+	"github.com/stretchr/testify/require"
+)
+
+// secret is the RFC 6238 test secret for HMAC-SHA1, base32 encoded
+var secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// Tests for Code
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B test vectors (last 6 digits)
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.code, func(t *testing.T) {
+			// act
+			code, err := totp.Code(secret, totp.Step(time.Unix(c.unix, 0)))
+
+			// assert
+			require.NoError(t, err)
+			require.Equal(t, c.code, code)
+		})
+	}
+
+	t.Run("failure - invalid secret", func(t *testing.T) {
+		// act
+		_, err := totp.Code("not base32!", 1)
+
+		// assert
+		require.ErrorIs(t, err, totp.ErrTOTPSecret)
+	})
+}
+
+// Tests for Validate
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	t.Run("success - current step", func(t *testing.T) {
+		// act
+		step, ok, err := totp.Validate(secret, "050471", now, 1)
+
+		// assert
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, totp.Step(now), step)
+	})
+
+	t.Run("success - previous step within skew", func(t *testing.T) {
+		// arrange
+		code, err := totp.Code(secret, totp.Step(now)-1)
+		require.NoError(t, err)
+
+		// act
+		step, ok, err := totp.Validate(secret, code, now, 1)
+
+		// assert
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, totp.Step(now)-1, step)
+	})
+
+	t.Run("failure - step out of skew", func(t *testing.T) {
+		// arrange
+		code, err := totp.Code(secret, totp.Step(now)-2)
+		require.NoError(t, err)
+
+		// act
+		_, ok, err := totp.Validate(secret, code, now, 1)
+
+		// assert
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("failure - wrong code", func(t *testing.T) {
+		// act
+		_, ok, err := totp.Validate(secret, "000000", now, 1)
+
+		// assert
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
+
+// Tests for GenerateSecret and URI
+func TestGenerateSecret_URI(t *testing.T) {
+	// act
+	s, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	uri := totp.URI("msauth", "johndoe@gmail.com", s)
+
+	// assert
+	require.Len(t, s, 32)
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", u.Scheme)
+	require.Equal(t, "totp", u.Host)
+	require.Equal(t, "/msauth:johndoe@gmail.com", u.Path)
+	require.Equal(t, s, u.Query().Get("secret"))
+	require.Equal(t, "msauth", u.Query().Get("issuer"))
+	require.Equal(t, "6", u.Query().Get("digits"))
+	require.Equal(t, "30", u.Query().Get("period"))
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/LNMMusic/msauth/pkg/web/response"
+)
+
+// KeyFunc returns the key of a request to be rate limited
+// - in case of an empty key, the request is not rate limited
+type KeyFunc func(r *http.Request) (key string)
+
+// KeyIP returns the key of a request by its remote ip
+func KeyIP() KeyFunc {
+	return func(r *http.Request) (key string) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		key = "ip:" + host
+		return
+	}
+}
+
+// KeyJSONMaxBytes is the maximum size of the bodies read by KeyJSON
+const KeyJSONMaxBytes = 1 << 20
+
+// KeyJSON returns the key of a request by a string field of its json body (e.g. username or email)
+// - the body is restored, so it can be read again by the next handler
+// - bodies are read up to KeyJSONMaxBytes, a larger body is restored with the read error, so the next handler fails to read it too
+func KeyJSON(field string) KeyFunc {
+	return func(r *http.Request) (key string) {
+		if r.Body == nil {
+			return
+		}
+
+		// read body and restore it
+		b, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, KeyJSONMaxBytes))
+		r.Body.Close()
+		if err != nil {
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(b), &errReader{err}))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(b))
+
+		// decode field
+		m := make(map[string]any)
+		if err = json.Unmarshal(b, &m); err != nil {
+			return
+		}
+		value, ok := m[field].(string)
+		if !ok || value == "" {
+			return
+		}
+
+		key = field + ":" + strings.ToLower(value)
+		return
+	}
+}
+
+// Middleware returns a middleware that rate limits requests by key
+// - requests exceeding the limit are responded with 429 and a Retry-After header in seconds
+func Middleware(l Limiter, keyFn KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// key
+			key := keyFn(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// limit
+			retryAfter, err := l.Allow(key)
+			if err != nil {
+				switch {
+				case errors.Is(err, ErrRateLimitExceeded):
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+					response.JSON(w, http.StatusTooManyRequests, "too many requests")
+				default:
+					response.JSON(w, http.StatusInternalServerError, "internal server error")
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// errReader is a reader that always fails
+type errReader struct {
+	// err is the error of the reads
+	err error
+}
+
+// Read returns the error of the reader
+func (e *errReader) Read(p []byte) (n int, err error) {
+	err = e.err
+	return
+}
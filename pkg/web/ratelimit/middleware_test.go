@@ -0,0 +1,112 @@
+package ratelimit_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LNMMusic/msauth/pkg/web/ratelimit"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Middleware
+func TestMiddleware(t *testing.T) {
+	// next handler echoes the body, to check it is restored after reading the key
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		w.Write(b)
+	})
+
+	t.Run("success - request allowed by ip", func(t *testing.T) {
+		// arrange
+		l, err := ratelimit.NewLimiterSlidingWindow(ratelimit.NewStoreLocal(), 1, time.Minute)
+		require.NoError(t, err)
+		hd := ratelimit.Middleware(l, ratelimit.KeyIP())(next)
+
+		// act
+		req := httptest.NewRequest(http.MethodPost, "/signin", strings.NewReader(`{"username":"johndoe"}`))
+		req.RemoteAddr = "10.0.0.1:1234"
+		res := httptest.NewRecorder()
+		hd.ServeHTTP(res, req)
+
+		// assert
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, `{"username":"johndoe"}`, res.Body.String())
+	})
+
+	t.Run("failure - 429 with retry after by ip", func(t *testing.T) {
+		// arrange
+		l, err := ratelimit.NewLimiterSlidingWindow(ratelimit.NewStoreLocal(), 1, time.Minute)
+		require.NoError(t, err)
+		hd := ratelimit.Middleware(l, ratelimit.KeyIP())(next)
+		req := httptest.NewRequest(http.MethodPost, "/signin", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		hd.ServeHTTP(httptest.NewRecorder(), req)
+
+		// act
+		req = httptest.NewRequest(http.MethodPost, "/signin", nil)
+		req.RemoteAddr = "10.0.0.1:5678"
+		res := httptest.NewRecorder()
+		hd.ServeHTTP(res, req)
+
+		// assert
+		require.Equal(t, http.StatusTooManyRequests, res.Code)
+		require.Equal(t, "60", res.Header().Get("Retry-After"))
+		require.JSONEq(t, `"too many requests"`, res.Body.String())
+	})
+
+	t.Run("failure - 429 by json field, body restored for the next handler", func(t *testing.T) {
+		// arrange
+		l, err := ratelimit.NewLimiterTokenBucket(ratelimit.NewStoreLocal(), 1, 1)
+		require.NoError(t, err)
+		hd := ratelimit.Middleware(l, ratelimit.KeyJSON("username"))(next)
+
+		// act
+		req := httptest.NewRequest(http.MethodPost, "/signin", strings.NewReader(`{"username":"johndoe"}`))
+		res := httptest.NewRecorder()
+		hd.ServeHTTP(res, req)
+		req = httptest.NewRequest(http.MethodPost, "/signin", strings.NewReader(`{"username":"JohnDoe"}`))
+		resLimited := httptest.NewRecorder()
+		hd.ServeHTTP(resLimited, req)
+
+		// assert
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, `{"username":"johndoe"}`, res.Body.String())
+		require.Equal(t, http.StatusTooManyRequests, resLimited.Code)
+		require.Equal(t, "1", resLimited.Header().Get("Retry-After"))
+	})
+
+	t.Run("success - large bodies are not keyed and fail to be read by the next handler", func(t *testing.T) {
+		// arrange
+		body := `{"username":"johndoe","padding":"` + strings.Repeat("a", ratelimit.KeyJSONMaxBytes) + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/signin", strings.NewReader(body))
+
+		// act
+		key := ratelimit.KeyJSON("username")(req)
+		_, err := io.ReadAll(req.Body)
+
+		// assert
+		require.Empty(t, key)
+		var maxBytesErr *http.MaxBytesError
+		require.ErrorAs(t, err, &maxBytesErr)
+	})
+
+	t.Run("success - requests without key are not limited", func(t *testing.T) {
+		// arrange
+		l, err := ratelimit.NewLimiterTokenBucket(ratelimit.NewStoreLocal(), 1, 1)
+		require.NoError(t, err)
+		hd := ratelimit.Middleware(l, ratelimit.KeyJSON("email"))(next)
+
+		// act & assert
+		for i := 0; i < 3; i++ {
+			req := httptest.NewRequest(http.MethodPost, "/signin", strings.NewReader(`{"username":"johndoe"}`))
+			res := httptest.NewRecorder()
+			hd.ServeHTTP(res, req)
+			require.Equal(t, http.StatusOK, res.Code)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrRateLimitExceeded is returned when a key exceeded its rate limit
+	ErrRateLimitExceeded = errors.New("ratelimit: rate limit exceeded")
+	// ErrRateLimitStore is returned when the store fails
+	ErrRateLimitStore = errors.New("ratelimit: store error")
+	// ErrRateLimitConfig is returned when a limiter is configured with invalid values
+	ErrRateLimitConfig = errors.New("ratelimit: invalid config")
+)
+
+// Limiter interface to handle rate limiting of requests by key (ip, username, email, etc)
+type Limiter interface {
+	// Allow registers a request for a key and checks if it is allowed
+	// - in case of exceeding the limit, it returns ErrRateLimitExceeded and the time to wait before retrying
+	Allow(key string) (retryAfter time.Duration, err error)
+}
+
+// State is the state of a key in the store
+// - each strategy uses its own fields
+type State struct {
+	// Tokens is the number of tokens left in the bucket (token bucket)
+	Tokens 	float64
+	// Last is the date of the last refill of the bucket (token bucket)
+	Last 	time.Time
+	// Hits are the dates of the requests within the window (sliding window)
+	Hits 	[]time.Time
+	// Expire is the date after which the state is no longer relevant and can be evicted
+	Expire 	time.Time
+}
+
+// Store interface to handle the state of the keys of a Limiter
+type Store interface {
+	// Update applies fn to the state of a key atomically
+	// - in case of the key not existing, fn receives a zero state
+	Update(key string, fn func(s *State)) (err error)
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+// NewLimiterSlidingWindow returns a new LimiterSlidingWindow
+// - limit is the maximum number of requests allowed within the window, at least 1
+// - window is the duration of the window, greater than 0
+func NewLimiterSlidingWindow(st Store, limit int, window time.Duration) (l *LimiterSlidingWindow, err error) {
+	if limit < 1 {
+		err = fmt.Errorf("%w. limit should be at least 1, got %d", ErrRateLimitConfig, limit)
+		return
+	}
+	if window <= 0 {
+		err = fmt.Errorf("%w. window should be greater than 0, got %s", ErrRateLimitConfig, window)
+		return
+	}
+
+	l = &LimiterSlidingWindow{
+		st: st,
+		limit: limit,
+		window: window,
+		now: time.Now,
+	}
+	return
+}
+
+// LimiterSlidingWindow is the sliding window (log) implementation of the Limiter interface
+// - each key keeps the dates of its requests within the last window
+type LimiterSlidingWindow struct {
+	// st is the store of the request logs
+	st Store
+	// limit is the maximum number of requests allowed within the window
+	limit int
+	// window is the duration of the window
+	window time.Duration
+	// now returns the current date
+	now func() time.Time
+}
+
+// Allow registers a request for a key and checks if it is allowed
+func (l *LimiterSlidingWindow) Allow(key string) (retryAfter time.Duration, err error) {
+	now := l.now()
+
+	var allowed bool
+	err = l.st.Update(key, func(s *State) {
+		// slide window
+		start := now.Add(-l.window)
+		var i int
+		for i < len(s.Hits) && !s.Hits[i].After(start) {
+			i++
+		}
+		s.Hits = s.Hits[i:]
+
+		// check limit
+		if len(s.Hits) >= l.limit {
+			retryAfter = s.Hits[len(s.Hits) - l.limit].Add(l.window).Sub(now)
+			return
+		}
+		s.Hits = append(s.Hits, now)
+		s.Expire = now.Add(l.window)
+		allowed = true
+	})
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrRateLimitStore, err.Error())
+		return
+	}
+
+	if !allowed {
+		err = ErrRateLimitExceeded
+		return
+	}
+
+	return
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for LimiterSlidingWindow.Allow
+func TestLimiterSlidingWindow_Allow(t *testing.T) {
+	t.Run("success - requests within the limit are allowed", func(t *testing.T) {
+		// arrange
+		now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		l, err := NewLimiterSlidingWindow(NewStoreLocal(), 3, time.Minute)
+		require.NoError(t, err)
+		l.now = func() time.Time { return now }
+
+		// act & assert
+		for i := 0; i < 3; i++ {
+			retryAfter, err := l.Allow("email:johndoe@gmail.com")
+			require.NoError(t, err)
+			require.Zero(t, retryAfter)
+		}
+	})
+
+	t.Run("failure - requests exceeding the limit are rejected until the window slides", func(t *testing.T) {
+		// arrange
+		now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		l, err := NewLimiterSlidingWindow(NewStoreLocal(), 2, time.Minute)
+		require.NoError(t, err)
+		l.now = func() time.Time { return now }
+		_, _ = l.Allow("email:johndoe@gmail.com")
+		now = now.Add(20 * time.Second)
+		_, _ = l.Allow("email:johndoe@gmail.com")
+
+		// act
+		now = now.Add(10 * time.Second)
+		retryAfter, err := l.Allow("email:johndoe@gmail.com")
+
+		// assert
+		require.ErrorIs(t, err, ErrRateLimitExceeded)
+		require.Equal(t, 30 * time.Second, retryAfter)
+
+		// act: the first request leaves the window
+		now = now.Add(30 * time.Second)
+		retryAfter, err = l.Allow("email:johndoe@gmail.com")
+
+		// assert
+		require.NoError(t, err)
+		require.Zero(t, retryAfter)
+	})
+}
+
+// Tests for NewLimiterSlidingWindow
+func TestNewLimiterSlidingWindow(t *testing.T) {
+	type input struct {limit int; window time.Duration}
+	type testCase struct {
+		title  string
+		input  input
+		errMsg string
+	}
+
+	cases := []testCase{
+		{title: "zero limit", input: input{limit: 0, window: time.Minute}, errMsg: "ratelimit: invalid config. limit should be at least 1, got 0"},
+		{title: "negative limit", input: input{limit: -1, window: time.Minute}, errMsg: "ratelimit: invalid config. limit should be at least 1, got -1"},
+		{title: "zero window", input: input{limit: 1, window: 0}, errMsg: "ratelimit: invalid config. window should be greater than 0, got 0s"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// act
+			l, err := NewLimiterSlidingWindow(NewStoreLocal(), c.input.limit, c.input.window)
+
+			// assert
+			require.ErrorIs(t, err, ErrRateLimitConfig)
+			require.EqualError(t, err, c.errMsg)
+			require.Nil(t, l)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// NewStoreLocal returns a new StoreLocal
+func NewStoreLocal() *StoreLocal {
+	return &StoreLocal{
+		db: make(map[string]*State),
+		mu: &sync.Mutex{},
+		pruneAt: 1024,
+		now: time.Now,
+	}
+}
+
+// StoreLocal is an in-memory implementation of the Store interface
+type StoreLocal struct {
+	// db is the map of states
+	// - key: limiter key
+	// - value: state
+	db map[string]*State
+	// mu is the mutex of the db
+	mu *sync.Mutex
+	// pruneAt is the size of the db that triggers the next eviction of expired states
+	pruneAt int
+	// now returns the current date
+	now func() time.Time
+}
+
+// Update applies fn to the state of a key atomically
+func (s *StoreLocal) Update(key string, fn func(st *State)) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// evict expired states (amortized, only when the db doubles its size)
+	if len(s.db) >= s.pruneAt {
+		now := s.now()
+		for k, st := range s.db {
+			if now.After(st.Expire) {
+				delete(s.db, k)
+			}
+		}
+		s.pruneAt = max(1024, 2 * len(s.db))
+	}
+
+	// update state
+	st, ok := s.db[key]
+	if !ok {
+		st = &State{}
+		s.db[key] = st
+	}
+	fn(st)
+
+	return
+}
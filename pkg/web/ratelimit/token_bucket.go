@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// NewLimiterTokenBucket returns a new LimiterTokenBucket
+// - rate is the number of tokens refilled per second, greater than 0 and finite
+// - burst is the capacity of the bucket, at least 1
+func NewLimiterTokenBucket(st Store, rate float64, burst int) (l *LimiterTokenBucket, err error) {
+	if !(rate > 0) || math.IsInf(rate, 1) {
+		err = fmt.Errorf("%w. rate should be greater than 0 and finite, got %v", ErrRateLimitConfig, rate)
+		return
+	}
+	if burst < 1 {
+		err = fmt.Errorf("%w. burst should be at least 1, got %d", ErrRateLimitConfig, burst)
+		return
+	}
+
+	l = &LimiterTokenBucket{
+		st: st,
+		rate: rate,
+		burst: float64(burst),
+		now: time.Now,
+	}
+	return
+}
+
+// LimiterTokenBucket is the token bucket implementation of the Limiter interface
+// - each key has a bucket of burst tokens refilled at rate tokens per second
+// - each request consumes a token
+type LimiterTokenBucket struct {
+	// st is the store of the buckets
+	st Store
+	// rate is the number of tokens refilled per second
+	rate float64
+	// burst is the capacity of the bucket
+	burst float64
+	// now returns the current date
+	now func() time.Time
+}
+
+// Allow registers a request for a key and checks if it is allowed
+func (l *LimiterTokenBucket) Allow(key string) (retryAfter time.Duration, err error) {
+	now := l.now()
+
+	var allowed bool
+	err = l.st.Update(key, func(s *State) {
+		// refill
+		if s.Last.IsZero() {
+			s.Tokens = l.burst
+		} else {
+			s.Tokens = math.Min(l.burst, s.Tokens + now.Sub(s.Last).Seconds() * l.rate)
+		}
+		s.Last = now
+
+		// consume
+		if s.Tokens >= 1 {
+			s.Tokens--
+			allowed = true
+		} else {
+			retryAfter = time.Duration((1 - s.Tokens) / l.rate * float64(time.Second))
+		}
+
+		// the bucket is full again (and so irrelevant) after refilling the missing tokens
+		s.Expire = now.Add(time.Duration((l.burst - s.Tokens) / l.rate * float64(time.Second)))
+	})
+	if err != nil {
+		err = fmt.Errorf("%w. %s", ErrRateLimitStore, err.Error())
+		return
+	}
+
+	if !allowed {
+		err = ErrRateLimitExceeded
+		return
+	}
+
+	return
+}
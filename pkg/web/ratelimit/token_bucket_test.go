@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for LimiterTokenBucket.Allow
+func TestLimiterTokenBucket_Allow(t *testing.T) {
+	t.Run("success - requests within the burst are allowed", func(t *testing.T) {
+		// arrange
+		now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		l, err := NewLimiterTokenBucket(NewStoreLocal(), 1, 3)
+		require.NoError(t, err)
+		l.now = func() time.Time { return now }
+
+		// act & assert
+		for i := 0; i < 3; i++ {
+			retryAfter, err := l.Allow("ip:127.0.0.1")
+			require.NoError(t, err)
+			require.Zero(t, retryAfter)
+		}
+	})
+
+	t.Run("failure - requests exceeding the burst are rejected until refilled", func(t *testing.T) {
+		// arrange
+		now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		l, err := NewLimiterTokenBucket(NewStoreLocal(), 0.5, 2)
+		require.NoError(t, err)
+		l.now = func() time.Time { return now }
+		_, _ = l.Allow("ip:127.0.0.1")
+		_, _ = l.Allow("ip:127.0.0.1")
+
+		// act
+		retryAfter, err := l.Allow("ip:127.0.0.1")
+
+		// assert
+		require.ErrorIs(t, err, ErrRateLimitExceeded)
+		require.Equal(t, 2 * time.Second, retryAfter)
+
+		// act: refill
+		now = now.Add(2 * time.Second)
+		retryAfter, err = l.Allow("ip:127.0.0.1")
+
+		// assert
+		require.NoError(t, err)
+		require.Zero(t, retryAfter)
+	})
+
+	t.Run("success - keys have independent buckets", func(t *testing.T) {
+		// arrange
+		now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		l, err := NewLimiterTokenBucket(NewStoreLocal(), 1, 1)
+		require.NoError(t, err)
+		l.now = func() time.Time { return now }
+		_, _ = l.Allow("username:johndoe")
+
+		// act
+		_, err = l.Allow("username:janedoe")
+
+		// assert
+		require.NoError(t, err)
+	})
+}
+
+// Tests for NewLimiterTokenBucket
+func TestNewLimiterTokenBucket(t *testing.T) {
+	type input struct {rate float64; burst int}
+	type testCase struct {
+		title  string
+		input  input
+		errMsg string
+	}
+
+	cases := []testCase{
+		{title: "zero rate", input: input{rate: 0, burst: 1}, errMsg: "ratelimit: invalid config. rate should be greater than 0 and finite, got 0"},
+		{title: "negative rate", input: input{rate: -1, burst: 1}, errMsg: "ratelimit: invalid config. rate should be greater than 0 and finite, got -1"},
+		{title: "not a number rate", input: input{rate: math.NaN(), burst: 1}, errMsg: "ratelimit: invalid config. rate should be greater than 0 and finite, got NaN"},
+		{title: "infinite rate", input: input{rate: math.Inf(1), burst: 1}, errMsg: "ratelimit: invalid config. rate should be greater than 0 and finite, got +Inf"},
+		{title: "zero burst", input: input{rate: 1, burst: 0}, errMsg: "ratelimit: invalid config. burst should be at least 1, got 0"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			// act
+			l, err := NewLimiterTokenBucket(NewStoreLocal(), c.input.rate, c.input.burst)
+
+			// assert
+			require.ErrorIs(t, err, ErrRateLimitConfig)
+			require.EqualError(t, err, c.errMsg)
+			require.Nil(t, l)
+		})
+	}
+}